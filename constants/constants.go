@@ -1,18 +1,111 @@
 package constants
 
 const LOGGING_LEVEL string = "error"
+const LOGGING_LEVEL_ENV string = "SWISSWALLET_LOG_LEVEL"
+
+const JSON_LOGGING_FORMAT string = "json"
+const TEXT_LOGGING_FORMAT string = "text"
 
 const AES_BLOCKSIZE_ERROR string = "AES BlockSize error: ciphertext too short"
 const AES_PLAINTEXT_NOT_MULTIPLE_ERROR string = "Plaintext is not a multiple of the block size"
 
+const BIP38_INVALID_KEY_ERROR string = "Invalid BIP38 encrypted private key"
+const BIP38_WRONG_PASSPHRASE_ERROR string = "Wrong BIP38 passphrase: the decrypted key does not match the address hash"
+
 const BAD_REQUEST_DIFFICULTY_ERROR string = "Provided difficulty not supported"
 
+const VERIFY_MATCH string = "MATCH"
+const VERIFY_NO_MATCH string = "NO MATCH"
+
+// Memory bandwidth of an attacker with about a thousand GPUs, and the crack
+// times below which a password is refused or warned about.
+const ATTACKER_BYTES_PER_SECOND float64 = 1e15
+const PASSWORD_REFUSE_SECONDS float64 = 100 * 365 * 24 * 3600
+const PASSWORD_WARN_SECONDS float64 = 1e6 * 365 * 24 * 3600
+
+const NORMALIZED_WARNING string = "Warning: the %s changes under NFKD normalization, a wallet generated from it by an older version needs -no-normalize"
+
+const SALT_WHITESPACE_WARNING string = "Warning: the salt starts or ends with whitespace, it is part of the salt and must be typed again exactly"
+
+const KEYFILE_REQUIRED string = "Keyfile: required, the password and salt alone do not derive this wallet"
+
+const SELFTEST_FAILED_ERROR string = "Refusing to derive keys"
+
 const GENERATE_MODE string = "generate"
 const DECRYPT_MODE string = "decrypt"
 const ENCRYPT_MODE string = "encrypt"
+const SELFTEST_MODE string = "selftest"
+const VERIFY_MODE string = "verify"
+const SPLIT_MODE string = "split"
+const COMBINE_MODE string = "combine"
+const MULTISIG_MODE string = "multisig"
+const SIGN_TX_MODE string = "sign-tx"
+const SIGN_PSBT_MODE string = "sign-psbt"
+const SIGN_MESSAGE_MODE string = "sign-message"
+const VERIFY_MESSAGE_MODE string = "verify-message"
+const VALIDATE_MODE string = "validate"
+const PASSPHRASE_MODE string = "passphrase"
 
 const RAW_OUTPUT string = "raw"
 const MNEMONIC_OUTPUT string = "mnemonic"
+const KEYSTORE_OUTPUT string = "keystore"
+
+const BIP38_PREFIX string = "6P"
+const BIP38_NON_EC_MULTIPLY_MODE string = "non-ec"
+
+const HTML_PAPER_FORMAT string = "html"
+const SVG_PAPER_FORMAT string = "svg"
+const PDF_PAPER_FORMAT string = "pdf"
+
+const QR_LOW_LEVEL string = "low"
+const QR_MEDIUM_LEVEL string = "medium"
+const QR_HIGH_LEVEL string = "high"
+const QR_HIGHEST_LEVEL string = "highest"
+
+const NO_SEEDQR_FORMAT string = "none"
+const STANDARD_SEEDQR_FORMAT string = "standard"
+const COMPACT_SEEDQR_FORMAT string = "compact"
+
+const SLIP39_MASTER_SECRET_LENGTH int = 32
+const ENTROPY_SLIP39_SECRET string = "entropy"
+const SEED_SLIP39_SECRET string = "seed"
+
+const EFF_LARGE_WORDLIST string = "eff-large"
+const EFF_SHORT_WORDLIST string = "eff-short"
+const BIP39_WORDLIST string = "bip39"
+const PASSPHRASE_MAX_WORDS int = 64
+
+const ETHEREUM_DERIVATION_PATH string = "m/44'/60'/0'/0/0"
+const MULTISIG_DERIVATION_PATH string = "m/48'/%d'/0'/2'"
+const MULTISIG_MAX_COSIGNERS int = 20
+
+const REQUIRED_SALT_POLICY string = "required"
+const EMAIL_SALT_POLICY string = "email"
+const VERBATIM_SALT_POLICY string = "verbatim"
+
+const REAL_PROFILE string = "real"
+const DECOY_PROFILE string = "decoy"
+const MAX_DECOYS int = 10
+const DEFAULT_DURESS_SUFFIX string = "!"
+
+const MAX_VANITY_LENGTH int = 6
+const MAX_MNEMONIC_VANITY_LENGTH int = 4
+const VANITY_MAX_TRIES_FACTOR uint64 = 64
+
+const P2PKH_ADDRESS_TYPE string = "p2pkh"
+const P2SH_P2WPKH_ADDRESS_TYPE string = "p2sh-p2wpkh"
+const P2WPKH_ADDRESS_TYPE string = "p2wpkh"
+const P2TR_ADDRESS_TYPE string = "p2tr"
+
+const BIP44_DERIVATION_PATH string = "m/44'/%d'/0'"
+const BIP49_DERIVATION_PATH string = "m/49'/%d'/0'"
+const BIP84_DERIVATION_PATH string = "m/84'/%d'/0'"
+const BIP86_DERIVATION_PATH string = "m/86'/%d'/0'"
+
+const SCRYPT_KEYSTORE_KDF string = "scrypt"
+const PBKDF2_KEYSTORE_KDF string = "pbkdf2"
+const KEYSTORE_PBKDF2_ITERATIONS int = 262144
+const KEYSTORE_VERSION int = 3
 
 const ENGLISH_LANGUAGE string = "english"
 const SPANISH_LANGUAGE string = "spanish"
@@ -2,6 +2,7 @@ package controller
 
 import (
 	"errors"
+	"fmt"
 	. "swisswallet/constants"
 	"swisswallet/logger"
 	"swisswallet/model"
@@ -25,10 +26,19 @@ func NewController(service service.Service, simpleUtils utils.SimpleUtils, logge
 
 func (c *Controller) RunSwissWallet() {
 	arguments, mode, nonFlagArguments := c.simpleUtils.GetArguments()
+	c.logger.AddSecrets(arguments.Password, arguments.Salt, arguments.Mnemonic, arguments.Key, arguments.KeystorePassword, arguments.Bip38Passphrase, arguments.Slip39Passphrase)
+	c.logger.AddSecrets(arguments.Shares...)
+
+	err := c.ConfigureLogger(arguments)
+	if err != nil {
+		c.simpleUtils.ExitWithError(err)
+	}
+
 	c.logger.LogOnEntryWithContext(c.logger.GetContext(), arguments, nonFlagArguments)
 
-	//  || arguments.SaltIsEmpry()
-	if (arguments.GetCurrencyCode() == CurrencyCode["unknown"]) || arguments.PasswordIsEmpry() || !c.simpleUtils.IsEmptyArray(nonFlagArguments) {
+	passwordRequired := !c.simpleUtils.StringInSlice(mode, c.simpleUtils.GetPasswordlessModes())
+
+	if (arguments.GetCurrencyCode() == CurrencyCode["unknown"]) || (passwordRequired && arguments.PasswordIsEmpry()) || !c.simpleUtils.IsEmptyArray(nonFlagArguments) {
 		err := errors.New("Wrong arguments")
 		c.logger.LogOnBadRequestErrorWithContext(c.logger.GetContext(), err)
 		c.simpleUtils.PrintHelpParamsAndExit(mode)
@@ -38,13 +48,58 @@ func (c *Controller) RunSwissWallet() {
 	c.logger.LogOnExitWithContext(c.logger.GetContext())
 }
 
+func (c *Controller) ConfigureLogger(arguments *model.Arguments) error {
+	err := c.simpleUtils.CheckIfSupported(arguments.LogLevel, c.simpleUtils.GetSupportedLoggingLevels())
+	if err != nil {
+		c.logger.LogOnBadRequestErrorWithContext(c.logger.GetContext(), err)
+		return err
+	}
+
+	err = c.simpleUtils.CheckIfSupported(arguments.LogFormat, c.simpleUtils.GetSupportedLoggingFormats())
+	if err != nil {
+		c.logger.LogOnBadRequestErrorWithContext(c.logger.GetContext(), err)
+		return err
+	}
+
+	if !arguments.LogFileIsEmpty() {
+		err = c.logger.SetLoggingFile(arguments.LogFile)
+		if err != nil {
+			c.logger.LogOnBadRequestErrorWithContext(c.logger.GetContext(), err)
+			return err
+		}
+	}
+
+	c.logger.SetLoggingFormat(arguments.LogFormat)
+	c.logger.SetLoggingLevel(arguments.LogLevel)
+	return err
+}
+
 func (c *Controller) SwitchFunctionByMode(mode string, arguments *model.Arguments) {
 	c.logger.LogOnEntryWithContext(c.logger.GetContext(), mode, arguments)
 
 	var mapModeToFunction = map[string]func(model.Arguments) error{
-		GENERATE_MODE: c.service.GenerateWallet,
-		DECRYPT_MODE:  c.service.DecryptWallet,
-		ENCRYPT_MODE:  c.service.EncryptWallet,
+		GENERATE_MODE:       c.service.GenerateWallet,
+		DECRYPT_MODE:        c.service.DecryptWallet,
+		ENCRYPT_MODE:        c.service.EncryptWallet,
+		VERIFY_MODE:         c.service.VerifyWallet,
+		SPLIT_MODE:          c.service.SplitWallet,
+		COMBINE_MODE:        c.service.CombineWallet,
+		MULTISIG_MODE:       c.service.MultisigWallet,
+		SIGN_TX_MODE:        c.service.SignTransaction,
+		SIGN_PSBT_MODE:      c.service.SignPsbt,
+		SIGN_MESSAGE_MODE:   c.service.SignMessage,
+		VERIFY_MESSAGE_MODE: c.service.VerifyMessage,
+		VALIDATE_MODE:       c.service.ValidateAddress,
+		PASSPHRASE_MODE:     c.service.GeneratePassphrase,
+		SELFTEST_MODE:       c.service.RunSelfTest,
+	}
+
+	if mode != SELFTEST_MODE {
+		err := c.service.SelfTest()
+		if err != nil {
+			c.logger.LogOnInternalErrorWithContext(c.logger.GetContext(), err)
+			c.simpleUtils.ExitWithError(errors.New(fmt.Sprintf("%s: %s", SELFTEST_FAILED_ERROR, err)))
+		}
 	}
 
 	err := mapModeToFunction[mode](*arguments)
@@ -2,6 +2,7 @@ package logger
 
 import (
 	"fmt"
+	"os"
 	"runtime"
 
 	"github.com/sirupsen/logrus"
@@ -14,13 +15,15 @@ type Event struct {
 
 type Logger struct {
 	*logrus.Logger
+	redactingFormatter *RedactingFormatter
 }
 
 func NewLogger() *Logger {
 	var baseLogger = logrus.New()
-	var standardLogger = &Logger{baseLogger}
+	var redactingFormatter = &RedactingFormatter{Formatter: &logrus.JSONFormatter{DisableHTMLEscape: true}}
+	var standardLogger = &Logger{baseLogger, redactingFormatter}
 
-	standardLogger.Formatter = &logrus.JSONFormatter{DisableHTMLEscape: true}
+	standardLogger.Formatter = redactingFormatter
 
 	return standardLogger
 }
@@ -55,6 +58,28 @@ func (l *Logger) SetLoggingLevel(level string) {
 	}
 }
 
+func (l *Logger) SetLoggingFormat(format string) {
+	switch format {
+	case "text":
+		l.redactingFormatter.Formatter = &logrus.TextFormatter{DisableColors: true, FullTimestamp: true}
+	default:
+		l.redactingFormatter.Formatter = &logrus.JSONFormatter{DisableHTMLEscape: true}
+	}
+}
+
+func (l *Logger) SetLoggingFile(path string) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	l.SetOutput(file)
+	return nil
+}
+
+func (l *Logger) AddSecrets(secrets ...string) {
+	l.redactingFormatter.AddSecrets(secrets...)
+}
+
 func (l *Logger) GetContext() string {
 	pc := make([]uintptr, 15)
 	n := runtime.Callers(2, pc)
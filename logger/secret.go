@@ -0,0 +1,130 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+)
+
+const REDACTED_SECRET string = "[REDACTED]"
+
+// Secret wraps a sensitive string so that it is masked whatever verb is used
+// to format it. Passwords, salts, mnemonics and keys must be logged as Secret.
+type Secret string
+
+func (s Secret) String() string {
+	return REDACTED_SECRET
+}
+
+func (s Secret) Format(f fmt.State, verb rune) {
+	io.WriteString(f, REDACTED_SECRET)
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(REDACTED_SECRET)
+}
+
+// SecretBytes is the []byte counterpart of Secret, used for KDF outputs,
+// entropy, seeds and AES material.
+type SecretBytes []byte
+
+func (s SecretBytes) String() string {
+	return REDACTED_SECRET
+}
+
+func (s SecretBytes) Format(f fmt.State, verb rune) {
+	io.WriteString(f, REDACTED_SECRET)
+}
+
+func (s SecretBytes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(REDACTED_SECRET)
+}
+
+// MIN_REDACTED_SECRET_LENGTH is the shortest secret the RedactingFormatter
+// looks for. Shorter secrets would match inside ordinary words, making the
+// log unreadable and showing which characters they contain, so they rely on
+// the Secret and SecretBytes wrappers alone.
+const MIN_REDACTED_SECRET_LENGTH int = 4
+
+// RedactingFormatter is a last line of defence for values that reach the log
+// sink without being wrapped in a Secret, e.g. inside error messages. Every
+// registered secret is replaced where it appears as a whole value, not as
+// part of a longer word, before the wrapped formatter runs.
+type RedactingFormatter struct {
+	logrus.Formatter
+	secrets []string
+	mutex   sync.RWMutex
+}
+
+func (r *RedactingFormatter) AddSecrets(secrets ...string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, secret := range secrets {
+		if utf8.RuneCountInString(secret) >= MIN_REDACTED_SECRET_LENGTH {
+			r.secrets = append(r.secrets, secret)
+		}
+	}
+	// Longest first, so a short secret contained in a longer one does not
+	// break the longer one apart before it is replaced.
+	sort.SliceStable(r.secrets, func(i, j int) bool {
+		return len(r.secrets[i]) > len(r.secrets[j])
+	})
+}
+
+func (r *RedactingFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	entry.Message = r.redact(entry.Message)
+	for key, value := range entry.Data {
+		if str, ok := value.(string); ok {
+			entry.Data[key] = r.redact(str)
+		}
+	}
+	return r.Formatter.Format(entry)
+}
+
+func (r *RedactingFormatter) redact(str string) string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, secret := range r.secrets {
+		str = replaceWholeValue(str, secret, REDACTED_SECRET)
+	}
+	return str
+}
+
+// replaceWholeValue replaces the occurrences of value in str that are not
+// preceded or followed by a letter or a digit.
+func replaceWholeValue(str string, value string, replacement string) string {
+	var replaced strings.Builder
+	for {
+		i := strings.Index(str, value)
+		if i < 0 {
+			break
+		}
+		end := i + len(value)
+		before, _ := utf8.DecodeLastRuneInString(str[:i])
+		after, _ := utf8.DecodeRuneInString(str[end:])
+		if isWordRune(before) || isWordRune(after) {
+			_, size := utf8.DecodeRuneInString(str[i:])
+			replaced.WriteString(str[:i+size])
+			str = str[i+size:]
+			continue
+		}
+		replaced.WriteString(str[:i])
+		replaced.WriteString(replacement)
+		str = str[end:]
+	}
+	replaced.WriteString(str)
+	return replaced.String()
+}
+
+func isWordRune(r rune) bool {
+	return r != utf8.RuneError && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
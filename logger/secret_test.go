@@ -0,0 +1,100 @@
+package logger_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"swisswallet/logger"
+	"swisswallet/model"
+)
+
+func TestTraceLogDoesNotLeakSecrets(t *testing.T) {
+	arguments := model.Arguments{
+		Password:         "hunter22",
+		Salt:             "s4lt@example.com",
+		Mnemonic:         "abandon ability able about above absent absorb abstract absurd abuse access accident",
+		Key:              "91d44287fb2bc7455cb5ba3b3fda833e29b1044b52325bec5428069631223499",
+		KeystorePassword: "kspass",
+		Bip38Passphrase:  "Satoshi",
+		Slip39Passphrase: "TREZOR",
+		Shares:           []string{"academic acid acrobat"},
+	}
+	secretBytes := []byte{0xde, 0xad, 0xbe, 0xef}
+	secrets := []string{
+		arguments.Password, arguments.Salt, arguments.Mnemonic, arguments.Key, arguments.KeystorePassword,
+		arguments.Bip38Passphrase, arguments.Slip39Passphrase, arguments.Shares[0], hex.EncodeToString(secretBytes),
+	}
+
+	for _, format := range []string{"json", "text"} {
+		var buffer bytes.Buffer
+		l := logger.NewLogger()
+		l.SetOutput(&buffer)
+		l.SetLoggingLevel("trace")
+		l.SetLoggingFormat(format)
+		l.AddSecrets(arguments.Password, arguments.Salt, arguments.Mnemonic, arguments.Key, arguments.KeystorePassword, arguments.Bip38Passphrase, arguments.Slip39Passphrase)
+		l.AddSecrets(arguments.Shares...)
+
+		l.LogOnEntryWithContext(l.GetContext(), arguments)
+		l.LogOnExitWithContext(l.GetContext(), logger.Secret(arguments.Mnemonic), logger.SecretBytes(secretBytes))
+		l.Tracef("%s %v %q %x", logger.Secret(arguments.Key), logger.Secret(arguments.Password), logger.Secret(arguments.Salt), logger.SecretBytes(secretBytes))
+		l.LogOnErrorWithContext(l.GetContext(), errors.New("wrong password "+arguments.Password+" for salt "+arguments.Salt))
+		l.WithField("passphrase", arguments.Bip38Passphrase).Trace("unwrapped field")
+
+		output := buffer.String()
+		if !strings.Contains(output, logger.REDACTED_SECRET) {
+			t.Fatalf("%s: nothing was redacted: %s", format, output)
+		}
+		for _, secret := range secrets {
+			if strings.Contains(output, secret) {
+				t.Errorf("%s: secret %q leaked into the log: %s", format, secret, output)
+			}
+		}
+	}
+}
+
+func TestShortSecretsAreNotRedactedInsideWords(t *testing.T) {
+	arguments := model.Arguments{Password: "a", Salt: "x", Currency: "ethereum", Difficulty: "minimum", Language: "english", Output: "raw"}
+	redactedInsideWord := regexp.MustCompile(`\w\[REDACTED\]|\[REDACTED\]\w`)
+
+	for _, format := range []string{"json", "text"} {
+		var buffer bytes.Buffer
+		l := logger.NewLogger()
+		l.SetOutput(&buffer)
+		l.SetLoggingLevel("trace")
+		l.SetLoggingFormat(format)
+		l.AddSecrets(arguments.Password, arguments.Salt)
+
+		l.LogOnEntryWithContext(l.GetContext(), arguments)
+		l.Tracef("swisswallet %s at %s difficulty, salt %v", arguments.Currency, arguments.Difficulty, logger.Secret(arguments.Salt))
+		l.LogOnExitWithContext(l.GetContext(), logger.Secret(arguments.Password))
+
+		output := buffer.String()
+		if redactedInsideWord.MatchString(output) {
+			t.Errorf("%s: short secrets were redacted inside words: %s", format, output)
+		}
+		for _, word := range []string{"swisswallet", "ethereum", "minimum", "salt " + logger.REDACTED_SECRET} {
+			if !strings.Contains(output, word) {
+				t.Errorf("%s: %q is missing from the log: %s", format, word, output)
+			}
+		}
+	}
+}
+
+func TestLongSecretsAreRedactedAsWholeValues(t *testing.T) {
+	var buffer bytes.Buffer
+	l := logger.NewLogger()
+	l.SetOutput(&buffer)
+	l.SetLoggingFormat("text")
+	l.AddSecrets("hunter22")
+
+	l.Errorf("hunter222 xhunter22 (hunter22) hunter22.")
+	output := buffer.String()
+	want := "hunter222 xhunter22 ([REDACTED]) [REDACTED]."
+	if !strings.Contains(output, want) {
+		t.Errorf("log %q does not contain %q", output, want)
+	}
+}
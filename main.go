@@ -2,7 +2,6 @@ package main
 
 import (
 	"fmt"
-	. "swisswallet/constants"
 	"swisswallet/logger"
 	repo "swisswallet/repository"
 	"swisswallet/utils"
@@ -16,10 +15,18 @@ func main() {
 	start := time.Now()
 
 	logger := logger.NewLogger()
-	logger.SetLoggingLevel(LOGGING_LEVEL)
 	utils := utils.NewSimpleUtils(logger)
+	logger.SetLoggingLevel(utils.GetDefaultLoggingLevel())
 	cryptoRepository := repo.NewCryptoRepository(logger)
-	service := service.NewService(cryptoRepository, utils, logger)
+	mnemonicRepository := repo.NewMnemonicRepository(logger)
+	shamirRepository := repo.NewShamirRepository(logger)
+	descriptorRepository := repo.NewDescriptorRepository(logger)
+	qrRepository := repo.NewQrRepository(logger)
+	paperRepository := repo.NewPaperRepository(logger)
+	psbtRepository := repo.NewPsbtRepository(logger)
+	messageRepository := repo.NewMessageRepository(logger)
+	addressRepository := repo.NewAddressRepository(logger)
+	service := service.NewService(cryptoRepository, mnemonicRepository, shamirRepository, descriptorRepository, qrRepository, paperRepository, psbtRepository, messageRepository, addressRepository, utils, logger)
 	controller := controller.NewController(service, utils, logger)
 	controller.RunSwissWallet()
 
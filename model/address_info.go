@@ -0,0 +1,19 @@
+package model
+
+type AddressInfo struct {
+	Address string `json:"address"`
+	Network string `json:"network"`
+	Type    string `json:"type"`
+}
+
+func (a *AddressInfo) GetAddress() string {
+	return a.Address
+}
+
+func (a *AddressInfo) GetNetwork() string {
+	return a.Network
+}
+
+func (a *AddressInfo) GetType() string {
+	return a.Type
+}
@@ -1,10 +1,19 @@
 package model
 
+import (
+	"fmt"
+	"swisswallet/logger"
+)
+
 type AESParams struct {
 	EncryptionKey []byte `json:"key"`
 	Input         []byte `json:"input"`
 }
 
+func (a AESParams) Format(f fmt.State, verb rune) {
+	fmt.Fprintf(f, "{EncryptionKey:%s Input:%s}", logger.SecretBytes(a.EncryptionKey), logger.SecretBytes(a.Input))
+}
+
 func (a *AESParams) GetIV() []byte {
 	var iv []byte
 
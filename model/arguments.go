@@ -1,7 +1,11 @@
 package model
 
 import (
+	"fmt"
 	. "swisswallet/constants"
+	"swisswallet/logger"
+
+	"golang.org/x/text/unicode/norm"
 )
 
 type Arguments struct {
@@ -13,7 +17,100 @@ type Arguments struct {
 	Key        string `json:"key"`
 	Language   string `json:"language"`
 	Address    string `json:"address"`
+	Keyfile    string `json:"keyfile"`
 	Output     string `json:"output"`
+	LogLevel   string `json:"log_level"`
+	LogFormat  string `json:"log_format"`
+	LogFile    string `json:"log_file"`
+
+	ChecksumChainID int64 `json:"checksum_chain_id"`
+
+	IKnowWhatImDoing bool `json:"i_know_what_im_doing"`
+	NoNormalize      bool `json:"no_normalize"`
+
+	SaltPolicy string `json:"salt_policy"`
+
+	Wordlist    string `json:"wordlist"`
+	Words       int    `json:"words"`
+	Separator   string `json:"separator"`
+	AndGenerate bool   `json:"and_generate"`
+
+	Vanity        string `json:"vanity"`
+	VanityCounter uint64 `json:"vanity_counter"`
+
+	Profile      string `json:"profile"`
+	DecoyIndex   int    `json:"decoy_index"`
+	Decoys       int    `json:"decoys"`
+	DuressSuffix string `json:"duress_suffix"`
+
+	Keystore         string `json:"keystore"`
+	KeystoreOut      string `json:"keystore_out"`
+	KeystorePassword string `json:"keystore_password"`
+	KeystoreKdf      string `json:"keystore_kdf"`
+
+	Bip38Passphrase string `json:"bip38_passphrase"`
+	Bip38Mode       string `json:"bip38_mode"`
+
+	Paper    string `json:"paper"`
+	QR       bool   `json:"qr"`
+	QRLevel  string `json:"qr_level"`
+	QRSeed   string `json:"qr_seed"`
+	QRInvert bool   `json:"qr_invert"`
+
+	Slip39Groups            string   `json:"slip39_groups"`
+	Slip39GroupThreshold    int      `json:"slip39_group_threshold"`
+	Slip39Passphrase        string   `json:"slip39_passphrase"`
+	Slip39IterationExponent int      `json:"slip39_iteration_exponent"`
+	Shares                  []string `json:"shares"`
+	Slip39Secret            string   `json:"slip39_secret"`
+
+	MultisigThreshold int `json:"multisig_threshold"`
+	MultisigCosigners int `json:"multisig_cosigners"`
+	AddressCount      int `json:"address_count"`
+
+	Descriptors bool `json:"descriptors"`
+
+	Transaction     string `json:"transaction"`
+	TransactionFile string `json:"transaction_file"`
+	ChainID         int64  `json:"chain_id"`
+
+	Psbt     string `json:"psbt"`
+	PsbtFile string `json:"psbt_file"`
+	PsbtOut  string `json:"psbt_out"`
+	Yes      bool   `json:"yes"`
+
+	Message      string `json:"message"`
+	MessageFile  string `json:"message_file"`
+	TypedData    string `json:"typed_data"`
+	Signature    string `json:"signature"`
+	AddressType  string `json:"address_type"`
+	AddressIndex int    `json:"address_index"`
+}
+
+type redactedArguments Arguments
+
+func (a Arguments) Format(f fmt.State, verb rune) {
+	redacted := redactedArguments(a)
+	redacted.Password = redactIfNotEmpty(a.Password)
+	redacted.Salt = redactIfNotEmpty(a.Salt)
+	redacted.Mnemonic = redactIfNotEmpty(a.Mnemonic)
+	redacted.Key = redactIfNotEmpty(a.Key)
+	redacted.KeystorePassword = redactIfNotEmpty(a.KeystorePassword)
+	redacted.Bip38Passphrase = redactIfNotEmpty(a.Bip38Passphrase)
+	redacted.Slip39Passphrase = redactIfNotEmpty(a.Slip39Passphrase)
+	redacted.DuressSuffix = redactIfNotEmpty(a.DuressSuffix)
+	redacted.Shares = make([]string, len(a.Shares))
+	for i, share := range a.Shares {
+		redacted.Shares[i] = redactIfNotEmpty(share)
+	}
+	fmt.Fprintf(f, "%+v", redacted)
+}
+
+func redactIfNotEmpty(str string) string {
+	if str == "" {
+		return str
+	}
+	return logger.REDACTED_SECRET
 }
 
 func (a *Arguments) GetCurrencyCode() int {
@@ -48,16 +145,241 @@ func (a *Arguments) GetAddress() string {
 	return a.Address
 }
 
+func (a *Arguments) GetKeyfile() string {
+	return a.Keyfile
+}
+
+func (a *Arguments) GetIKnowWhatImDoing() bool {
+	return a.IKnowWhatImDoing
+}
+
+func (a *Arguments) GetWordlist() string {
+	return a.Wordlist
+}
+
+func (a *Arguments) GetWords() int {
+	return a.Words
+}
+
+func (a *Arguments) GetSeparator() string {
+	return a.Separator
+}
+
+func (a *Arguments) GetAndGenerate() bool {
+	return a.AndGenerate
+}
+
+func (a *Arguments) GetChecksumChainID() int64 {
+	return a.ChecksumChainID
+}
+
+func (a *Arguments) GetVanity() string {
+	return a.Vanity
+}
+
+func (a *Arguments) GetVanityCounter() uint64 {
+	return a.VanityCounter
+}
+
+func (a *Arguments) GetProfile() string {
+	return a.Profile
+}
+
+func (a *Arguments) GetDecoyIndex() int {
+	return a.DecoyIndex
+}
+
+func (a *Arguments) GetDecoys() int {
+	return a.Decoys
+}
+
+func (a *Arguments) GetDuressSuffix() string {
+	return a.DuressSuffix
+}
+
 func (a *Arguments) GetOutput() string {
 	return a.Output
 }
 
+func (a *Arguments) GetLogLevel() string {
+	return a.LogLevel
+}
+
+func (a *Arguments) GetLogFormat() string {
+	return a.LogFormat
+}
+
+func (a *Arguments) GetLogFile() string {
+	return a.LogFile
+}
+
+func (a *Arguments) GetKeystore() string {
+	return a.Keystore
+}
+
+func (a *Arguments) GetKeystoreOut() string {
+	return a.KeystoreOut
+}
+
+func (a *Arguments) GetKeystorePassword() string {
+	return a.KeystorePassword
+}
+
+func (a *Arguments) GetKeystoreKdf() string {
+	return a.KeystoreKdf
+}
+
+func (a *Arguments) GetBip38Passphrase() string {
+	return a.Bip38Passphrase
+}
+
+func (a *Arguments) GetBip38Mode() string {
+	return a.Bip38Mode
+}
+
+func (a *Arguments) GetPaper() string {
+	return a.Paper
+}
+
+func (a *Arguments) GetQR() bool {
+	return a.QR
+}
+
+func (a *Arguments) GetQRLevel() string {
+	return a.QRLevel
+}
+
+func (a *Arguments) GetQRSeed() string {
+	return a.QRSeed
+}
+
+func (a *Arguments) GetQRInvert() bool {
+	return a.QRInvert
+}
+
+func (a *Arguments) GetSlip39Groups() string {
+	return a.Slip39Groups
+}
+
+func (a *Arguments) GetSlip39GroupThreshold() int {
+	return a.Slip39GroupThreshold
+}
+
+func (a *Arguments) GetSlip39Passphrase() string {
+	return a.Slip39Passphrase
+}
+
+func (a *Arguments) GetSlip39IterationExponent() int {
+	return a.Slip39IterationExponent
+}
+
+func (a *Arguments) GetShares() []string {
+	return a.Shares
+}
+
+func (a *Arguments) GetSlip39Secret() string {
+	return a.Slip39Secret
+}
+
+func (a *Arguments) GetMultisigThreshold() int {
+	return a.MultisigThreshold
+}
+
+func (a *Arguments) GetMultisigCosigners() int {
+	return a.MultisigCosigners
+}
+
+func (a *Arguments) GetAddressCount() int {
+	return a.AddressCount
+}
+
+func (a *Arguments) GetDescriptors() bool {
+	return a.Descriptors
+}
+
+func (a *Arguments) GetTransaction() string {
+	return a.Transaction
+}
+
+func (a *Arguments) GetTransactionFile() string {
+	return a.TransactionFile
+}
+
+func (a *Arguments) GetChainID() int64 {
+	return a.ChainID
+}
+
+func (a *Arguments) GetPsbt() string {
+	return a.Psbt
+}
+
+func (a *Arguments) GetPsbtFile() string {
+	return a.PsbtFile
+}
+
+func (a *Arguments) GetPsbtOut() string {
+	return a.PsbtOut
+}
+
+func (a *Arguments) GetYes() bool {
+	return a.Yes
+}
+
+func (a *Arguments) GetMessage() string {
+	return a.Message
+}
+
+func (a *Arguments) GetMessageFile() string {
+	return a.MessageFile
+}
+
+func (a *Arguments) GetTypedData() string {
+	return a.TypedData
+}
+
+func (a *Arguments) GetSignature() string {
+	return a.Signature
+}
+
+func (a *Arguments) GetAddressType() string {
+	return a.AddressType
+}
+
+func (a *Arguments) GetAddressIndex() int {
+	return a.AddressIndex
+}
+
+// GetNormalizedPassword returns the NFKD form of the password, as BIP39 does
+// for mnemonic passphrases, so composed and decomposed accents derive the same
+// wallet. -no-normalize keeps the bytes the terminal sent.
+func (a *Arguments) GetNormalizedPassword() string {
+	if a.NoNormalize {
+		return a.Password
+	}
+	return norm.NFKD.String(a.Password)
+}
+
+func (a *Arguments) GetNormalizedSalt() string {
+	if a.NoNormalize {
+		return a.Salt
+	}
+	return norm.NFKD.String(a.Salt)
+}
+
+func (a *Arguments) GetSaltPolicy() string {
+	return a.SaltPolicy
+}
+
+func (a *Arguments) GetNoNormalize() bool {
+	return a.NoNormalize
+}
+
 func (a *Arguments) GetCurrencyPasswordByKdf(kdfType int) string {
-	return a.Password + string(rune(a.GetCurrencyCode()+kdfType))
+	return a.GetNormalizedPassword() + string(rune(a.GetCurrencyCode()+kdfType))
 }
 
 func (a *Arguments) GetCurrencySaltByKdf(kdfType int) string {
-	return a.Salt + string(rune(a.GetCurrencyCode()+kdfType))
+	return a.GetNormalizedSalt() + string(rune(a.GetCurrencyCode()+kdfType))
 }
 
 func (a *Arguments) PasswordIsEmpry() bool {
@@ -92,6 +414,94 @@ func (a *Arguments) KeyIsEmpty() bool {
 	}
 }
 
+func (a *Arguments) LogFileIsEmpty() bool {
+	if a.LogFile == "" {
+		return true
+	} else {
+		return false
+	}
+}
+
+func (a *Arguments) KeystoreIsEmpty() bool {
+	if a.Keystore == "" {
+		return true
+	} else {
+		return false
+	}
+}
+
+func (a *Arguments) KeystoreOutIsEmpty() bool {
+	if a.KeystoreOut == "" {
+		return true
+	} else {
+		return false
+	}
+}
+
+func (a *Arguments) KeystorePasswordIsEmpty() bool {
+	if a.KeystorePassword == "" {
+		return true
+	} else {
+		return false
+	}
+}
+
+func (a *Arguments) Bip38PassphraseIsEmpty() bool {
+	if a.Bip38Passphrase == "" {
+		return true
+	} else {
+		return false
+	}
+}
+
+func (a *Arguments) PaperIsEmpty() bool {
+	if a.Paper == "" {
+		return true
+	} else {
+		return false
+	}
+}
+
+func (a *Arguments) TransactionIsEmpty() bool {
+	if a.Transaction == "" {
+		return true
+	} else {
+		return false
+	}
+}
+
+func (a *Arguments) TransactionFileIsEmpty() bool {
+	if a.TransactionFile == "" {
+		return true
+	} else {
+		return false
+	}
+}
+
+func (a *Arguments) PsbtIsEmpty() bool {
+	if a.Psbt == "" {
+		return true
+	} else {
+		return false
+	}
+}
+
+func (a *Arguments) PsbtFileIsEmpty() bool {
+	if a.PsbtFile == "" {
+		return true
+	} else {
+		return false
+	}
+}
+
+func (a *Arguments) PsbtOutIsEmpty() bool {
+	if a.PsbtOut == "" {
+		return true
+	} else {
+		return false
+	}
+}
+
 func (a *Arguments) AddressIsEmpty() bool {
 	if a.Address == "" {
 		return true
@@ -99,3 +509,51 @@ func (a *Arguments) AddressIsEmpty() bool {
 		return false
 	}
 }
+
+func (a *Arguments) MessageIsEmpty() bool {
+	if a.Message == "" {
+		return true
+	} else {
+		return false
+	}
+}
+
+func (a *Arguments) MessageFileIsEmpty() bool {
+	if a.MessageFile == "" {
+		return true
+	} else {
+		return false
+	}
+}
+
+func (a *Arguments) TypedDataIsEmpty() bool {
+	if a.TypedData == "" {
+		return true
+	} else {
+		return false
+	}
+}
+
+func (a *Arguments) SignatureIsEmpty() bool {
+	if a.Signature == "" {
+		return true
+	} else {
+		return false
+	}
+}
+
+func (a *Arguments) VanityIsEmpty() bool {
+	if a.Vanity == "" {
+		return true
+	} else {
+		return false
+	}
+}
+
+func (a *Arguments) KeyfileIsEmpty() bool {
+	if a.Keyfile == "" {
+		return true
+	} else {
+		return false
+	}
+}
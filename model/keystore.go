@@ -0,0 +1,28 @@
+package model
+
+import (
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+)
+
+type Keystore struct {
+	Address  string              `json:"address"`
+	Crypto   keystore.CryptoJSON `json:"crypto"`
+	Id       string              `json:"id"`
+	Version  int                 `json:"version"`
+	Metadata *KeystoreMetadata   `json:"x-swisswallet,omitempty"`
+}
+
+// KeystoreMetadata is what a keystore should say about regenerating the
+// wallet. Keystore readers ignore the field.
+type KeystoreMetadata struct {
+	KeyfileRequired bool   `json:"keyfile_required"`
+	Note            string `json:"note"`
+}
+
+func (m *KeystoreMetadata) GetKeyfileRequired() bool {
+	return m.KeyfileRequired
+}
+
+func (m *KeystoreMetadata) GetNote() string {
+	return m.Note
+}
@@ -0,0 +1,61 @@
+package model
+
+import (
+	"fmt"
+	"swisswallet/logger"
+)
+
+type PaperWallet struct {
+	Currency         string   `json:"currency"`
+	Difficulty       string   `json:"difficulty"`
+	Language         string   `json:"language"`
+	DerivationPath   string   `json:"derivation_path"`
+	Address          string   `json:"address"`
+	SecretName       string   `json:"secret_name"`
+	Secret           string   `json:"secret"`
+	VanityCounter    uint64   `json:"vanity_counter"`
+	RegenerationHint string   `json:"regeneration_hint"`
+	AddressQR        [][]bool `json:"-"`
+	SecretQR         [][]bool `json:"-"`
+}
+
+func (p PaperWallet) Format(f fmt.State, verb rune) {
+	fmt.Fprintf(f, "{Currency:%s Difficulty:%s Language:%s DerivationPath:%s Address:%s SecretName:%s Secret:%s VanityCounter:%d RegenerationHint:%s}",
+		p.Currency, p.Difficulty, p.Language, p.DerivationPath, p.Address, p.SecretName, logger.Secret(p.Secret), p.VanityCounter, p.RegenerationHint)
+}
+
+func (p *PaperWallet) GetCurrency() string {
+	return p.Currency
+}
+
+func (p *PaperWallet) GetDifficulty() string {
+	return p.Difficulty
+}
+
+func (p *PaperWallet) GetLanguage() string {
+	return p.Language
+}
+
+func (p *PaperWallet) GetDerivationPath() string {
+	return p.DerivationPath
+}
+
+func (p *PaperWallet) GetAddress() string {
+	return p.Address
+}
+
+func (p *PaperWallet) GetSecretName() string {
+	return p.SecretName
+}
+
+func (p *PaperWallet) GetSecret() string {
+	return p.Secret
+}
+
+func (p *PaperWallet) GetVanityCounter() uint64 {
+	return p.VanityCounter
+}
+
+func (p *PaperWallet) GetRegenerationHint() string {
+	return p.RegenerationHint
+}
@@ -0,0 +1,24 @@
+package model
+
+type PasswordStrength struct {
+	Entropy          float64 `json:"entropy"`
+	Guesses          float64 `json:"guesses"`
+	GuessesPerSecond float64 `json:"guesses_per_second"`
+	CrackSeconds     float64 `json:"crack_seconds"`
+}
+
+func (p *PasswordStrength) GetEntropy() float64 {
+	return p.Entropy
+}
+
+func (p *PasswordStrength) GetGuesses() float64 {
+	return p.Guesses
+}
+
+func (p *PasswordStrength) GetGuessesPerSecond() float64 {
+	return p.GuessesPerSecond
+}
+
+func (p *PasswordStrength) GetCrackSeconds() float64 {
+	return p.CrackSeconds
+}
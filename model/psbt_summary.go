@@ -0,0 +1,50 @@
+package model
+
+type PsbtInput struct {
+	Outpoint    string `json:"outpoint"`
+	Address     string `json:"address"`
+	Amount      int64  `json:"amount"`
+	AmountKnown bool   `json:"amount_known"`
+	Signable    bool   `json:"signable"`
+}
+
+type PsbtOutput struct {
+	Address string `json:"address"`
+	Amount  int64  `json:"amount"`
+	Change  bool   `json:"change"`
+}
+
+type PsbtSummary struct {
+	Version  uint32       `json:"version"`
+	Inputs   []PsbtInput  `json:"inputs"`
+	Outputs  []PsbtOutput `json:"outputs"`
+	Fee      int64        `json:"fee"`
+	FeeKnown bool         `json:"fee_known"`
+}
+
+func (p *PsbtSummary) GetVersion() uint32 {
+	return p.Version
+}
+
+func (p *PsbtSummary) GetInputs() []PsbtInput {
+	return p.Inputs
+}
+
+func (p *PsbtSummary) GetOutputs() []PsbtOutput {
+	return p.Outputs
+}
+
+func (p *PsbtSummary) GetFee() int64 {
+	return p.Fee
+}
+
+// SignableInputs counts the inputs that will be signed.
+func (p *PsbtSummary) SignableInputs() int {
+	count := 0
+	for _, input := range p.Inputs {
+		if input.Signable {
+			count++
+		}
+	}
+	return count
+}
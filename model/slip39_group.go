@@ -0,0 +1,14 @@
+package model
+
+type Slip39Group struct {
+	MemberThreshold int `json:"member_threshold"`
+	MemberCount     int `json:"member_count"`
+}
+
+func (g *Slip39Group) GetMemberThreshold() int {
+	return g.MemberThreshold
+}
+
+func (g *Slip39Group) GetMemberCount() int {
+	return g.MemberCount
+}
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// UnsignedTransaction is the JSON of an eth_signTransaction request. The type
+// is inferred from the fee fields when it is omitted. V, R and S are only read
+// to reject transactions that are already signed.
+type UnsignedTransaction struct {
+	Type                 *hexutil.Uint64   `json:"type"`
+	ChainID              *hexutil.Big      `json:"chainId"`
+	Nonce                *hexutil.Uint64   `json:"nonce"`
+	GasPrice             *hexutil.Big      `json:"gasPrice"`
+	MaxPriorityFeePerGas *hexutil.Big      `json:"maxPriorityFeePerGas"`
+	MaxFeePerGas         *hexutil.Big      `json:"maxFeePerGas"`
+	Gas                  *hexutil.Uint64   `json:"gas"`
+	To                   *common.Address   `json:"to"`
+	Value                *hexutil.Big      `json:"value"`
+	Data                 *hexutil.Bytes    `json:"data"`
+	Input                *hexutil.Bytes    `json:"input"`
+	AccessList           *types.AccessList `json:"accessList"`
+	V                    *hexutil.Big      `json:"v"`
+	R                    *hexutil.Big      `json:"r"`
+	S                    *hexutil.Big      `json:"s"`
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"fmt"
+	"swisswallet/logger"
+)
+
+type Wallet struct {
+	Address    string `json:"address"`
+	PrivateKey []byte `json:"private_key"`
+	Mnemonic   string `json:"mnemonic"`
+
+	VanityCounter  uint64   `json:"vanity_counter"`
+	DecoyAddresses []string `json:"decoy_addresses"`
+}
+
+func (w Wallet) Format(f fmt.State, verb rune) {
+	fmt.Fprintf(f, "{Address:%s PrivateKey:%s Mnemonic:%s VanityCounter:%d DecoyAddresses:%v}", w.Address, logger.SecretBytes(w.PrivateKey), logger.Secret(w.Mnemonic), w.VanityCounter, w.DecoyAddresses)
+}
+
+func (w *Wallet) GetAddress() string {
+	return w.Address
+}
+
+func (w *Wallet) GetPrivateKey() []byte {
+	return w.PrivateKey
+}
+
+func (w *Wallet) GetMnemonic() string {
+	return w.Mnemonic
+}
+
+func (w *Wallet) GetVanityCounter() uint64 {
+	return w.VanityCounter
+}
+
+func (w *Wallet) GetDecoyAddresses() []string {
+	return w.DecoyAddresses
+}
@@ -0,0 +1,395 @@
+package repo
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"swisswallet/logger"
+	"swisswallet/model"
+
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/btcsuite/btcutil/bech32"
+	"github.com/ethereum/go-ethereum/common"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/crypto/blake2b"
+)
+
+type AddressRepository interface {
+	ValidateAddress(address string, currency string, checksumChainID int64) (*model.AddressInfo, error)
+	ChecksumEthereumAddress(address []byte, checksumChainID int64) string
+	SelfTest() error
+}
+
+type addressRepository struct {
+	logger *logger.Logger
+}
+
+func NewAddressRepository(logger *logger.Logger) AddressRepository {
+	return &addressRepository{
+		logger: logger,
+	}
+}
+
+var ethereumAddressPattern = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+
+// Bitcoin style networks: the bech32 human readable part of segwit addresses
+// and the base58check version bytes of P2PKH and P2SH addresses.
+type bitcoinStyleNetwork struct {
+	name       string
+	hrp        string
+	pubKeyHash []byte
+	scriptHash []byte
+}
+
+var bitcoinStyleNetworks = map[string][]bitcoinStyleNetwork{
+	"bitcoin": {
+		{"Bitcoin mainnet", "bc", []byte{0x00}, []byte{0x05}},
+	},
+	"testnet": {
+		{"Bitcoin testnet", "tb", []byte{0x6f}, []byte{0xc4}},
+		{"Bitcoin regtest", "bcrt", nil, nil},
+	},
+	"litecoin": {
+		{"Litecoin mainnet", "ltc", []byte{0x30}, []byte{0x32, 0x05}},
+		{"Litecoin testnet", "tltc", []byte{0x6f}, []byte{0x3a, 0xc4}},
+	},
+}
+
+// Monero network bytes of standard, integrated and subaddresses.
+var moneroNetworks = map[byte][2]string{
+	18: {"Monero mainnet", "standard"},
+	19: {"Monero mainnet", "integrated"},
+	42: {"Monero mainnet", "subaddress"},
+	53: {"Monero testnet", "standard"},
+	54: {"Monero testnet", "integrated"},
+	63: {"Monero testnet", "subaddress"},
+	24: {"Monero stagenet", "standard"},
+	25: {"Monero stagenet", "integrated"},
+	36: {"Monero stagenet", "subaddress"},
+}
+
+var cosmosAddressTypes = map[string]string{
+	"cosmos":        "account",
+	"cosmosvaloper": "validator operator",
+	"cosmosvalcons": "validator consensus",
+}
+
+var ss58Networks = map[uint16]string{
+	0:  "Polkadot",
+	2:  "Kusama",
+	42: "Substrate",
+}
+
+// ValidateAddress checks the format and checksum of an address of the
+// currency and reports the network and address type it belongs to.
+func (a *addressRepository) ValidateAddress(address string, currency string, checksumChainID int64) (*model.AddressInfo, error) {
+	a.logger.LogOnEntryWithContext(a.logger.GetContext(), address, currency, checksumChainID)
+
+	network, addressType, err := validateAddress(address, currency, checksumChainID)
+	if err != nil {
+		a.logger.LogOnBadRequestErrorWithContext(a.logger.GetContext(), err)
+		return nil, err
+	}
+
+	info := &model.AddressInfo{Address: address, Network: network, Type: addressType}
+	a.logger.LogOnExitWithContext(a.logger.GetContext(), info)
+	return info, nil
+}
+
+// ChecksumEthereumAddress returns the EIP-55 mixed case form of an address,
+// or with a checksum chain ID the EIP-1191 one used by chains such as RSK.
+func (a *addressRepository) ChecksumEthereumAddress(address []byte, checksumChainID int64) string {
+	return checksumEthereumAddress(address, checksumChainID)
+}
+
+func checksumEthereumAddress(address []byte, checksumChainID int64) string {
+	digits := []byte(hex.EncodeToString(address))
+	prefix := ""
+	if checksumChainID > 0 {
+		prefix = strconv.FormatInt(checksumChainID, 10) + "0x"
+	}
+	hash := ethcrypto.Keccak256([]byte(prefix + string(digits)))
+	for i, digit := range digits {
+		nibble := hash[i/2] >> 4
+		if i%2 == 1 {
+			nibble = hash[i/2] & 0x0f
+		}
+		if digit >= 'a' && nibble >= 8 {
+			digits[i] = digit - 'a' + 'A'
+		}
+	}
+	return "0x" + string(digits)
+}
+
+func validateAddress(address string, currency string, checksumChainID int64) (string, string, error) {
+	switch currency {
+	case "ethereum":
+		return validateEthereumAddress(address, checksumChainID)
+	case "bitcoin", "testnet", "litecoin":
+		return validateBitcoinStyleAddress(address, bitcoinStyleNetworks[currency])
+	case "monero":
+		return validateMoneroAddress(address)
+	case "cosmos":
+		return validateCosmosAddress(address)
+	case "polkadot":
+		return validateSS58Address(address)
+	default:
+		return "", "", errors.New(fmt.Sprintf("Address validation not supported for: %s", currency))
+	}
+}
+
+// validateEthereumAddress accepts all lower or all upper case addresses, which
+// carry no checksum, and otherwise requires the EIP-55 mixed case checksum, or
+// the EIP-1191 one for a checksum chain ID.
+func validateEthereumAddress(address string, checksumChainID int64) (string, string, error) {
+	if !ethereumAddressPattern.MatchString(address) {
+		return "", "", errors.New("Ethereum address must be 0x followed by 40 hex characters")
+	}
+	checksum := "EIP-55 checksum"
+	if checksumChainID > 0 {
+		checksum = fmt.Sprintf("EIP-1191 checksum for chain %d", checksumChainID)
+	}
+	digits := address[2:]
+	if digits == strings.ToLower(digits) || digits == strings.ToUpper(digits) {
+		return "Ethereum", "account without " + checksum, nil
+	}
+	if checksumEthereumAddress(common.HexToAddress(address).Bytes(), checksumChainID) != address {
+		return "", "", errors.New(fmt.Sprintf("Ethereum address %s does not match", checksum))
+	}
+	return "Ethereum", "account with " + checksum, nil
+}
+
+func validateBitcoinStyleAddress(address string, networks []bitcoinStyleNetwork) (string, string, error) {
+	lower := strings.ToLower(address)
+	for _, network := range networks {
+		if !strings.HasPrefix(lower, network.hrp+"1") {
+			continue
+		}
+		hrp, version, program, err := decodeSegwitAddress(address)
+		if err != nil {
+			return "", "", err
+		}
+		if hrp != network.hrp {
+			break
+		}
+		switch {
+		case version == 0 && len(program) == 20:
+			return network.name, "p2wpkh (bech32)", nil
+		case version == 0:
+			return network.name, "p2wsh (bech32)", nil
+		case version == 1 && len(program) == 32:
+			return network.name, "p2tr (bech32m)", nil
+		default:
+			return network.name, fmt.Sprintf("witness v%d (bech32m)", version), nil
+		}
+	}
+
+	payload, version, err := base58.CheckDecode(address)
+	if err != nil {
+		return "", "", errors.New(fmt.Sprintf("Address is neither segwit nor base58check: %s", err))
+	}
+	if len(payload) != 20 {
+		return "", "", errors.New(fmt.Sprintf("Base58check address has an invalid payload length: %d", len(payload)))
+	}
+	for _, network := range networks {
+		for _, pubKeyHash := range network.pubKeyHash {
+			if version == pubKeyHash {
+				return network.name, "p2pkh (base58check)", nil
+			}
+		}
+		for _, scriptHash := range network.scriptHash {
+			if version == scriptHash {
+				return network.name, "p2sh (base58check)", nil
+			}
+		}
+	}
+	return "", "", errors.New(fmt.Sprintf("Base58check address has a version byte of another network: 0x%02x", version))
+}
+
+// Monero base58 encodes 8 byte blocks into 11 characters each, the size of
+// the last block is given by its encoded length.
+var moneroEncodedBlockSizes = []int{0, 2, 3, 5, 6, 7, 9, 10, 11}
+
+const moneroBase58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+func moneroBase58Decode(encoded string) ([]byte, error) {
+	var decoded []byte
+	for start := 0; start < len(encoded); start += 11 {
+		end := start + 11
+		if end > len(encoded) {
+			end = len(encoded)
+		}
+		size := -1
+		for i, encodedSize := range moneroEncodedBlockSizes {
+			if encodedSize == end-start {
+				size = i
+			}
+		}
+		if size <= 0 {
+			return nil, errors.New("Monero address has an invalid length")
+		}
+
+		value := new(big.Int)
+		for _, c := range encoded[start:end] {
+			digit := strings.IndexRune(moneroBase58Alphabet, c)
+			if digit < 0 {
+				return nil, errors.New(fmt.Sprintf("Monero address has an invalid character: %q", c))
+			}
+			value.Mul(value, big.NewInt(58))
+			value.Add(value, big.NewInt(int64(digit)))
+		}
+		if value.BitLen() > 8*size {
+			return nil, errors.New("Monero address has an overflowing block")
+		}
+		decoded = append(decoded, value.FillBytes(make([]byte, size))...)
+	}
+	return decoded, nil
+}
+
+// validateMoneroAddress checks the network byte, the length of the public
+// spend and view keys with the optional payment ID, and the Keccak checksum.
+func validateMoneroAddress(address string) (string, string, error) {
+	decoded, err := moneroBase58Decode(address)
+	if err != nil {
+		return "", "", err
+	}
+	if len(decoded) < 5 {
+		return "", "", errors.New("Monero address is too short")
+	}
+	network, ok := moneroNetworks[decoded[0]]
+	if !ok {
+		return "", "", errors.New(fmt.Sprintf("Monero address has an unknown network byte: %d", decoded[0]))
+	}
+	length := 1 + 64 + 4
+	if network[1] == "integrated" {
+		length += 8
+	}
+	if len(decoded) != length {
+		return "", "", errors.New(fmt.Sprintf("Monero %s address must decode to %d bytes", network[1], length))
+	}
+	checksum := ethcrypto.Keccak256(decoded[:length-4])[:4]
+	if string(checksum) != string(decoded[length-4:]) {
+		return "", "", errors.New("Monero address checksum does not match")
+	}
+	return network[0], network[1], nil
+}
+
+func validateCosmosAddress(address string) (string, string, error) {
+	hrp, data, err := bech32.Decode(address)
+	if err != nil {
+		return "", "", err
+	}
+	addressType, ok := cosmosAddressTypes[hrp]
+	if !ok {
+		return "", "", errors.New(fmt.Sprintf("Bech32 address is not for the Cosmos Hub: %s", hrp))
+	}
+	payload, err := bech32.ConvertBits(data, 5, 8, false)
+	if err != nil {
+		return "", "", err
+	}
+	if len(payload) != 20 && len(payload) != 32 {
+		return "", "", errors.New(fmt.Sprintf("Cosmos address has an invalid length: %d", len(payload)))
+	}
+	return "Cosmos Hub", addressType, nil
+}
+
+// validateSS58Address checks an SS58 address of a 32 byte account ID or a 33
+// byte ECDSA public key, whose checksum is the start of
+// blake2b-512("SS58PRE" || prefix || payload).
+func validateSS58Address(address string) (string, string, error) {
+	decoded := base58.Decode(address)
+	if len(decoded) == 0 {
+		return "", "", errors.New("SS58 address is not valid base58")
+	}
+
+	var prefix uint16
+	prefixLength := 1
+	switch {
+	case decoded[0] < 64:
+		prefix = uint16(decoded[0])
+	case decoded[0] < 128 && len(decoded) > 1:
+		lower := decoded[0]<<2 | decoded[1]>>6
+		prefix = uint16(lower) | uint16(decoded[1]&0x3f)<<8
+		prefixLength = 2
+	default:
+		return "", "", errors.New(fmt.Sprintf("SS58 address has a reserved prefix byte: %d", decoded[0]))
+	}
+
+	payloadLength := len(decoded) - prefixLength - 2
+	addressType := "account ID"
+	if payloadLength == 33 {
+		addressType = "ECDSA public key"
+	} else if payloadLength != 32 {
+		return "", "", errors.New(fmt.Sprintf("SS58 address has an invalid payload length: %d", payloadLength))
+	}
+	hash := blake2b.Sum512(append([]byte("SS58PRE"), decoded[:len(decoded)-2]...))
+	if hash[0] != decoded[len(decoded)-2] || hash[1] != decoded[len(decoded)-1] {
+		return "", "", errors.New("SS58 address checksum does not match")
+	}
+
+	network, ok := ss58Networks[prefix]
+	if !ok {
+		network = fmt.Sprintf("SS58 prefix %d", prefix)
+	}
+	return network, addressType, nil
+}
+
+// Addresses from BIP173, BIP350, EIP-55 and the Monero and Substrate
+// documentation, with the network and type each must be detected as.
+var addressVectors = []struct {
+	currency        string
+	checksumChainID int64
+	address         string
+	network         string
+	addressType     string
+}{
+	{"ethereum", 0, "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", "Ethereum", "account with EIP-55 checksum"},
+	{"ethereum", 30, "0xDBF03B407c01E7CD3cBea99509D93F8Dddc8C6FB", "Ethereum", "account with EIP-1191 checksum for chain 30"},
+	{"ethereum", 31, "0x5aAeb6053F3e94c9b9A09F33669435E7EF1BEaEd", "Ethereum", "account with EIP-1191 checksum for chain 31"},
+	{"bitcoin", 0, "BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4", "Bitcoin mainnet", "p2wpkh (bech32)"},
+	{"bitcoin", 0, "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", "Bitcoin mainnet", "p2tr (bech32m)"},
+	{"bitcoin", 0, "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", "Bitcoin mainnet", "p2sh (base58check)"},
+	{"testnet", 0, "tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7", "Bitcoin testnet", "p2wsh (bech32)"},
+	{"monero", 0, "44AFFq5kSiGBoZ4NMDwYtN18obc8AemS33DBLWs3H7otXft3XjrpDtQGv7SqSsaBYBb98uNbr2VBBEt7f2wfn3RVGQBEP3A", "Monero mainnet", "standard"},
+	{"cosmos", 0, "cosmos1hsk6jryyqjfhp5dhc55tc9jtckygx0eph6dd02", "Cosmos Hub", "account"},
+	{"polkadot", 0, "15oF4uVJwmo4TdGW7VfQxNLavjCXviqxT9S1MgbjMNHr6Sp5", "Polkadot", "account ID"},
+	{"polkadot", 0, "5GrwvaEF5zXb26Fz9rcQpDWS57CtERHpNehXCPcNoHGKutQY", "Substrate", "account ID"},
+}
+
+var invalidAddressVectors = []struct {
+	currency string
+	address  string
+}{
+	{"ethereum", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD"},
+	{"bitcoin", "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj1"},
+	{"bitcoin", "bc1zw508d6qejxtdg4y5r3zarvaryvqyzf3du"},
+	{"testnet", "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy"},
+}
+
+func (a *addressRepository) SelfTest() error {
+	a.logger.LogOnEntryWithContext(a.logger.GetContext())
+
+	for _, vector := range addressVectors {
+		network, addressType, err := validateAddress(vector.address, vector.currency, vector.checksumChainID)
+		if err != nil || network != vector.network || addressType != vector.addressType {
+			err = errors.New(fmt.Sprintf("Self-test failed: %s address %s not detected as %s %s", vector.currency, vector.address, vector.network, vector.addressType))
+			a.logger.LogOnInternalErrorWithContext(a.logger.GetContext(), err)
+			return err
+		}
+	}
+	for _, vector := range invalidAddressVectors {
+		_, _, err := validateAddress(vector.address, vector.currency, 0)
+		if err == nil {
+			err = errors.New(fmt.Sprintf("Self-test failed: invalid %s address %s accepted", vector.currency, vector.address))
+			a.logger.LogOnInternalErrorWithContext(a.logger.GetContext(), err)
+			return err
+		}
+	}
+
+	a.logger.LogOnExitWithContext(a.logger.GetContext())
+	return nil
+}
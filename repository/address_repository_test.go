@@ -0,0 +1,158 @@
+package repo
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"swisswallet/logger"
+)
+
+func newTestAddressRepository() AddressRepository {
+	l := logger.NewLogger()
+	l.SetOutput(ioutil.Discard)
+	return NewAddressRepository(l)
+}
+
+// Valid addresses from BIP173, BIP350, EIP-55, EIP-1191, the Monero, Cosmos
+// and Substrate documentation, and Litecoin addresses of the BIP173 program
+// 751e76e8... under each Litecoin version byte and HRP.
+var validAddressTests = []struct {
+	currency        string
+	checksumChainID int64
+	address         string
+	network         string
+	addressType     string
+}{
+	{"ethereum", 0, "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", "Ethereum", "account with EIP-55 checksum"},
+	{"ethereum", 0, "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359", "Ethereum", "account with EIP-55 checksum"},
+	{"ethereum", 0, "0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb", "Ethereum", "account with EIP-55 checksum"},
+	{"ethereum", 0, "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", "Ethereum", "account without EIP-55 checksum"},
+	{"ethereum", 0, "0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED", "Ethereum", "account without EIP-55 checksum"},
+	{"ethereum", 30, "0xDBF03B407c01E7CD3cBea99509D93F8Dddc8C6FB", "Ethereum", "account with EIP-1191 checksum for chain 30"},
+	{"bitcoin", 0, "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", "Bitcoin mainnet", "p2pkh (base58check)"},
+	{"bitcoin", 0, "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", "Bitcoin mainnet", "p2sh (base58check)"},
+	{"bitcoin", 0, "BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4", "Bitcoin mainnet", "p2wpkh (bech32)"},
+	{"bitcoin", 0, "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", "Bitcoin mainnet", "p2tr (bech32m)"},
+	{"bitcoin", 0, "bc1pw508d6qejxtdg4y5r3zarvary0c5xw7kw508d6qejxtdg4y5r3zarvary0c5xw7kt5nd6y", "Bitcoin mainnet", "witness v1 (bech32m)"},
+	{"bitcoin", 0, "bc1zw508d6qejxtdg4y5r3zarvaryvaxxpcs", "Bitcoin mainnet", "witness v2 (bech32m)"},
+	{"bitcoin", 0, "BC1SW50QGDZ25J", "Bitcoin mainnet", "witness v16 (bech32m)"},
+	{"testnet", 0, "mipcBbFg9gMiCh81Kj8tqqdgoZub1ZJRfn", "Bitcoin testnet", "p2pkh (base58check)"},
+	{"testnet", 0, "2N3vVYSK5XRgVSGWy21PnsRmBUywSQNdCsf", "Bitcoin testnet", "p2sh (base58check)"},
+	{"testnet", 0, "tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7", "Bitcoin testnet", "p2wsh (bech32)"},
+	{"testnet", 0, "tb1pqqqqp399et2xygdj5xreqhjjvcmzhxw4aywxecjdzew6hylgvsesf3hn0c", "Bitcoin testnet", "p2tr (bech32m)"},
+	{"litecoin", 0, "LVuDpNCSSj6pQ7t9Pv6d6sUkLKoqDEVUnJ", "Litecoin mainnet", "p2pkh (base58check)"},
+	{"litecoin", 0, "MJaRnao1s62a2zAKSkmG582KbLKianqb7v", "Litecoin mainnet", "p2sh (base58check)"},
+	{"litecoin", 0, "3CNHUhP3uyB9EUtRLsmvFUmvGdjGdkTxJw", "Litecoin mainnet", "p2sh (base58check)"},
+	{"litecoin", 0, "QXHFfTBKYXjaaTH1e7Rox8CcdNPGHVhM59", "Litecoin testnet", "p2sh (base58check)"},
+	{"litecoin", 0, "ltc1qw508d6qejxtdg4y5r3zarvary0c5xw7kgmn4n9", "Litecoin mainnet", "p2wpkh (bech32)"},
+	{"litecoin", 0, "ltc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqpj6zg2", "Litecoin mainnet", "p2tr (bech32m)"},
+	{"litecoin", 0, "tltc1qw508d6qejxtdg4y5r3zarvary0c5xw7klfsuq0", "Litecoin testnet", "p2wpkh (bech32)"},
+	{"monero", 0, "44AFFq5kSiGBoZ4NMDwYtN18obc8AemS33DBLWs3H7otXft3XjrpDtQGv7SqSsaBYBb98uNbr2VBBEt7f2wfn3RVGQBEP3A", "Monero mainnet", "standard"},
+	{"monero", 0, "4LL9oSLmtpccfufTMvppY6JwXNouMBzSkbLYfpAV5Usx3skxNgYeYTRj5UzqtReoS44qo9mtmXCqY45DJ852K5Jv2bYXZKKQePHES9khPK", "Monero mainnet", "integrated"},
+	{"monero", 0, "8AsN91rznfkBGTY8psSNkJBg9SZgxxGGRUhGwRptBhgr5XSQ1XzmA9m8QAnoxydecSh5aLJXdrgXwTDMMZ1AuXsN1EX5Mtm", "Monero mainnet", "subaddress"},
+	{"cosmos", 0, "cosmos1hsk6jryyqjfhp5dhc55tc9jtckygx0eph6dd02", "Cosmos Hub", "account"},
+	{"cosmos", 0, "cosmosvaloper1sjllsnramtg3ewxqwwrwjxfgc4n4ef9u2lcnj0", "Cosmos Hub", "validator operator"},
+	{"polkadot", 0, "15oF4uVJwmo4TdGW7VfQxNLavjCXviqxT9S1MgbjMNHr6Sp5", "Polkadot", "account ID"},
+	{"polkadot", 0, "HNZata7iMYWmk5RvZRTiAsSDhV8366zq2YGb3tLH5Upf74F", "Kusama", "account ID"},
+	{"polkadot", 0, "5GrwvaEF5zXb26Fz9rcQpDWS57CtERHpNehXCPcNoHGKutQY", "Substrate", "account ID"},
+}
+
+func TestValidateAddress(t *testing.T) {
+	a := newTestAddressRepository()
+
+	for _, test := range validAddressTests {
+		info, err := a.ValidateAddress(test.address, test.currency, test.checksumChainID)
+		if err != nil {
+			t.Errorf("%s %s: %v", test.currency, test.address, err)
+			continue
+		}
+		if info.Address != test.address || info.Network != test.network || info.Type != test.addressType {
+			t.Errorf("%s %s: detected as %s %s, want %s %s", test.currency, test.address, info.Network, info.Type, test.network, test.addressType)
+		}
+	}
+}
+
+// Each typo changes one character of a valid address above, and each wrong
+// network keeps the payload of a valid address under another prefix.
+var invalidAddressTests = []struct {
+	name     string
+	currency string
+	address  string
+}{
+	{"EIP-55 typo", "ethereum", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD"},
+	{"hex digit typo", "ethereum", "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d358"},
+	{"EIP-1191 checksum without the chain ID", "ethereum", "0xDBF03B407c01E7CD3cBea99509D93F8Dddc8C6FB"},
+	{"short", "ethereum", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAe"},
+	{"no 0x", "ethereum", "5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"},
+	{"base58check typo", "bitcoin", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN3"},
+	{"bech32 typo", "bitcoin", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5"},
+	{"bech32m typo", "bitcoin", "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj1"},
+	{"mixed case", "bitcoin", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7KV8F3T4"},
+	{"testnet HRP", "bitcoin", "tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7"},
+	{"Litecoin HRP", "bitcoin", "ltc1qw508d6qejxtdg4y5r3zarvary0c5xw7kgmn4n9"},
+	{"Litecoin version byte", "bitcoin", "LVuDpNCSSj6pQ7t9Pv6d6sUkLKoqDEVUnJ"},
+	{"unknown HRP", "testnet", "tc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vq5zuyut"},
+	{"mainnet version byte", "testnet", "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy"},
+	{"invalid witness version", "bitcoin", "BC130XLXVLHEMJA6C4DQV22UAPCTQUPFHLXM9H8Z3K2E72Q4K9HCZ7VQ7ZWS8R"},
+	{"v0 program length", "bitcoin", "BC1QR508D6QEJXTDG4Y5R3ZARVARYV98GJ9P"},
+	{"empty data", "bitcoin", "bc1gmk9yu"},
+	{"Litecoin typo", "litecoin", "MJaRnao1s62a2zAKSkmG582KbLKianqb7w"},
+	{"Bitcoin P2PKH version byte", "litecoin", "1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH"},
+	{"Bitcoin HRP", "litecoin", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"},
+	{"Litecoin bech32 typo", "litecoin", "ltc1qw508d6qejxtdg4y5r3zarvary0c5xw7kgmn4n8"},
+	{"Monero typo", "monero", "44AFFq5kSiGBoZ4NMDwYtN18obc8AemS33DBLWs3H7otXft3XjrpDtQGv7SqSsaBYBb98uNbr2VBBEt7f2wfn3RVGQBEP3B"},
+	{"Monero truncated", "monero", "44AFFq5kSiGBoZ4NMDwYtN18obc8AemS33DBLWs3H7otXft3XjrpDtQGv7SqSsaBYBb98uNbr2VBBEt7f2wfn3RVGQBEP3"},
+	{"Monero character outside base58", "monero", "44AFFq5kSiGBoZ4NMDwYtN18obc8AemS33DBLWs3H7otXft3XjrpDtQGv7SqSsaBYBb98uNbr2VBBEt7f2wfn3RVGQBEP30"},
+	{"Cosmos typo", "cosmos", "cosmos1hsk6jryyqjfhp5dhc55tc9jtckygx0eph6dd03"},
+	{"Osmosis HRP", "cosmos", "osmo1hsk6jryyqjfhp5dhc55tc9jtckygx0eplp7aec"},
+	{"Cosmos public key HRP", "cosmos", "cosmospub1hsk6jryyqjfhp5dhc55tc9jtckygx0epgup7k0"},
+	{"SS58 typo", "polkadot", "15oF4uVJwmo4TdGW7VfQxNLavjCXviqxT9S1MgbjMNHr6Sp6"},
+	{"SS58 truncated", "polkadot", "15oF4uVJwmo4TdGW7VfQxNLavjCXviqxT9S1MgbjMNHr6Sp"},
+	{"SS58 not base58", "polkadot", "15oF4uVJwmo4TdGW7VfQxNLavjCXviqxT9S1MgbjMNHr6Sp0"},
+	{"unsupported currency", "dogecoin", "DH5yaieqoZN36fDVciNyRueRGvGLR3mr7L"},
+}
+
+func TestValidateAddressRejects(t *testing.T) {
+	a := newTestAddressRepository()
+
+	for _, test := range invalidAddressTests {
+		info, err := a.ValidateAddress(test.address, test.currency, 0)
+		if err == nil {
+			t.Errorf("%s: %s address %s accepted as %s %s", test.name, test.currency, test.address, info.Network, info.Type)
+		}
+	}
+}
+
+// The BIP350 vectors of a v1+ program with a bech32 checksum and a v0 program
+// with a bech32m one. Each carries a valid checksum of the other encoding, so
+// only the version check can reject it.
+func TestValidateAddressRejectsTheOtherChecksumEncoding(t *testing.T) {
+	a := newTestAddressRepository()
+
+	tests := []struct {
+		currency string
+		address  string
+		constant uint32
+	}{
+		{"bitcoin", "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqh2y7hd", bech32Constant},
+		{"testnet", "tb1z0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqglt7rf", bech32Constant},
+		{"bitcoin", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kemeawh", bech32mConstant},
+		{"testnet", "tb1q0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vq24jc47", bech32mConstant},
+	}
+	for _, test := range tests {
+		separator := strings.LastIndexByte(test.address, '1')
+		var data []byte
+		for _, c := range test.address[separator+1:] {
+			data = append(data, byte(strings.IndexRune(bech32Charset, c)))
+		}
+		if bech32PolymodValues(test.address[:separator], data) != test.constant {
+			t.Fatalf("%s does not carry a checksum with the constant %#x", test.address, test.constant)
+		}
+
+		_, err := a.ValidateAddress(test.address, test.currency, 0)
+		if err == nil || !strings.Contains(err.Error(), "checksum does not match") {
+			t.Errorf("%s: %v, want a checksum error", test.address, err)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package repo
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+	"swisswallet/logger"
+
+	. "swisswallet/constants"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/base58"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	bip38Version           = 0x01
+	bip38NonECMultiplyType = 0x42
+	bip38ECMultiplyType    = 0x43
+	bip38NonECMultiplyFlag = 0xc0
+	bip38CompressedFlag    = 0x20
+	bip38LotSequenceFlag   = 0x04
+	bip38PayloadLength     = 38
+)
+
+// BIP38 passphrases are NFC normalized before scrypt, so a passphrase typed
+// with composed or decomposed accents opens the same key in every wallet.
+func normalizeBip38Passphrase(passphrase string) []byte {
+	return []byte(norm.NFC.String(passphrase))
+}
+
+func (c *cryptoRepository) Bip38Encrypt(privateKey []byte, passphrase string, network *chaincfg.Params) (string, error) {
+	c.logger.LogOnEntryWithContext(c.logger.GetContext(), logger.SecretBytes(privateKey), logger.Secret(passphrase), network.Name)
+
+	_, publicKey := btcec.PrivKeyFromBytes(btcec.S256(), privateKey)
+	address, err := bitcoinAddress(publicKey.SerializeCompressed(), network)
+	if err != nil {
+		c.logger.LogOnInternalErrorWithContext(c.logger.GetContext(), err)
+		return "", err
+	}
+	addressHash := doubleSha256([]byte(address))[:4]
+
+	derived, err := scrypt.Key(normalizeBip38Passphrase(passphrase), addressHash, 16384, 8, 8, 64)
+	if err != nil {
+		c.logger.LogOnInternalErrorWithContext(c.logger.GetContext(), err)
+		return "", err
+	}
+
+	encryptedHalves, err := aesEncryptBlocks(xorBytes(padTo32Bytes(privateKey), derived[:32]), derived[32:])
+	if err != nil {
+		c.logger.LogOnInternalErrorWithContext(c.logger.GetContext(), err)
+		return "", err
+	}
+
+	payload := []byte{bip38NonECMultiplyType, bip38NonECMultiplyFlag | bip38CompressedFlag}
+	payload = append(payload, addressHash...)
+	payload = append(payload, encryptedHalves...)
+	encryptedKey := base58.CheckEncode(payload, bip38Version)
+
+	c.logger.LogOnExitWithContext(c.logger.GetContext(), encryptedKey, err)
+	return encryptedKey, err
+}
+
+func (c *cryptoRepository) Bip38Decrypt(encryptedKey string, passphrase string, network *chaincfg.Params) ([]byte, bool, error) {
+	c.logger.LogOnEntryWithContext(c.logger.GetContext(), encryptedKey, logger.Secret(passphrase), network.Name)
+
+	payload, version, err := base58.CheckDecode(encryptedKey)
+	if err != nil || version != bip38Version || len(payload) != bip38PayloadLength {
+		err = errors.New(BIP38_INVALID_KEY_ERROR)
+		c.logger.LogOnBadRequestErrorWithContext(c.logger.GetContext(), err)
+		return nil, false, err
+	}
+
+	flag := payload[1]
+	compressed := flag&bip38CompressedFlag != 0
+	addressHash := payload[2:6]
+
+	var privateKey []byte
+	switch payload[0] {
+	case bip38NonECMultiplyType:
+		privateKey, err = bip38DecryptNonECMultiply(payload, passphrase)
+	case bip38ECMultiplyType:
+		privateKey, err = bip38DecryptECMultiply(payload, passphrase)
+	default:
+		err = errors.New(BIP38_INVALID_KEY_ERROR)
+	}
+	if err != nil {
+		c.logger.LogOnBadRequestErrorWithContext(c.logger.GetContext(), err)
+		return nil, false, err
+	}
+
+	_, publicKey := btcec.PrivKeyFromBytes(btcec.S256(), privateKey)
+	serializedPublicKey := publicKey.SerializeUncompressed()
+	if compressed {
+		serializedPublicKey = publicKey.SerializeCompressed()
+	}
+	address, err := bitcoinAddress(serializedPublicKey, network)
+	if err != nil {
+		c.logger.LogOnInternalErrorWithContext(c.logger.GetContext(), err)
+		return nil, false, err
+	}
+	if !bytes.Equal(doubleSha256([]byte(address))[:4], addressHash) {
+		err = errors.New(BIP38_WRONG_PASSPHRASE_ERROR)
+		c.logger.LogOnBadRequestErrorWithContext(c.logger.GetContext(), err)
+		return nil, false, err
+	}
+
+	c.logger.LogOnExitWithContext(c.logger.GetContext(), logger.SecretBytes(privateKey), compressed, err)
+	return privateKey, compressed, err
+}
+
+func (c *cryptoRepository) GetBitcoinAddress(privateKey []byte, compressed bool, network *chaincfg.Params) (string, error) {
+	_, publicKey := btcec.PrivKeyFromBytes(btcec.S256(), privateKey)
+	if compressed {
+		return bitcoinAddress(publicKey.SerializeCompressed(), network)
+	}
+	return bitcoinAddress(publicKey.SerializeUncompressed(), network)
+}
+
+func (c *cryptoRepository) GetBitcoinWIF(privateKey []byte, compressed bool, network *chaincfg.Params) (string, error) {
+	ecPrivateKey, _ := btcec.PrivKeyFromBytes(btcec.S256(), privateKey)
+	wif, err := btcutil.NewWIF(ecPrivateKey, network, compressed)
+	if err != nil {
+		return "", err
+	}
+	return wif.String(), nil
+}
+
+func bip38DecryptNonECMultiply(payload []byte, passphrase string) ([]byte, error) {
+	addressHash := payload[2:6]
+
+	derived, err := scrypt.Key(normalizeBip38Passphrase(passphrase), addressHash, 16384, 8, 8, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	decryptedHalves, err := aesDecryptBlocks(payload[6:38], derived[32:])
+	if err != nil {
+		return nil, err
+	}
+	return xorBytes(decryptedHalves, derived[:32]), nil
+}
+
+func bip38DecryptECMultiply(payload []byte, passphrase string) ([]byte, error) {
+	flag := payload[1]
+	addressHash := payload[2:6]
+	ownerEntropy := payload[6:14]
+	encryptedPart1 := payload[14:22]
+	encryptedPart2 := payload[22:38]
+
+	ownerSalt := ownerEntropy
+	if flag&bip38LotSequenceFlag != 0 {
+		ownerSalt = ownerEntropy[:4]
+	}
+	passFactor, err := scrypt.Key(normalizeBip38Passphrase(passphrase), ownerSalt, 16384, 8, 8, 32)
+	if err != nil {
+		return nil, err
+	}
+	if flag&bip38LotSequenceFlag != 0 {
+		passFactor = doubleSha256(append(passFactor, ownerEntropy...))
+	}
+	passPoint := serializeCompressedPoint(btcec.S256().ScalarBaseMult(passFactor))
+
+	derived, err := scrypt.Key(passPoint, append(append([]byte{}, addressHash...), ownerEntropy...), 1024, 1, 1, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	decryptedPart2, err := aesDecryptBlocks(encryptedPart2, derived[32:])
+	if err != nil {
+		return nil, err
+	}
+	decryptedPart2 = xorBytes(decryptedPart2, derived[16:32])
+
+	decryptedPart1, err := aesDecryptBlocks(append(append([]byte{}, encryptedPart1...), decryptedPart2[:8]...), derived[32:])
+	if err != nil {
+		return nil, err
+	}
+	decryptedPart1 = xorBytes(decryptedPart1, derived[:16])
+
+	seedB := append(decryptedPart1, decryptedPart2[8:]...)
+	factorB := new(big.Int).SetBytes(doubleSha256(seedB))
+	privateKey := new(big.Int).Mul(new(big.Int).SetBytes(passFactor), factorB)
+	privateKey.Mod(privateKey, btcec.S256().N)
+	return padTo32Bytes(privateKey.Bytes()), nil
+}
+
+func bitcoinAddress(serializedPublicKey []byte, network *chaincfg.Params) (string, error) {
+	address, err := btcutil.NewAddressPubKeyHash(btcutil.Hash160(serializedPublicKey), network)
+	if err != nil {
+		return "", err
+	}
+	return address.EncodeAddress(), nil
+}
+
+func serializeCompressedPoint(x *big.Int, y *big.Int) []byte {
+	publicKey := btcec.PublicKey{Curve: btcec.S256(), X: x, Y: y}
+	return publicKey.SerializeCompressed()
+}
+
+func doubleSha256(data []byte) []byte {
+	first := sha256.Sum256(data)
+	second := sha256.Sum256(first[:])
+	return second[:]
+}
+
+func aesEncryptBlocks(plaintext []byte, key []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext := make([]byte, len(plaintext))
+	for i := 0; i < len(plaintext); i += aes.BlockSize {
+		block.Encrypt(ciphertext[i:i+aes.BlockSize], plaintext[i:i+aes.BlockSize])
+	}
+	return ciphertext, nil
+}
+
+func aesDecryptBlocks(ciphertext []byte, key []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	plaintext := make([]byte, len(ciphertext))
+	for i := 0; i < len(ciphertext); i += aes.BlockSize {
+		block.Decrypt(plaintext[i:i+aes.BlockSize], ciphertext[i:i+aes.BlockSize])
+	}
+	return plaintext, nil
+}
+
+func xorBytes(a []byte, b []byte) []byte {
+	result := make([]byte, len(a))
+	for i := range a {
+		result[i] = a[i] ^ b[i]
+	}
+	return result
+}
+
+func padTo32Bytes(data []byte) []byte {
+	if len(data) >= 32 {
+		return data
+	}
+	padded := make([]byte, 32)
+	copy(padded[32-len(data):], data)
+	return padded
+}
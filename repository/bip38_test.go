@@ -0,0 +1,101 @@
+package repo
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"testing"
+
+	"swisswallet/logger"
+
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+func newTestCryptoRepository() CryptoRepository {
+	l := logger.NewLogger()
+	l.SetOutput(ioutil.Discard)
+	return NewCryptoRepository(l)
+}
+
+// Test vectors from BIP38, the third passphrase is given in its non-NFC form
+// as in the specification.
+var bip38Vectors = []struct {
+	encryptedKey string
+	passphrase   string
+	address      string
+}{
+	{"6PRVWUbkzzsbcVac2qwfssoUJAN1Xhrg6bNk8J7Nzm5H7kxEbn2Nh2ZoGg", "TestingOneTwoThree", "1Jq6MksXQVWzrznvZzxkV6oY57oWXD9TXB"},
+	{"6PRNFFkZc2NZ6dJqFfhRoFNMR9Lnyj7dYGrzdgXXVMXcxoKTePPX1dWByq", "Satoshi", "1AvKt49sui9zfzGeo8EyL8ypvAhtR2KwbL"},
+	{"6PRW5o9FLp4gJDDVqJQKJFTpMvdsSGJxMYHtHaQBF3ooa8mwD69bapcDQn", "\u03D2\u0301\u0000\U00010400\U0001F4A9", "16ktGzmfrurhbhi6JGqsMWf7TyqK9HNAeF"},
+	{"6PYNKZ1EAgYgmQfmNVamxyXVWHzK5s6DGhwP4J5o44cvXdoY7sRzhtpUeo", "TestingOneTwoThree", "164MQi977u9GUteHr4EPH27VkkdxmfCvGW"},
+	{"6PYLtMnXvfG3oJde97zRyLYFZCYizPU5T3LwgdYJz1fRhh16bU7u6PPmY7", "Satoshi", "1HmPbwsvG5qJ3KJfxzsZRZWhbm1xBMuS8B"},
+	{"6PfQu77ygVyJLZjfvMLyhLMQbYnu5uguoJJ4kMCLqWwPEdfpwANVS76gTX", "TestingOneTwoThree", "1PE6TQi6HTVNz5DLwB1LcpMBALubfuN2z2"},
+	{"6PfLGnQs6VZnrNpmVKfjotbnQuaJK4KZoPFrAjx1JMJUa1Ft8gnf5WxfKd", "Satoshi", "1CqzrtZC6mXSAhoxtFwVjz8LtwLJjDYU3V"},
+	{"6PgNBNNzDkKdhkT6uJntUXwwzQV8Rr2tZcbkDcuC9DZRsS6AtHts4Ypo1j", "MOLON LABE", "1Jscj8ALrYu2y9TD8NrpvDBugPedmbj4Yh"},
+}
+
+func TestBip38DecryptVectors(t *testing.T) {
+	c := newTestCryptoRepository()
+
+	for _, vector := range bip38Vectors {
+		privateKey, compressed, err := c.Bip38Decrypt(vector.encryptedKey, vector.passphrase, &chaincfg.MainNetParams)
+		if err != nil {
+			t.Errorf("Bip38Decrypt(%s): %v", vector.encryptedKey, err)
+			continue
+		}
+		address, err := c.GetBitcoinAddress(privateKey, compressed, &chaincfg.MainNetParams)
+		if err != nil || address != vector.address {
+			t.Errorf("Bip38Decrypt(%s) opens %s, %v, want %s", vector.encryptedKey, address, err, vector.address)
+		}
+	}
+}
+
+func TestBip38PassphraseIsNFCNormalized(t *testing.T) {
+	c := newTestCryptoRepository()
+	privateKey := make([]byte, 32)
+	privateKey[31] = 1
+
+	encryptedKey, err := c.Bip38Encrypt(privateKey, "caf\u00e9", &chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypted, _, err := c.Bip38Decrypt(encryptedKey, "cafe\u0301", &chaincfg.MainNetParams)
+	if err != nil || string(decrypted) != string(privateKey) {
+		t.Errorf("decomposed passphrase does not open a key encrypted with the composed one: %v", err)
+	}
+}
+
+// The non-EC-multiply vectors of BIP38 with their private keys. Encryption is
+// deterministic, its salt is the hash of the address, so Bip38Encrypt, which
+// always encrypts the compressed key, must give the compressed vectors.
+var bip38EncryptVectors = []struct {
+	privateKey   string
+	passphrase   string
+	uncompressed string
+	compressed   string
+}{
+	{"cbf4b9f70470856bb4f40f80b87edb90865997ffee6df315ab166d713af433a5", "TestingOneTwoThree", bip38Vectors[0].encryptedKey, bip38Vectors[3].encryptedKey},
+	{"09c2686880095b1a4c249ee3ac4eea8a014f11e6f986d0b5025ac1f39afbd9ae", "Satoshi", bip38Vectors[1].encryptedKey, bip38Vectors[4].encryptedKey},
+}
+
+func TestBip38EncryptVectors(t *testing.T) {
+	c := newTestCryptoRepository()
+
+	for _, vector := range bip38EncryptVectors {
+		privateKey, _ := hex.DecodeString(vector.privateKey)
+		encryptedKey, err := c.Bip38Encrypt(privateKey, vector.passphrase, &chaincfg.MainNetParams)
+		if err != nil || encryptedKey != vector.compressed {
+			t.Errorf("Bip38Encrypt(%s) = %s, %v, want %s", vector.privateKey, encryptedKey, err, vector.compressed)
+		}
+
+		for _, encrypted := range []string{vector.compressed, vector.uncompressed} {
+			decrypted, compressed, err := c.Bip38Decrypt(encrypted, vector.passphrase, &chaincfg.MainNetParams)
+			if err != nil || hex.EncodeToString(decrypted) != vector.privateKey || compressed != (encrypted == vector.compressed) {
+				t.Errorf("Bip38Decrypt(%s) = %x, compressed %t, %v, want %s", encrypted, decrypted, compressed, err, vector.privateKey)
+			}
+		}
+		_, _, err = c.Bip38Decrypt(vector.compressed, vector.passphrase+"!", &chaincfg.MainNetParams)
+		if err == nil {
+			t.Errorf("Bip38Decrypt(%s) accepted a wrong passphrase", vector.compressed)
+		}
+	}
+}
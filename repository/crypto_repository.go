@@ -5,10 +5,14 @@ import (
 	"crypto/cipher"
 	"errors"
 	"fmt"
+	"math/big"
 	"swisswallet/logger"
+	"swisswallet/model"
 
 	. "swisswallet/constants"
 
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/ethereum/go-ethereum/core/types"
 	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/scrypt"
 )
@@ -20,6 +24,19 @@ type CryptoRepository interface {
 	ScryptKdf(password string, salt string, difficulty string) ([]byte, error)
 	GetArgon2ParamsByDifficulty(difficulty string) (uint32, uint32, uint8, uint32, error)
 	GetScryptParamsByDifficulty(difficulty string) (int, int, int, int, error)
+	EncryptKeystore(privateKey []byte, password string, kdf string, metadata *model.KeystoreMetadata) ([]byte, error)
+	DecryptKeystore(keystoreJSON []byte, password string) ([]byte, error)
+	Bip38Encrypt(privateKey []byte, passphrase string, network *chaincfg.Params) (string, error)
+	Bip38Decrypt(encryptedKey string, passphrase string, network *chaincfg.Params) ([]byte, bool, error)
+	GetBitcoinAddress(privateKey []byte, compressed bool, network *chaincfg.Params) (string, error)
+	GetBitcoinWIF(privateKey []byte, compressed bool, network *chaincfg.Params) (string, error)
+	ParseUnsignedTransaction(input string, chainID *big.Int) (*types.Transaction, error)
+	SignTransaction(tx *types.Transaction, chainID *big.Int, privateKey []byte) (*types.Transaction, error)
+	VanityEntropy(entropy []byte, counter uint64) []byte
+	KeyfilePassword(password string, keyfile []byte) string
+	EstimatePasswordStrength(password string, userInputs []string, difficulty string) (*model.PasswordStrength, error)
+	GetKdfBytesPerGuess(difficulty string) (float64, error)
+	SelfTest() error
 }
 
 type cryptoRepository struct {
@@ -33,7 +50,7 @@ func NewCryptoRepository(logger *logger.Logger) CryptoRepository {
 }
 
 func (c *cryptoRepository) AesDecrypt(ciphertext []byte, key []byte, iv []byte) ([]byte, error) {
-	c.logger.LogOnEntryWithContext(c.logger.GetContext(), fmt.Sprintf("%x, %x, %x", ciphertext, key, iv))
+	c.logger.LogOnEntryWithContext(c.logger.GetContext(), logger.SecretBytes(ciphertext), logger.SecretBytes(key), logger.SecretBytes(iv))
 
 	block, err := aes.NewCipher(key)
 	if err != nil {
@@ -51,12 +68,12 @@ func (c *cryptoRepository) AesDecrypt(ciphertext []byte, key []byte, iv []byte)
 	cbc := cipher.NewCBCDecrypter(block, iv)
 	cbc.CryptBlocks(plaintext, ciphertext)
 
-	c.logger.LogOnExitWithContext(c.logger.GetContext(), fmt.Sprintf("%x", plaintext), err)
+	c.logger.LogOnExitWithContext(c.logger.GetContext(), logger.SecretBytes(plaintext), err)
 	return plaintext, err
 }
 
 func (c *cryptoRepository) AesEncrypt(plaintext []byte, key []byte, iv []byte) ([]byte, error) {
-	c.logger.LogOnEntryWithContext(c.logger.GetContext(), fmt.Sprintf("%x, %x, %x", plaintext, key, iv))
+	c.logger.LogOnEntryWithContext(c.logger.GetContext(), logger.SecretBytes(plaintext), logger.SecretBytes(key), logger.SecretBytes(iv))
 
 	if len(plaintext)%aes.BlockSize != 0 {
 		err := errors.New(AES_PLAINTEXT_NOT_MULTIPLE_ERROR)
@@ -74,12 +91,12 @@ func (c *cryptoRepository) AesEncrypt(plaintext []byte, key []byte, iv []byte) (
 	cbc := cipher.NewCBCEncrypter(block, iv)
 	cbc.CryptBlocks(ciphertext, plaintext)
 
-	c.logger.LogOnExitWithContext(c.logger.GetContext(), fmt.Sprintf("%x", ciphertext), err)
+	c.logger.LogOnExitWithContext(c.logger.GetContext(), logger.SecretBytes(ciphertext), err)
 	return ciphertext, err
 }
 
 func (c *cryptoRepository) Argon2Kdf(password string, salt string, difficulty string) ([]byte, error) {
-	c.logger.LogOnEntryWithContext(c.logger.GetContext(), password, salt, difficulty)
+	c.logger.LogOnEntryWithContext(c.logger.GetContext(), logger.Secret(password), logger.Secret(salt), difficulty)
 
 	time, memory, threads, keyLen, err := c.GetArgon2ParamsByDifficulty(difficulty)
 	if err != nil {
@@ -89,12 +106,12 @@ func (c *cryptoRepository) Argon2Kdf(password string, salt string, difficulty st
 
 	argon2Key := argon2.IDKey([]byte(password), []byte(salt), time, memory, threads, keyLen)
 
-	c.logger.LogOnExitWithContext(c.logger.GetContext(), fmt.Sprintf("%x", argon2Key))
+	c.logger.LogOnExitWithContext(c.logger.GetContext(), logger.SecretBytes(argon2Key))
 	return argon2Key, err
 }
 
 func (c *cryptoRepository) ScryptKdf(password string, salt string, difficulty string) ([]byte, error) {
-	c.logger.LogOnEntryWithContext(c.logger.GetContext(), password, salt, difficulty)
+	c.logger.LogOnEntryWithContext(c.logger.GetContext(), logger.Secret(password), logger.Secret(salt), difficulty)
 
 	N, r, p, keyLen, err := c.GetScryptParamsByDifficulty(difficulty)
 	if err != nil {
@@ -108,7 +125,7 @@ func (c *cryptoRepository) ScryptKdf(password string, salt string, difficulty st
 		return nil, err
 	}
 
-	c.logger.LogOnExitWithContext(c.logger.GetContext(), fmt.Sprintf("%x", scryptKey), err)
+	c.logger.LogOnExitWithContext(c.logger.GetContext(), logger.SecretBytes(scryptKey), err)
 	return scryptKey, err
 }
 
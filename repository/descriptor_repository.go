@@ -0,0 +1,257 @@
+package repo
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"swisswallet/logger"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/ethereum/go-ethereum/accounts"
+)
+
+type DescriptorRepository interface {
+	DeriveAccountKey(seed []byte, path string, private bool, network *chaincfg.Params) (string, string, error)
+	DerivePrivateKey(seed []byte, path string, network *chaincfg.Params) ([]byte, error)
+	AddChecksum(descriptor string) (string, error)
+	GetSortedMultisigAddresses(threshold int, accountKeys []string, branch uint32, count int, network *chaincfg.Params) ([]string, error)
+	SelfTest() error
+}
+
+type descriptorRepository struct {
+	logger *logger.Logger
+}
+
+func NewDescriptorRepository(logger *logger.Logger) DescriptorRepository {
+	return &descriptorRepository{
+		logger: logger,
+	}
+}
+
+// BIP380 descriptor checksum, a BCH code over the descriptor characters.
+const descriptorInputCharset = "0123456789()[],'/*abcdefgh@:$%{}IJKLMNOPQRSTUVWXYZ&+-.;<=>?!^_|~ijklmnopqrstuvwxyzABCDEFGH`#\"\\ "
+const descriptorChecksumCharset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+var descriptorGenerator = [5]uint64{0xf5dee51989, 0xa9fdca3312, 0x1bab10e32d, 0x3706b1677a, 0x644d626ffd}
+
+// DeriveAccountKey returns the extended key at path, an xpub or an xprv, and
+// the fingerprint of the master key for the key origin.
+func (d *descriptorRepository) DeriveAccountKey(seed []byte, path string, private bool, network *chaincfg.Params) (string, string, error) {
+	d.logger.LogOnEntryWithContext(d.logger.GetContext(), logger.SecretBytes(seed), path, private, network.Name)
+
+	derivationPath, err := accounts.ParseDerivationPath(path)
+	if err != nil {
+		d.logger.LogOnBadRequestErrorWithContext(d.logger.GetContext(), err)
+		return "", "", err
+	}
+
+	masterKey, err := hdkeychain.NewMaster(seed, network)
+	if err != nil {
+		d.logger.LogOnInternalErrorWithContext(d.logger.GetContext(), err)
+		return "", "", err
+	}
+	masterPublicKey, err := masterKey.ECPubKey()
+	if err != nil {
+		d.logger.LogOnInternalErrorWithContext(d.logger.GetContext(), err)
+		return "", "", err
+	}
+	fingerprint := fmt.Sprintf("%x", btcutil.Hash160(masterPublicKey.SerializeCompressed())[:4])
+
+	accountKey := masterKey
+	for _, index := range derivationPath {
+		accountKey, err = accountKey.Derive(index)
+		if err != nil {
+			d.logger.LogOnInternalErrorWithContext(d.logger.GetContext(), err)
+			return "", "", err
+		}
+	}
+	if !private {
+		accountKey, err = accountKey.Neuter()
+		if err != nil {
+			d.logger.LogOnInternalErrorWithContext(d.logger.GetContext(), err)
+			return "", "", err
+		}
+	}
+
+	d.logger.LogOnExitWithContext(d.logger.GetContext(), fingerprint, err)
+	return accountKey.String(), fingerprint, nil
+}
+
+// DerivePrivateKey returns the raw private key at a full derivation path.
+func (d *descriptorRepository) DerivePrivateKey(seed []byte, path string, network *chaincfg.Params) ([]byte, error) {
+	d.logger.LogOnEntryWithContext(d.logger.GetContext(), logger.SecretBytes(seed), path, network.Name)
+
+	derivationPath, err := accounts.ParseDerivationPath(path)
+	if err != nil {
+		d.logger.LogOnBadRequestErrorWithContext(d.logger.GetContext(), err)
+		return nil, err
+	}
+
+	key, err := hdkeychain.NewMaster(seed, network)
+	if err != nil {
+		d.logger.LogOnInternalErrorWithContext(d.logger.GetContext(), err)
+		return nil, err
+	}
+	for _, index := range derivationPath {
+		key, err = key.Derive(index)
+		if err != nil {
+			d.logger.LogOnInternalErrorWithContext(d.logger.GetContext(), err)
+			return nil, err
+		}
+	}
+	privateKey, err := key.ECPrivKey()
+	if err != nil {
+		d.logger.LogOnInternalErrorWithContext(d.logger.GetContext(), err)
+		return nil, err
+	}
+
+	d.logger.LogOnExitWithContext(d.logger.GetContext(), err)
+	return privateKey.Serialize(), nil
+}
+
+func (d *descriptorRepository) AddChecksum(descriptor string) (string, error) {
+	d.logger.LogOnEntryWithContext(d.logger.GetContext(), logger.Secret(descriptor))
+
+	var symbols []uint64
+	var groups []uint64
+	for _, c := range descriptor {
+		position := strings.IndexRune(descriptorInputCharset, c)
+		if position < 0 {
+			err := errors.New(fmt.Sprintf("Invalid character in descriptor: %q", c))
+			d.logger.LogOnBadRequestErrorWithContext(d.logger.GetContext(), err)
+			return "", err
+		}
+		symbols = append(symbols, uint64(position&31))
+		groups = append(groups, uint64(position>>5))
+		if len(groups) == 3 {
+			symbols = append(symbols, groups[0]*9+groups[1]*3+groups[2])
+			groups = groups[:0]
+		}
+	}
+	if len(groups) == 1 {
+		symbols = append(symbols, groups[0])
+	} else if len(groups) == 2 {
+		symbols = append(symbols, groups[0]*3+groups[1])
+	}
+
+	checksum := descriptorPolymod(append(symbols, 0, 0, 0, 0, 0, 0, 0, 0)) ^ 1
+	var encoded strings.Builder
+	for i := 0; i < 8; i++ {
+		encoded.WriteByte(descriptorChecksumCharset[(checksum>>(5*uint(7-i)))&31])
+	}
+
+	d.logger.LogOnExitWithContext(d.logger.GetContext(), encoded.String())
+	return descriptor + "#" + encoded.String(), nil
+}
+
+func descriptorPolymod(symbols []uint64) uint64 {
+	checksum := uint64(1)
+	for _, value := range symbols {
+		top := checksum >> 35
+		checksum = (checksum&0x7ffffffff)<<5 ^ value
+		for i := uint(0); i < 5; i++ {
+			if top>>i&1 == 1 {
+				checksum ^= descriptorGenerator[i]
+			}
+		}
+	}
+	return checksum
+}
+
+// GetSortedMultisigAddresses derives the wsh(sortedmulti()) addresses of the
+// first count children of branch, 0 for receive and 1 for change.
+func (d *descriptorRepository) GetSortedMultisigAddresses(threshold int, accountKeys []string, branch uint32, count int, network *chaincfg.Params) ([]string, error) {
+	d.logger.LogOnEntryWithContext(d.logger.GetContext(), threshold, accountKeys, branch, count, network.Name)
+
+	branchKeys := make([]*hdkeychain.ExtendedKey, len(accountKeys))
+	for i, accountKey := range accountKeys {
+		extendedKey, err := hdkeychain.NewKeyFromString(accountKey)
+		if err != nil {
+			d.logger.LogOnBadRequestErrorWithContext(d.logger.GetContext(), err)
+			return nil, err
+		}
+		branchKeys[i], err = extendedKey.Derive(branch)
+		if err != nil {
+			d.logger.LogOnInternalErrorWithContext(d.logger.GetContext(), err)
+			return nil, err
+		}
+	}
+
+	addresses := make([]string, count)
+	for index := range addresses {
+		publicKeys := make([][]byte, len(branchKeys))
+		for i, branchKey := range branchKeys {
+			childKey, err := branchKey.Derive(uint32(index))
+			if err != nil {
+				d.logger.LogOnInternalErrorWithContext(d.logger.GetContext(), err)
+				return nil, err
+			}
+			publicKey, err := childKey.ECPubKey()
+			if err != nil {
+				d.logger.LogOnInternalErrorWithContext(d.logger.GetContext(), err)
+				return nil, err
+			}
+			publicKeys[i] = publicKey.SerializeCompressed()
+		}
+		sort.Slice(publicKeys, func(i, j int) bool {
+			return bytes.Compare(publicKeys[i], publicKeys[j]) < 0
+		})
+
+		addressPublicKeys := make([]*btcutil.AddressPubKey, len(publicKeys))
+		for i, publicKey := range publicKeys {
+			addressPublicKey, err := btcutil.NewAddressPubKey(publicKey, network)
+			if err != nil {
+				d.logger.LogOnInternalErrorWithContext(d.logger.GetContext(), err)
+				return nil, err
+			}
+			addressPublicKeys[i] = addressPublicKey
+		}
+		script, err := txscript.MultiSigScript(addressPublicKeys, threshold)
+		if err != nil {
+			d.logger.LogOnBadRequestErrorWithContext(d.logger.GetContext(), err)
+			return nil, err
+		}
+		scriptHash := sha256.Sum256(script)
+		address, err := btcutil.NewAddressWitnessScriptHash(scriptHash[:], network)
+		if err != nil {
+			d.logger.LogOnInternalErrorWithContext(d.logger.GetContext(), err)
+			return nil, err
+		}
+		addresses[index] = address.EncodeAddress()
+	}
+
+	d.logger.LogOnExitWithContext(d.logger.GetContext(), addresses)
+	return addresses, nil
+}
+
+// Known answers from the BIP380 examples.
+var descriptorChecksumVectors = map[string]string{
+	"raw(deadbeef)": "raw(deadbeef)#89f8spxm",
+	"pkh([d34db33f/44'/0'/0']xpub6ERApfZwUNrhLCkDtcHTcxd75RbzS1ed54G1LkBUHQVHQKqhMkhgbmJbZRkrgZw4koxb5JaHWkY4ALHY2grBGRjaDMzQLcgJvLJuZZvRcEL/1/*)": "pkh([d34db33f/44'/0'/0']xpub6ERApfZwUNrhLCkDtcHTcxd75RbzS1ed54G1LkBUHQVHQKqhMkhgbmJbZRkrgZw4koxb5JaHWkY4ALHY2grBGRjaDMzQLcgJvLJuZZvRcEL/1/*)#ml40v0wf",
+}
+
+func (d *descriptorRepository) SelfTest() error {
+	d.logger.LogOnEntryWithContext(d.logger.GetContext())
+
+	for descriptor, expected := range descriptorChecksumVectors {
+		withChecksum, err := d.AddChecksum(descriptor)
+		if err != nil {
+			d.logger.LogOnInternalErrorWithContext(d.logger.GetContext(), err)
+			return err
+		}
+		if withChecksum != expected {
+			err = errors.New("Self-test failed: descriptor checksum does not match its known answer")
+			d.logger.LogOnInternalErrorWithContext(d.logger.GetContext(), err)
+			return err
+		}
+	}
+
+	d.logger.LogOnExitWithContext(d.logger.GetContext())
+	return nil
+}
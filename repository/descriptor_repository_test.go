@@ -0,0 +1,126 @@
+package repo
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io/ioutil"
+	"sort"
+	"testing"
+
+	"swisswallet/logger"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
+)
+
+func newTestDescriptorRepository() DescriptorRepository {
+	l := logger.NewLogger()
+	l.SetOutput(ioutil.Discard)
+	return NewDescriptorRepository(l)
+}
+
+func TestAddChecksumBip380Vectors(t *testing.T) {
+	d := newTestDescriptorRepository()
+
+	for descriptor, expected := range descriptorChecksumVectors {
+		withChecksum, err := d.AddChecksum(descriptor)
+		if err != nil || withChecksum != expected {
+			t.Errorf("AddChecksum(%s) = %s, %v, want %s", descriptor, withChecksum, err, expected)
+		}
+	}
+	_, err := d.AddChecksum("raw(deadbeef)\n")
+	if err == nil {
+		t.Error("AddChecksum accepted a character outside the descriptor charset")
+	}
+}
+
+// The wsh(sortedmulti()) example of BIP383, whose keys are the BIP32 test
+// vector 2 master key and its first child:
+//
+//	wsh(sortedmulti(1,xpub661My.../1/0/*,xpub69H7F.../0/0/*))
+//
+// Index 2 is where sortedmulti differs from multi with the same keys. The
+// checksum is the one AddChecksum gives, which the BIP380 vectors pin above.
+var (
+	bip383MasterKey  = "xpub661MyMwAqRbcFW31YEwpkMuc5THy2PSt5bDMsktWQcFF8syAmRUapSCGu8ED9W6oDMSgv6Zz8idoc4a6mr8BDzTJY47LJhkJ8UB7WEGuduB"
+	bip383ChildKey   = "xpub69H7F5d8KSRgmmdJg2KhpAK8SR3DjMwAdkxj3ZuxV27CprR9LgpeyGmXUbC6wb7ERfvrnKZjXoUmmDznezpbZb7ap6r1D3tgFxHmwMkQTPH"
+	bip383Descriptor = "wsh(sortedmulti(1," + bip383MasterKey + "/1/0/*," + bip383ChildKey + "/0/0/*))#v66cvalc"
+	bip383Addresses  = []string{
+		"bc1qvjtfmrxu524qhdevl6yyyasjs7xmnzjlqlu60mrwepact60eyz9s9xjw0c",
+		"bc1qp6rfclasvmwys7w7j4svgc2mrujq9m73s5shpw4e799hwkdcqlcsj464fw",
+		"bc1qvxcjrqhrkdkkuujfk3enulmwve5r2x4cm9f4q8g64kg64q7puyvsv8vkcm",
+	}
+)
+
+func childKeyString(t *testing.T, extendedKey string, path ...uint32) string {
+	key, err := hdkeychain.NewKeyFromString(extendedKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, index := range path {
+		key, err = key.Derive(index)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return key.String()
+}
+
+func TestGetSortedMultisigAddressesBip383(t *testing.T) {
+	d := newTestDescriptorRepository()
+	accountKeys := []string{childKeyString(t, bip383MasterKey, 1), childKeyString(t, bip383ChildKey, 0)}
+
+	descriptor, err := d.AddChecksum("wsh(sortedmulti(1," + bip383MasterKey + "/1/0/*," + bip383ChildKey + "/0/0/*))")
+	if err != nil || descriptor != bip383Descriptor {
+		t.Errorf("descriptor %s, %v, want %s", descriptor, err, bip383Descriptor)
+	}
+
+	for _, keys := range [][]string{accountKeys, {accountKeys[1], accountKeys[0]}} {
+		addresses, err := d.GetSortedMultisigAddresses(1, keys, 0, len(bip383Addresses), &chaincfg.MainNetParams)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, address := range addresses {
+			if address != bip383Addresses[i] {
+				t.Errorf("receive address %d is %s, want %s", i, address, bip383Addresses[i])
+			}
+		}
+	}
+
+	// The unsorted multi() address at index 2 must differ, or the vector
+	// would not tell the two apart.
+	var publicKeys []*btcutil.AddressPubKey
+	for _, accountKey := range accountKeys {
+		key, _ := hdkeychain.NewKeyFromString(childKeyString(t, accountKey, 0, 2))
+		publicKey, _ := key.ECPubKey()
+		addressPublicKey, _ := btcutil.NewAddressPubKey(publicKey.SerializeCompressed(), &chaincfg.MainNetParams)
+		publicKeys = append(publicKeys, addressPublicKey)
+	}
+	script, _ := txscript.MultiSigScript(publicKeys, 1)
+	scriptHash := sha256.Sum256(script)
+	unsorted, _ := btcutil.NewAddressWitnessScriptHash(scriptHash[:], &chaincfg.MainNetParams)
+	if unsorted.EncodeAddress() == bip383Addresses[2] {
+		t.Errorf("multi() and sortedmulti() give the same address %s at index 2", unsorted.EncodeAddress())
+	}
+	if sort.SliceIsSorted(publicKeys, func(i, j int) bool {
+		return bytes.Compare(publicKeys[i].ScriptAddress(), publicKeys[j].ScriptAddress()) < 0
+	}) {
+		t.Error("the keys at index 2 are already sorted")
+	}
+}
+
+func TestGetSortedMultisigAddressesRejectsBadArguments(t *testing.T) {
+	d := newTestDescriptorRepository()
+	accountKey := childKeyString(t, bip383MasterKey, 1)
+
+	_, err := d.GetSortedMultisigAddresses(1, []string{accountKey, "xpub-not-a-key"}, 0, 1, &chaincfg.MainNetParams)
+	if err == nil {
+		t.Error("GetSortedMultisigAddresses accepted an invalid account key")
+	}
+	_, err = d.GetSortedMultisigAddresses(3, []string{accountKey, childKeyString(t, bip383ChildKey, 0)}, 0, 1, &chaincfg.MainNetParams)
+	if err == nil {
+		t.Error("GetSortedMultisigAddresses accepted a threshold above the number of keys")
+	}
+}
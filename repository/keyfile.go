@@ -0,0 +1,14 @@
+package repo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// KeyfilePassword appends the SHA-256 of the keyfile to a KDF password. The
+// NUL separator cannot appear in a password given on the command line, so no
+// other password and keyfile pair gives the same KDF input.
+func (c *cryptoRepository) KeyfilePassword(password string, keyfile []byte) string {
+	hash := sha256.Sum256(keyfile)
+	return password + "\x00" + hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,128 @@
+package repo
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"swisswallet/logger"
+	"swisswallet/model"
+
+	. "swisswallet/constants"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+func (c *cryptoRepository) EncryptKeystore(privateKey []byte, password string, kdf string, metadata *model.KeystoreMetadata) ([]byte, error) {
+	c.logger.LogOnEntryWithContext(c.logger.GetContext(), logger.SecretBytes(privateKey), logger.Secret(password), kdf, metadata)
+
+	ecdsaKey, err := ethcrypto.ToECDSA(privateKey)
+	if err != nil {
+		c.logger.LogOnBadRequestErrorWithContext(c.logger.GetContext(), err)
+		return nil, err
+	}
+
+	var cryptoJSON keystore.CryptoJSON
+	switch kdf {
+	case SCRYPT_KEYSTORE_KDF:
+		cryptoJSON, err = keystore.EncryptDataV3(privateKey, []byte(password), keystore.StandardScryptN, keystore.StandardScryptP)
+	case PBKDF2_KEYSTORE_KDF:
+		cryptoJSON, err = encryptDataV3WithPbkdf2(privateKey, []byte(password))
+	default:
+		err = errors.New(fmt.Sprintf("Keystore KDF not supported: %s", kdf))
+	}
+	if err != nil {
+		c.logger.LogOnBadRequestErrorWithContext(c.logger.GetContext(), err)
+		return nil, err
+	}
+
+	id, err := newRandomUUID()
+	if err != nil {
+		c.logger.LogOnInternalErrorWithContext(c.logger.GetContext(), err)
+		return nil, err
+	}
+
+	keystoreJSON, err := json.Marshal(model.Keystore{
+		Address:  hex.EncodeToString(ethcrypto.PubkeyToAddress(ecdsaKey.PublicKey).Bytes()),
+		Crypto:   cryptoJSON,
+		Id:       id,
+		Version:  KEYSTORE_VERSION,
+		Metadata: metadata,
+	})
+	if err != nil {
+		c.logger.LogOnInternalErrorWithContext(c.logger.GetContext(), err)
+		return nil, err
+	}
+
+	c.logger.LogOnExitWithContext(c.logger.GetContext(), string(keystoreJSON), err)
+	return keystoreJSON, err
+}
+
+func (c *cryptoRepository) DecryptKeystore(keystoreJSON []byte, password string) ([]byte, error) {
+	c.logger.LogOnEntryWithContext(c.logger.GetContext(), string(keystoreJSON), logger.Secret(password))
+
+	key, err := keystore.DecryptKey(keystoreJSON, password)
+	if err != nil {
+		c.logger.LogOnBadRequestErrorWithContext(c.logger.GetContext(), err)
+		return nil, err
+	}
+	privateKey := ethcrypto.FromECDSA(key.PrivateKey)
+
+	c.logger.LogOnExitWithContext(c.logger.GetContext(), logger.SecretBytes(privateKey), err)
+	return privateKey, err
+}
+
+// go-ethereum only writes scrypt keystores, so the pbkdf2 variant of Web3
+// Secret Storage is built here. It is decrypted by keystore.DecryptKey.
+func encryptDataV3WithPbkdf2(data []byte, password []byte) (keystore.CryptoJSON, error) {
+	var cryptoJSON keystore.CryptoJSON
+
+	salt := make([]byte, 32)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return cryptoJSON, err
+	}
+	derivedKey := pbkdf2.Key(password, salt, KEYSTORE_PBKDF2_ITERATIONS, 32, sha256.New)
+
+	iv := make([]byte, aes.BlockSize)
+	_, err = rand.Read(iv)
+	if err != nil {
+		return cryptoJSON, err
+	}
+	block, err := aes.NewCipher(derivedKey[:16])
+	if err != nil {
+		return cryptoJSON, err
+	}
+	cipherText := make([]byte, len(data))
+	cipher.NewCTR(block, iv).XORKeyStream(cipherText, data)
+
+	cryptoJSON.Cipher = "aes-128-ctr"
+	cryptoJSON.CipherText = hex.EncodeToString(cipherText)
+	cryptoJSON.CipherParams.IV = hex.EncodeToString(iv)
+	cryptoJSON.KDF = PBKDF2_KEYSTORE_KDF
+	cryptoJSON.KDFParams = map[string]interface{}{
+		"c":     KEYSTORE_PBKDF2_ITERATIONS,
+		"prf":   "hmac-sha256",
+		"dklen": 32,
+		"salt":  hex.EncodeToString(salt),
+	}
+	cryptoJSON.MAC = hex.EncodeToString(ethcrypto.Keccak256(derivedKey[16:32], cipherText))
+	return cryptoJSON, nil
+}
+
+func newRandomUUID() (string, error) {
+	id := make([]byte, 16)
+	_, err := rand.Read(id)
+	if err != nil {
+		return "", err
+	}
+	id[6] = id[6]&0x0f | 0x40
+	id[8] = id[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:]), nil
+}
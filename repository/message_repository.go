@@ -0,0 +1,469 @@
+package repo
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"swisswallet/logger"
+
+	. "swisswallet/constants"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+type MessageRepository interface {
+	SignEthereumMessage(privateKey []byte, message []byte, typed bool) (string, error)
+	VerifyEthereumMessage(address string, message []byte, typed bool, signature string) error
+	GetBitcoinAddress(privateKey []byte, addressType string, network *chaincfg.Params) (string, error)
+	SignBitcoinMessage(privateKey []byte, addressType string, message []byte, network *chaincfg.Params) (string, error)
+	VerifyBitcoinMessage(address string, message []byte, signature string, network *chaincfg.Params) error
+	SelfTest() error
+}
+
+type messageRepository struct {
+	logger *logger.Logger
+}
+
+func NewMessageRepository(logger *logger.Logger) MessageRepository {
+	return &messageRepository{
+		logger: logger,
+	}
+}
+
+const bitcoinMessageMagic = "Bitcoin Signed Message:\n"
+
+// Header bytes of compact signatures: 27 for uncompressed P2PKH keys, 31 for
+// compressed ones and, following BIP137, 35 for P2SH-P2WPKH and 39 for P2WPKH.
+const (
+	compactHeaderUncompressed = 27
+	compactHeaderCompressed   = 31
+	compactHeaderP2SHP2WPKH   = 35
+	compactHeaderP2WPKH       = 39
+	compactSignatureLength    = 65
+)
+
+func ethereumMessageHash(message []byte, typed bool) ([]byte, error) {
+	if typed {
+		return typedDataHash(message)
+	}
+	return accounts.TextHash(message), nil
+}
+
+// SignEthereumMessage signs an EIP-191 personal_sign message, or EIP-712 typed
+// data given as its JSON, and returns the 65 byte r || s || v signature with v
+// being 27 or 28.
+func (m *messageRepository) SignEthereumMessage(privateKey []byte, message []byte, typed bool) (string, error) {
+	m.logger.LogOnEntryWithContext(m.logger.GetContext(), logger.SecretBytes(privateKey), len(message), typed)
+
+	hash, err := ethereumMessageHash(message, typed)
+	if err != nil {
+		m.logger.LogOnBadRequestErrorWithContext(m.logger.GetContext(), err)
+		return "", err
+	}
+	ecdsaKey, err := ethcrypto.ToECDSA(privateKey)
+	if err != nil {
+		m.logger.LogOnBadRequestErrorWithContext(m.logger.GetContext(), err)
+		return "", err
+	}
+	signature, err := ethcrypto.Sign(hash, ecdsaKey)
+	if err != nil {
+		m.logger.LogOnInternalErrorWithContext(m.logger.GetContext(), err)
+		return "", err
+	}
+	signature[64] += 27
+
+	m.logger.LogOnExitWithContext(m.logger.GetContext(), hexutil.Encode(signature))
+	return hexutil.Encode(signature), nil
+}
+
+func (m *messageRepository) VerifyEthereumMessage(address string, message []byte, typed bool, signature string) error {
+	m.logger.LogOnEntryWithContext(m.logger.GetContext(), address, len(message), typed, signature)
+
+	if !common.IsHexAddress(address) {
+		err := errors.New(fmt.Sprintf("Not an Ethereum address: %s", address))
+		m.logger.LogOnBadRequestErrorWithContext(m.logger.GetContext(), err)
+		return err
+	}
+	hash, err := ethereumMessageHash(message, typed)
+	if err != nil {
+		m.logger.LogOnBadRequestErrorWithContext(m.logger.GetContext(), err)
+		return err
+	}
+	decoded, err := hexutil.Decode(signature)
+	if err != nil || len(decoded) != compactSignatureLength {
+		err = errors.New("Ethereum signature must be 65 bytes of 0x prefixed hex")
+		m.logger.LogOnBadRequestErrorWithContext(m.logger.GetContext(), err)
+		return err
+	}
+	if decoded[64] >= 27 {
+		decoded[64] -= 27
+	}
+	publicKey, err := ethcrypto.SigToPub(hash, decoded)
+	if err != nil {
+		m.logger.LogOnBadRequestErrorWithContext(m.logger.GetContext(), err)
+		return err
+	}
+	if ethcrypto.PubkeyToAddress(*publicKey) != common.HexToAddress(address) {
+		err = errors.New(VERIFY_NO_MATCH)
+		m.logger.LogOnBadRequestErrorWithContext(m.logger.GetContext(), err)
+		return err
+	}
+
+	m.logger.LogOnExitWithContext(m.logger.GetContext())
+	return nil
+}
+
+func bitcoinMessageHash(message []byte) []byte {
+	var buffer bytes.Buffer
+	wire.WriteVarString(&buffer, 0, bitcoinMessageMagic)
+	wire.WriteVarBytes(&buffer, 0, message)
+	return chainhash.DoubleHashB(buffer.Bytes())
+}
+
+// bitcoinScript returns the output script of a P2PKH, P2SH or segwit address
+// of the network.
+func bitcoinScript(address string, network *chaincfg.Params) ([]byte, error) {
+	if strings.HasPrefix(strings.ToLower(address), network.Bech32HRPSegwit+"1") {
+		hrp, version, program, err := decodeSegwitAddress(address)
+		if err != nil {
+			return nil, err
+		}
+		if hrp != network.Bech32HRPSegwit {
+			return nil, errors.New(fmt.Sprintf("Address is not for %s: %s", network.Name, address))
+		}
+		opcode := byte(txscript.OP_0)
+		if version > 0 {
+			opcode = txscript.OP_1 + version - 1
+		}
+		return append([]byte{opcode, byte(len(program))}, program...), nil
+	}
+	decoded, err := btcutil.DecodeAddress(address, network)
+	if err != nil {
+		return nil, err
+	}
+	if !decoded.IsForNet(network) {
+		return nil, errors.New(fmt.Sprintf("Address is not for %s: %s", network.Name, address))
+	}
+	return txscript.PayToAddrScript(decoded)
+}
+
+// bitcoinScriptFromKey returns the output script of the given address type
+// for a compressed public key, with the redeem script for P2SH-P2WPKH.
+func bitcoinScriptFromKey(publicKey []byte, addressType string) ([]byte, error) {
+	keyHash := btcutil.Hash160(publicKey)
+	p2wpkh := append([]byte{txscript.OP_0, 0x14}, keyHash...)
+	switch addressType {
+	case P2PKH_ADDRESS_TYPE:
+		return append(append([]byte{txscript.OP_DUP, txscript.OP_HASH160, 0x14}, keyHash...), txscript.OP_EQUALVERIFY, txscript.OP_CHECKSIG), nil
+	case P2SH_P2WPKH_ADDRESS_TYPE:
+		return append(append([]byte{txscript.OP_HASH160, 0x14}, btcutil.Hash160(p2wpkh)...), txscript.OP_EQUAL), nil
+	case P2WPKH_ADDRESS_TYPE:
+		return p2wpkh, nil
+	case P2TR_ADDRESS_TYPE:
+		outputKey, err := taprootOutputKey(publicKey[1:], nil)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte{txscript.OP_1, 0x20}, outputKey...), nil
+	default:
+		return nil, errors.New(fmt.Sprintf("Address type not supported: %s", addressType))
+	}
+}
+
+func (m *messageRepository) GetBitcoinAddress(privateKey []byte, addressType string, network *chaincfg.Params) (string, error) {
+	m.logger.LogOnEntryWithContext(m.logger.GetContext(), logger.SecretBytes(privateKey), addressType, network.Name)
+
+	_, publicKey := btcec.PrivKeyFromBytes(btcec.S256(), privateKey)
+	script, err := bitcoinScriptFromKey(publicKey.SerializeCompressed(), addressType)
+	if err != nil {
+		m.logger.LogOnBadRequestErrorWithContext(m.logger.GetContext(), err)
+		return "", err
+	}
+	address := scriptAddress(script, network)
+
+	m.logger.LogOnExitWithContext(m.logger.GetContext(), address)
+	return address, nil
+}
+
+// SignBitcoinMessage uses the legacy signmessage format for P2PKH, its BIP137
+// variant for P2SH-P2WPKH, and a BIP322 simple signature for native segwit.
+func (m *messageRepository) SignBitcoinMessage(privateKey []byte, addressType string, message []byte, network *chaincfg.Params) (string, error) {
+	m.logger.LogOnEntryWithContext(m.logger.GetContext(), logger.SecretBytes(privateKey), addressType, len(message), network.Name)
+
+	key, publicKey := btcec.PrivKeyFromBytes(btcec.S256(), privateKey)
+	var signature []byte
+	var err error
+	switch addressType {
+	case P2PKH_ADDRESS_TYPE, P2SH_P2WPKH_ADDRESS_TYPE:
+		signature, err = btcec.SignCompact(btcec.S256(), key, bitcoinMessageHash(message), true)
+		if err == nil && addressType == P2SH_P2WPKH_ADDRESS_TYPE {
+			signature[0] += compactHeaderP2SHP2WPKH - compactHeaderCompressed
+		}
+	case P2WPKH_ADDRESS_TYPE, P2TR_ADDRESS_TYPE:
+		var script []byte
+		script, err = bitcoinScriptFromKey(publicKey.SerializeCompressed(), addressType)
+		if err == nil {
+			signature, err = bip322Sign(key, script, message)
+		}
+	default:
+		err = errors.New(fmt.Sprintf("Address type not supported: %s", addressType))
+	}
+	if err != nil {
+		m.logger.LogOnBadRequestErrorWithContext(m.logger.GetContext(), err)
+		return "", err
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(signature)
+	m.logger.LogOnExitWithContext(m.logger.GetContext(), encoded)
+	return encoded, nil
+}
+
+func (m *messageRepository) VerifyBitcoinMessage(address string, message []byte, signature string, network *chaincfg.Params) error {
+	m.logger.LogOnEntryWithContext(m.logger.GetContext(), address, len(message), signature, network.Name)
+
+	script, err := bitcoinScript(address, network)
+	if err != nil {
+		m.logger.LogOnBadRequestErrorWithContext(m.logger.GetContext(), err)
+		return err
+	}
+	decoded, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		m.logger.LogOnBadRequestErrorWithContext(m.logger.GetContext(), err)
+		return err
+	}
+
+	if len(decoded) == compactSignatureLength && decoded[0] >= compactHeaderUncompressed && decoded[0] < compactHeaderP2WPKH+4 {
+		err = verifyCompactMessage(script, message, decoded)
+	} else {
+		err = bip322Verify(script, message, decoded)
+	}
+	if err != nil {
+		m.logger.LogOnBadRequestErrorWithContext(m.logger.GetContext(), err)
+		return err
+	}
+
+	m.logger.LogOnExitWithContext(m.logger.GetContext())
+	return nil
+}
+
+// verifyCompactMessage recovers the key of a compact signature. Headers below
+// 35 are also accepted for segwit addresses, as Electrum signs them that way.
+func verifyCompactMessage(script []byte, message []byte, signature []byte) error {
+	header := signature[0]
+	addressTypes := []string{P2PKH_ADDRESS_TYPE, P2SH_P2WPKH_ADDRESS_TYPE, P2WPKH_ADDRESS_TYPE}
+	switch {
+	case header >= compactHeaderP2WPKH:
+		header -= compactHeaderP2WPKH - compactHeaderCompressed
+		addressTypes = []string{P2WPKH_ADDRESS_TYPE}
+	case header >= compactHeaderP2SHP2WPKH:
+		header -= compactHeaderP2SHP2WPKH - compactHeaderCompressed
+		addressTypes = []string{P2SH_P2WPKH_ADDRESS_TYPE}
+	}
+	recoverable := append([]byte{header}, signature[1:]...)
+	publicKey, compressed, err := btcec.RecoverCompact(btcec.S256(), recoverable, bitcoinMessageHash(message))
+	if err != nil {
+		return err
+	}
+
+	if !compressed {
+		uncompressedHash := btcutil.Hash160(publicKey.SerializeUncompressed())
+		expected := append(append([]byte{txscript.OP_DUP, txscript.OP_HASH160, 0x14}, uncompressedHash...), txscript.OP_EQUALVERIFY, txscript.OP_CHECKSIG)
+		if bytes.Equal(script, expected) {
+			return nil
+		}
+		return errors.New(VERIFY_NO_MATCH)
+	}
+	for _, addressType := range addressTypes {
+		expected, err := bitcoinScriptFromKey(publicKey.SerializeCompressed(), addressType)
+		if err == nil && bytes.Equal(script, expected) {
+			return nil
+		}
+	}
+	return errors.New(VERIFY_NO_MATCH)
+}
+
+// bip322Transactions builds the virtual to_spend and to_sign transactions of
+// BIP322 for a message and the output script of the address.
+func bip322Transactions(script []byte, message []byte) *wire.MsgTx {
+	messageHash := taggedHash("BIP0322-signed-message", message)
+	toSpend := wire.NewMsgTx(0)
+	toSpend.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Index: 0xffffffff},
+		SignatureScript:  append([]byte{txscript.OP_0, txscript.OP_DATA_32}, messageHash...),
+		Sequence:         0,
+	})
+	toSpend.AddTxOut(wire.NewTxOut(0, script))
+
+	toSign := wire.NewMsgTx(0)
+	toSign.AddTxIn(&wire.TxIn{PreviousOutPoint: wire.OutPoint{Hash: toSpend.TxHash(), Index: 0}, Sequence: 0})
+	toSign.AddTxOut(wire.NewTxOut(0, []byte{txscript.OP_RETURN}))
+	return toSign
+}
+
+func bip322Sign(key *btcec.PrivateKey, script []byte, message []byte) ([]byte, error) {
+	toSign := bip322Transactions(script, message)
+	var witness wire.TxWitness
+	if isP2TR(script) {
+		sighash, err := taprootKeySpendSighash(toSign, 0, []int64{0}, [][]byte{script}, taprootSighashDefault)
+		if err != nil {
+			return nil, err
+		}
+		tweakedKey, err := taprootTweakPrivateKey(key.Serialize(), nil)
+		if err != nil {
+			return nil, err
+		}
+		auxRand := make([]byte, 32)
+		_, err = rand.Read(auxRand)
+		if err != nil {
+			return nil, err
+		}
+		signature, err := schnorrSign(tweakedKey, sighash, auxRand)
+		if err != nil {
+			return nil, err
+		}
+		witness = wire.TxWitness{signature}
+	} else {
+		signature, err := txscript.RawTxInWitnessSignature(toSign, txscript.NewTxSigHashes(toSign), 0, 0, script, txscript.SigHashAll, key)
+		if err != nil {
+			return nil, err
+		}
+		witness = wire.TxWitness{signature, key.PubKey().SerializeCompressed()}
+	}
+
+	var encoded bytes.Buffer
+	err := wire.WriteVarInt(&encoded, 0, uint64(len(witness)))
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range witness {
+		err = wire.WriteVarBytes(&encoded, 0, item)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return encoded.Bytes(), nil
+}
+
+// bip322Verify checks a BIP322 simple signature, the witness of to_sign, for
+// P2WPKH and P2TR key path spends.
+func bip322Verify(script []byte, message []byte, signature []byte) error {
+	reader := bytes.NewReader(signature)
+	count, err := wire.ReadVarInt(reader, 0)
+	if err != nil || count > 2 {
+		return errors.New("Signature is neither a compact nor a BIP322 simple signature")
+	}
+	witness := make(wire.TxWitness, count)
+	for i := range witness {
+		witness[i], err = wire.ReadVarBytes(reader, 0, txscript.MaxScriptSize, "witness item")
+		if err != nil {
+			return err
+		}
+	}
+	if reader.Len() != 0 {
+		return errors.New("BIP322 signature has trailing data")
+	}
+
+	toSign := bip322Transactions(script, message)
+	switch {
+	case isP2WPKH(script):
+		if len(witness) != 2 || len(witness[0]) == 0 || witness[0][len(witness[0])-1] != byte(txscript.SigHashAll) {
+			return errors.New("BIP322 P2WPKH signature needs a SIGHASH_ALL signature and a public key")
+		}
+		if !bytes.Equal(btcutil.Hash160(witness[1]), script[2:]) {
+			return errors.New(VERIFY_NO_MATCH)
+		}
+		publicKey, err := btcec.ParsePubKey(witness[1], btcec.S256())
+		if err != nil {
+			return err
+		}
+		parsedSignature, err := btcec.ParseDERSignature(witness[0][:len(witness[0])-1], btcec.S256())
+		if err != nil {
+			return err
+		}
+		sighash, err := txscript.CalcWitnessSigHash(script, txscript.NewTxSigHashes(toSign), txscript.SigHashAll, toSign, 0, 0)
+		if err != nil {
+			return err
+		}
+		if !parsedSignature.Verify(sighash, publicKey) {
+			return errors.New(VERIFY_NO_MATCH)
+		}
+	case isP2TR(script):
+		if len(witness) != 1 || (len(witness[0]) != 64 && len(witness[0]) != 65) {
+			return errors.New("BIP322 P2TR signature needs a single Schnorr signature")
+		}
+		hashType := byte(taprootSighashDefault)
+		if len(witness[0]) == 65 {
+			hashType = witness[0][64]
+			if hashType == taprootSighashDefault {
+				return errors.New("BIP322 P2TR signature has an explicit SIGHASH_DEFAULT")
+			}
+		}
+		sighash, err := taprootKeySpendSighash(toSign, 0, []int64{0}, [][]byte{script}, hashType)
+		if err != nil {
+			return err
+		}
+		if !schnorrVerify(script[2:], sighash, witness[0][:64]) {
+			return errors.New(VERIFY_NO_MATCH)
+		}
+	default:
+		return errors.New("BIP322 simple signatures are only verified for P2WPKH and P2TR addresses")
+	}
+	return nil
+}
+
+// Known answers from the EIP-712 and BIP322 specifications.
+const (
+	typedDataVector          = `{"types":{"EIP712Domain":[{"name":"name","type":"string"},{"name":"version","type":"string"},{"name":"chainId","type":"uint256"},{"name":"verifyingContract","type":"address"}],"Person":[{"name":"name","type":"string"},{"name":"wallet","type":"address"}],"Mail":[{"name":"from","type":"Person"},{"name":"to","type":"Person"},{"name":"contents","type":"string"}]},"primaryType":"Mail","domain":{"name":"Ether Mail","version":"1","chainId":1,"verifyingContract":"0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"},"message":{"from":{"name":"Cow","wallet":"0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"},"to":{"name":"Bob","wallet":"0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"},"contents":"Hello, Bob!"}}`
+	typedDataAddressVector   = "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"
+	typedDataSignatureVector = "0x4355c47d63924e8a72e509b65029052eb6c299d53a04e167c5775fd466751c9d07299936d304c153f6443dfa05f40ff007d72911b6f72307f996231605b915621c"
+	bip322AddressVector      = "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l"
+	bip322MessageVector      = "Hello World"
+	bip322SignatureVector    = "AkcwRAIgZRfIY3p7/DoVTty6YZbWS71bc5Vct9p9Fia83eRmw2QCICK/ENGfwLtptFluMGs2KsqoNSk89pO7F29zJLUx9a/sASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHI="
+	bip322HashVector         = "f0eb03b1a75ac6d9847f55c624a99169b5dccba2a31f5b23bea77ba270de0a7a"
+)
+
+func (m *messageRepository) SelfTest() error {
+	m.logger.LogOnEntryWithContext(m.logger.GetContext())
+
+	privateKey := ethcrypto.Keccak256([]byte("cow"))
+	signature, err := m.SignEthereumMessage(privateKey, []byte(typedDataVector), true)
+	if err != nil || signature != typedDataSignatureVector {
+		err = errors.New("Self-test failed: EIP-712 signature does not match its known answer")
+		m.logger.LogOnInternalErrorWithContext(m.logger.GetContext(), err)
+		return err
+	}
+	err = m.VerifyEthereumMessage(typedDataAddressVector, []byte(typedDataVector), true, typedDataSignatureVector)
+	if err != nil {
+		err = errors.New("Self-test failed: EIP-712 signature does not verify")
+		m.logger.LogOnInternalErrorWithContext(m.logger.GetContext(), err)
+		return err
+	}
+
+	if hex.EncodeToString(taggedHash("BIP0322-signed-message", []byte(bip322MessageVector))) != bip322HashVector {
+		err = errors.New("Self-test failed: BIP322 message hash does not match its known answer")
+		m.logger.LogOnInternalErrorWithContext(m.logger.GetContext(), err)
+		return err
+	}
+	err = m.VerifyBitcoinMessage(bip322AddressVector, []byte(bip322MessageVector), bip322SignatureVector, &chaincfg.MainNetParams)
+	if err != nil {
+		err = errors.New("Self-test failed: BIP322 signature does not verify")
+		m.logger.LogOnInternalErrorWithContext(m.logger.GetContext(), err)
+		return err
+	}
+
+	m.logger.LogOnExitWithContext(m.logger.GetContext())
+	return nil
+}
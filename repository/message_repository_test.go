@@ -0,0 +1,250 @@
+package repo
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"io/ioutil"
+	"testing"
+
+	"swisswallet/logger"
+
+	. "swisswallet/constants"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+func newTestMessageRepository() MessageRepository {
+	l := logger.NewLogger()
+	l.SetOutput(ioutil.Discard)
+	return NewMessageRepository(l)
+}
+
+// The key of the BIP322 test vectors and its addresses.
+const (
+	bip322KeyVector         = "L3VFeEujGtevx9w18HD1fhRbCH67Az2dpCymeRE1SoPK6XQtaN2k"
+	bip322P2PKHVector       = "14vV3aCHBeStb5bkenkNHbe2YAFinYdXgc"
+	bip322P2SHP2WPKHVector  = "37qyp7jQAzqb2rCBpMvVtLDuuzKAUCVnJb"
+	bip322P2TRAddressVector = "bc1ppv609nr0vr25u07u95waq5lucwfm6tde4nydujnu8npg4q75mr5sxq8lt3"
+)
+
+func bip322Key(t *testing.T) []byte {
+	wif, err := btcutil.DecodeWIF(bip322KeyVector)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return wif.PrivKey.Serialize()
+}
+
+func TestGetBitcoinAddressOfTheBip322Key(t *testing.T) {
+	m := newTestMessageRepository()
+	privateKey := bip322Key(t)
+
+	for addressType, expected := range map[string]string{
+		P2PKH_ADDRESS_TYPE:       bip322P2PKHVector,
+		P2SH_P2WPKH_ADDRESS_TYPE: bip322P2SHP2WPKHVector,
+		P2WPKH_ADDRESS_TYPE:      bip322AddressVector,
+		P2TR_ADDRESS_TYPE:        bip322P2TRAddressVector,
+	} {
+		address, err := m.GetBitcoinAddress(privateKey, addressType, &chaincfg.MainNetParams)
+		if err != nil || address != expected {
+			t.Errorf("%s address %s, %v, want %s", addressType, address, err, expected)
+		}
+	}
+}
+
+// Signatures published with BIP322, and the signmessage vector of the Bitcoin
+// Core functional tests, a compressed P2PKH key with header 32.
+var bitcoinMessageVectors = []struct {
+	name      string
+	address   string
+	message   string
+	signature string
+	network   *chaincfg.Params
+}{
+	{"BIP322 P2WPKH empty message", bip322AddressVector, "", "AkcwRAIgM2gBAQqvZX15ZiysmKmQpDrG83avLIT492QBzLnQIxYCIBaTpOaD20qRlEylyxFSeEA2ba9YOixpX8z46TSDtS40ASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHI=", &chaincfg.MainNetParams},
+	{"BIP322 P2WPKH", bip322AddressVector, bip322MessageVector, bip322SignatureVector, &chaincfg.MainNetParams},
+	{"BIP322 P2WPKH other nonce", bip322AddressVector, bip322MessageVector, "AkgwRQIhAOzyynlqt93lOKJr+wmmxIens//zPzl9tqIOua93wO6MAiBi5n5EyAcPScOjf1lAqIUIQtr3zKNeavYabHyR8eGhowEhAsfxIAMZZEKUPYWI4BruhAQjzFT8FSFSajuFwrDL1Yhy", &chaincfg.MainNetParams},
+	{"BIP322 P2TR", bip322P2TRAddressVector, bip322MessageVector, "AUHd69PrJQEv+oKTfZ8l+WROBHuy9HKrbFCJu7U1iK2iiEy1vMU5EfMtjc+VSHM7aU0SDbak5IUZRVno2P5mjSafAQ==", &chaincfg.MainNetParams},
+	{"legacy P2PKH", "mpLQjfK79b7CCV4VMJWEWAj5Mpx8Up5zxB", "This is just a test message", "INbVnW4e6PeRmsv2Qgu8NuopvrVjkcxob+sX8OcZG0SALhWybUjzMLPdAsXI46YZGb0KQTRii+wWIQzRpG/U+S0=", &chaincfg.TestNet3Params},
+}
+
+func TestVerifyBitcoinMessageVectors(t *testing.T) {
+	m := newTestMessageRepository()
+
+	for _, vector := range bitcoinMessageVectors {
+		err := m.VerifyBitcoinMessage(vector.address, []byte(vector.message), vector.signature, vector.network)
+		if err != nil {
+			t.Errorf("%s: %v", vector.name, err)
+		}
+		err = m.VerifyBitcoinMessage(vector.address, []byte(vector.message+"!"), vector.signature, vector.network)
+		if err == nil {
+			t.Errorf("%s: verifies another message", vector.name)
+		}
+
+		decoded, _ := base64.StdEncoding.DecodeString(vector.signature)
+		for _, i := range []int{len(decoded) / 2, len(decoded) - 2} {
+			tampered := append([]byte{}, decoded...)
+			tampered[i] ^= 0x01
+			err = m.VerifyBitcoinMessage(vector.address, []byte(vector.message), base64.StdEncoding.EncodeToString(tampered), vector.network)
+			if err == nil {
+				t.Errorf("%s: verifies with byte %d flipped", vector.name, i)
+			}
+		}
+	}
+}
+
+func TestSignBitcoinMessageBip322Vectors(t *testing.T) {
+	m := newTestMessageRepository()
+	privateKey := bip322Key(t)
+
+	// RFC6979 without the low R grinding of Bitcoin Core gives the second
+	// signature BIP322 publishes for the message.
+	signature, err := m.SignBitcoinMessage(privateKey, P2WPKH_ADDRESS_TYPE, []byte(bip322MessageVector), &chaincfg.MainNetParams)
+	if err != nil || signature != bitcoinMessageVectors[2].signature {
+		t.Errorf("P2WPKH signature %s, %v, want %s", signature, err, bitcoinMessageVectors[2].signature)
+	}
+	// Schnorr signatures use random auxiliary data, so P2TR is only verified.
+	signature, err = m.SignBitcoinMessage(privateKey, P2TR_ADDRESS_TYPE, []byte(bip322MessageVector), &chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = m.VerifyBitcoinMessage(bip322P2TRAddressVector, []byte(bip322MessageVector), signature, &chaincfg.MainNetParams)
+	if err != nil {
+		t.Errorf("P2TR signature %s does not verify: %v", signature, err)
+	}
+}
+
+// compactSignature signs message with the BIP322 key and sets the BIP137
+// header base, 27, 31, 35 or 39.
+func compactSignature(t *testing.T, message string, headerBase byte) string {
+	key, _ := btcec.PrivKeyFromBytes(btcec.S256(), bip322Key(t))
+	signature, err := btcec.SignCompact(btcec.S256(), key, bitcoinMessageHash([]byte(message)), headerBase != compactHeaderUncompressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recoveryID := (signature[0] - compactHeaderUncompressed) % 4
+	signature[0] = headerBase + recoveryID
+	return base64.StdEncoding.EncodeToString(signature)
+}
+
+func TestVerifyBitcoinMessageCompactHeaders(t *testing.T) {
+	m := newTestMessageRepository()
+	privateKey := bip322Key(t)
+	key, _ := btcec.PrivKeyFromBytes(btcec.S256(), privateKey)
+	uncompressedAddress, _ := btcutil.NewAddressPubKeyHash(btcutil.Hash160(key.PubKey().SerializeUncompressed()), &chaincfg.MainNetParams)
+	message := "BIP137 header test"
+
+	tests := []struct {
+		name       string
+		headerBase byte
+		address    string
+		verifies   bool
+	}{
+		{"uncompressed P2PKH", compactHeaderUncompressed, uncompressedAddress.EncodeAddress(), true},
+		{"uncompressed header for the compressed key", compactHeaderUncompressed, bip322P2PKHVector, false},
+		{"compressed P2PKH", compactHeaderCompressed, bip322P2PKHVector, true},
+		{"compressed header for the uncompressed key", compactHeaderCompressed, uncompressedAddress.EncodeAddress(), false},
+		{"P2SH-P2WPKH", compactHeaderP2SHP2WPKH, bip322P2SHP2WPKHVector, true},
+		{"P2WPKH", compactHeaderP2WPKH, bip322AddressVector, true},
+		{"Electrum P2SH-P2WPKH with the compressed header", compactHeaderCompressed, bip322P2SHP2WPKHVector, true},
+		{"Electrum P2WPKH with the compressed header", compactHeaderCompressed, bip322AddressVector, true},
+		{"P2SH-P2WPKH header for P2WPKH", compactHeaderP2SHP2WPKH, bip322AddressVector, false},
+		{"P2WPKH header for P2SH-P2WPKH", compactHeaderP2WPKH, bip322P2SHP2WPKHVector, false},
+		{"P2WPKH header for P2PKH", compactHeaderP2WPKH, bip322P2PKHVector, false},
+		{"P2SH-P2WPKH header for P2PKH", compactHeaderP2SHP2WPKH, bip322P2PKHVector, false},
+		{"compact signature for P2TR", compactHeaderCompressed, bip322P2TRAddressVector, false},
+	}
+	for _, test := range tests {
+		err := m.VerifyBitcoinMessage(test.address, []byte(message), compactSignature(t, message, test.headerBase), &chaincfg.MainNetParams)
+		if (err == nil) != test.verifies {
+			t.Errorf("%s: %v, want verified %v", test.name, err, test.verifies)
+		}
+	}
+}
+
+func TestSignBitcoinMessageCompactHeaders(t *testing.T) {
+	m := newTestMessageRepository()
+	privateKey := bip322Key(t)
+
+	for addressType, headerBase := range map[string]byte{P2PKH_ADDRESS_TYPE: compactHeaderCompressed, P2SH_P2WPKH_ADDRESS_TYPE: compactHeaderP2SHP2WPKH} {
+		signature, err := m.SignBitcoinMessage(privateKey, addressType, []byte(bip322MessageVector), &chaincfg.MainNetParams)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, _ := base64.StdEncoding.DecodeString(signature)
+		if len(decoded) != compactSignatureLength || decoded[0] < headerBase || decoded[0] >= headerBase+4 {
+			t.Errorf("%s signature has header %d, want %d to %d", addressType, decoded[0], headerBase, headerBase+3)
+		}
+		address, _ := m.GetBitcoinAddress(privateKey, addressType, &chaincfg.MainNetParams)
+		err = m.VerifyBitcoinMessage(address, []byte(bip322MessageVector), signature, &chaincfg.MainNetParams)
+		if err != nil {
+			t.Errorf("%s signature does not verify: %v", addressType, err)
+		}
+	}
+}
+
+// EIP-191 hash of "Hello World", as given by ethers.js hashMessage.
+const personalMessageHashVector = "0xa1de988600a42c4b4ab089b619297c17d53cffae5d5120d82d8a92d0bb3b78f2"
+
+func TestEthereumMessageSignatures(t *testing.T) {
+	m := newTestMessageRepository()
+
+	hash, err := ethereumMessageHash([]byte("Hello World"), false)
+	if err != nil || hexutil.Encode(hash) != personalMessageHashVector {
+		t.Errorf("EIP-191 hash %x, %v, want %s", hash, err, personalMessageHashVector)
+	}
+
+	privateKey := ethcrypto.Keccak256([]byte("cow"))
+	tests := []struct {
+		name    string
+		message string
+		typed   bool
+	}{
+		{"EIP-191", "Hello World", false},
+		{"EIP-191 empty message", "", false},
+		{"EIP-712", typedDataVector, true},
+	}
+	for _, test := range tests {
+		signature, err := m.SignEthereumMessage(privateKey, []byte(test.message), test.typed)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if test.typed && signature != typedDataSignatureVector {
+			t.Errorf("%s: signature %s, want %s", test.name, signature, typedDataSignatureVector)
+		}
+		decoded, _ := hexutil.Decode(signature)
+		if decoded[64] != 27 && decoded[64] != 28 {
+			t.Errorf("%s: v is %d, want 27 or 28", test.name, decoded[64])
+		}
+
+		err = m.VerifyEthereumMessage(typedDataAddressVector, []byte(test.message), test.typed, signature)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		}
+		withRecoveryID := append([]byte{}, decoded...)
+		withRecoveryID[64] -= 27
+		err = m.VerifyEthereumMessage(typedDataAddressVector, []byte(test.message), test.typed, hexutil.Encode(withRecoveryID))
+		if err != nil {
+			t.Errorf("%s: v of 0 or 1 is not accepted: %v", test.name, err)
+		}
+		err = m.VerifyEthereumMessage("0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB", []byte(test.message), test.typed, signature)
+		if err == nil {
+			t.Errorf("%s: verifies for another address", test.name)
+		}
+		tampered := append([]byte{}, decoded...)
+		tampered[10] ^= 0x01
+		err = m.VerifyEthereumMessage(typedDataAddressVector, []byte(test.message), test.typed, hexutil.Encode(tampered))
+		if err == nil {
+			t.Errorf("%s: verifies a tampered signature", test.name)
+		}
+	}
+
+	err = m.VerifyEthereumMessage(typedDataAddressVector, []byte("Hello World"), false, "0x"+hex.EncodeToString(make([]byte, 64)))
+	if err == nil {
+		t.Error("a 64 byte signature verifies")
+	}
+}
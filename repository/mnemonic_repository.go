@@ -0,0 +1,214 @@
+package repo
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+	"swisswallet/logger"
+
+	. "swisswallet/constants"
+
+	"github.com/tyler-smith/go-bip39"
+	wordlist "github.com/tyler-smith/go-bip39/wordlists"
+)
+
+type MnemonicRepository interface {
+	NewMnemonic(entropy []byte, language string) (string, error)
+	EntropyFromMnemonic(mnemonic string, language string) ([]byte, error)
+	NewSeedFromMnemonic(mnemonic string, language string) ([]byte, error)
+	GetWordList(language string) ([]string, error)
+	NewPassphrase(wordList string, language string, words int, separator string) (string, float64, error)
+	NewSeedQRPayload(mnemonic string, language string, format string) (string, error)
+	SelfTest() error
+}
+
+type mnemonicRepository struct {
+	logger *logger.Logger
+}
+
+func NewMnemonicRepository(logger *logger.Logger) MnemonicRepository {
+	return &mnemonicRepository{
+		logger: logger,
+	}
+}
+
+var wordListsByLanguage = map[string][]string{
+	ENGLISH_LANGUAGE:             wordlist.English,
+	SPANISH_LANGUAGE:             wordlist.Spanish,
+	CHINESE_TRADITIONAL_LANGUAGE: wordlist.ChineseTraditional,
+	CHINESE_SIMPLIFIED_LANGUAGE:  wordlist.ChineseSimplified,
+	CZECH_LANGUAGE:               wordlist.Czech,
+	FRENCH_LANGUAGE:              wordlist.French,
+	ITALIAN_LANGUAGE:             wordlist.Italian,
+	JAPANESE_LANGUAGE:            wordlist.Japanese,
+	KOREAN_LANGUAGE:              wordlist.Korean,
+}
+
+// Reverse lookup maps are built once at init and only read afterwards, so they
+// can be shared between goroutines deriving wallets in different languages.
+var wordIndexesByLanguage = buildWordIndexes(wordListsByLanguage)
+
+func buildWordIndexes(wordLists map[string][]string) map[string]map[string]int {
+	wordIndexes := make(map[string]map[string]int, len(wordLists))
+	for language, words := range wordLists {
+		indexes := make(map[string]int, len(words))
+		for i, word := range words {
+			indexes[word] = i
+		}
+		wordIndexes[language] = indexes
+	}
+	return wordIndexes
+}
+
+func (m *mnemonicRepository) NewMnemonic(entropy []byte, language string) (string, error) {
+	m.logger.LogOnEntryWithContext(m.logger.GetContext(), logger.SecretBytes(entropy), language)
+
+	words, err := m.GetWordList(language)
+	if err != nil {
+		m.logger.LogOnBadRequestErrorWithContext(m.logger.GetContext(), err)
+		return "", err
+	}
+
+	entropyBitLength := len(entropy) * 8
+	if entropyBitLength%32 != 0 || entropyBitLength < 128 || entropyBitLength > 256 {
+		err = bip39.ErrEntropyLengthInvalid
+		m.logger.LogOnInternalErrorWithContext(m.logger.GetContext(), err)
+		return "", err
+	}
+
+	checksum := sha256.Sum256(entropy)
+	checksummedEntropy := append(append([]byte{}, entropy...), checksum[0])
+	sentenceLength := (entropyBitLength + entropyBitLength/32) / 11
+
+	sentence := make([]string, sentenceLength)
+	for i := range sentence {
+		sentence[i] = words[readElevenBits(checksummedEntropy, i*11)]
+	}
+	mnemonic := strings.Join(sentence, " ")
+
+	m.logger.LogOnExitWithContext(m.logger.GetContext(), logger.Secret(mnemonic), err)
+	return mnemonic, err
+}
+
+func (m *mnemonicRepository) EntropyFromMnemonic(mnemonic string, language string) ([]byte, error) {
+	m.logger.LogOnEntryWithContext(m.logger.GetContext(), logger.Secret(mnemonic), language)
+
+	wordIndexes, ok := wordIndexesByLanguage[language]
+	if !ok {
+		err := errors.New(fmt.Sprintf("Mnemonic language not supported: %s", language))
+		m.logger.LogOnBadRequestErrorWithContext(m.logger.GetContext(), err)
+		return nil, err
+	}
+
+	sentence := strings.Fields(mnemonic)
+	if len(sentence)%3 != 0 || len(sentence) < 12 || len(sentence) > 24 {
+		err := bip39.ErrInvalidMnemonic
+		m.logger.LogOnBadRequestErrorWithContext(m.logger.GetContext(), err)
+		return nil, err
+	}
+
+	checksummedEntropy := make([]byte, (len(sentence)*11+7)/8)
+	for i, word := range sentence {
+		index, found := wordIndexes[word]
+		if !found {
+			err := errors.New(fmt.Sprintf("Word number %d of the mnemonic not found in the %s wordlist", i+1, language))
+			m.logger.LogOnBadRequestErrorWithContext(m.logger.GetContext(), err)
+			return nil, err
+		}
+		writeElevenBits(checksummedEntropy, i*11, index)
+	}
+
+	entropyLength := len(sentence) / 3 * 4
+	entropy := checksummedEntropy[:entropyLength]
+	checksumBitLength := uint(len(sentence) / 3)
+	checksum := sha256.Sum256(entropy)
+	if checksummedEntropy[entropyLength]>>(8-checksumBitLength) != checksum[0]>>(8-checksumBitLength) {
+		err := bip39.ErrChecksumIncorrect
+		m.logger.LogOnBadRequestErrorWithContext(m.logger.GetContext(), err)
+		return nil, err
+	}
+
+	m.logger.LogOnExitWithContext(m.logger.GetContext(), logger.SecretBytes(entropy))
+	return entropy, nil
+}
+
+func (m *mnemonicRepository) NewSeedFromMnemonic(mnemonic string, language string) ([]byte, error) {
+	m.logger.LogOnEntryWithContext(m.logger.GetContext(), logger.Secret(mnemonic), language)
+
+	_, err := m.EntropyFromMnemonic(mnemonic, language)
+	if err != nil {
+		m.logger.LogOnBadRequestErrorWithContext(m.logger.GetContext(), err)
+		return nil, err
+	}
+
+	seed := bip39.NewSeed(mnemonic, "")
+
+	m.logger.LogOnExitWithContext(m.logger.GetContext(), logger.SecretBytes(seed))
+	return seed, nil
+}
+
+func (m *mnemonicRepository) GetWordList(language string) ([]string, error) {
+	words, ok := wordListsByLanguage[language]
+	if !ok {
+		err := errors.New(fmt.Sprintf("Mnemonic language not supported: %s", language))
+		m.logger.LogOnBadRequestErrorWithContext(m.logger.GetContext(), err)
+		return nil, err
+	}
+	return words, nil
+}
+
+// NewSeedQRPayload follows the SeedSigner SeedQR spec: the standard format is
+// the four digit index of every word, the compact one is the raw entropy that
+// has to be encoded in byte mode. Both are only defined for english.
+func (m *mnemonicRepository) NewSeedQRPayload(mnemonic string, language string, format string) (string, error) {
+	m.logger.LogOnEntryWithContext(m.logger.GetContext(), logger.Secret(mnemonic), language, format)
+
+	if language != ENGLISH_LANGUAGE {
+		err := errors.New(fmt.Sprintf("SeedQR is only defined for the english wordlist, not: %s", language))
+		m.logger.LogOnBadRequestErrorWithContext(m.logger.GetContext(), err)
+		return "", err
+	}
+
+	entropy, err := m.EntropyFromMnemonic(mnemonic, language)
+	if err != nil {
+		m.logger.LogOnBadRequestErrorWithContext(m.logger.GetContext(), err)
+		return "", err
+	}
+
+	var payload string
+	switch format {
+	case STANDARD_SEEDQR_FORMAT:
+		var digits strings.Builder
+		wordIndexes := wordIndexesByLanguage[language]
+		for _, word := range strings.Fields(mnemonic) {
+			fmt.Fprintf(&digits, "%04d", wordIndexes[word])
+		}
+		payload = digits.String()
+	case COMPACT_SEEDQR_FORMAT:
+		payload = string(entropy)
+	default:
+		err = errors.New(fmt.Sprintf("SeedQR format not supported: %s", format))
+		m.logger.LogOnBadRequestErrorWithContext(m.logger.GetContext(), err)
+		return "", err
+	}
+
+	m.logger.LogOnExitWithContext(m.logger.GetContext(), logger.Secret(payload), err)
+	return payload, err
+}
+
+func readElevenBits(data []byte, offset int) int {
+	value := 0
+	for i := offset; i < offset+11; i++ {
+		value = value<<1 | int(data[i/8]>>(7-uint(i%8))&1)
+	}
+	return value
+}
+
+func writeElevenBits(data []byte, offset int, value int) {
+	for i := 0; i < 11; i++ {
+		if value&(1<<(10-uint(i))) != 0 {
+			data[(offset+i)/8] |= 1 << (7 - uint((offset+i)%8))
+		}
+	}
+}
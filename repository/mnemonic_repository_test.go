@@ -0,0 +1,64 @@
+package repo
+
+import (
+	"bytes"
+	"encoding/hex"
+	"sync"
+	"testing"
+
+	. "swisswallet/constants"
+	"swisswallet/logger"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Vectors from the BIP39 Japanese test vectors and go-bip39 with the spanish
+// wordlist set globally, the way the repository used to do it. The wordlists
+// are NFKD, so the vectors are normalized before comparing.
+var mnemonicVectors = []struct {
+	entropy  string
+	language string
+	mnemonic string
+}{
+	{"00000000000000000000000000000000", SPANISH_LANGUAGE, "ábaco ábaco ábaco ábaco ábaco ábaco ábaco ábaco ábaco ábaco ábaco abierto"},
+	{"7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f", SPANISH_LANGUAGE, "ligero vista talar yogur venta queso yacer trozo ligero vista talar zafiro"},
+	{"9e885d952ad362caeb4efe34a8e91bd2", SPANISH_LANGUAGE, "obra diadema gorila farmacia colgar gorra pausa talar cocina duda dragón optar"},
+	{"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", SPANISH_LANGUAGE, "zurdo zurdo zurdo zurdo zurdo zurdo zurdo zurdo zurdo zurdo zurdo zurdo zurdo zurdo zurdo zurdo zurdo zurdo zurdo zurdo zurdo zurdo zurdo varón"},
+	{"00000000000000000000000000000000", JAPANESE_LANGUAGE, "あいこくしん あいこくしん あいこくしん あいこくしん あいこくしん あいこくしん あいこくしん あいこくしん あいこくしん あいこくしん あいこくしん あおぞら"},
+	{"7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f", JAPANESE_LANGUAGE, "そつう れきだい ほんやく わかす りくつ ばいか ろせん やちん そつう れきだい ほんやく わかめ"},
+	{"9e885d952ad362caeb4efe34a8e91bd2", JAPANESE_LANGUAGE, "ておくれ げざん しねま こりる きぼう しねん ななおし ほんやく きない けむり けまり てんない"},
+	{"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", JAPANESE_LANGUAGE, "われる われる われる われる われる われる われる われる われる われる われる われる われる われる われる われる われる われる われる われる われる われる われる らいう"},
+}
+
+// TestNewMnemonicConcurrentLanguages derives spanish and japanese mnemonics
+// from many goroutines at once, run it with -race.
+func TestNewMnemonicConcurrentLanguages(t *testing.T) {
+	m := NewMnemonicRepository(logger.NewLogger())
+
+	var wg sync.WaitGroup
+	for round := 0; round < 50; round++ {
+		for _, vector := range mnemonicVectors {
+			wg.Add(1)
+			go func(entropyHex, language, expected string) {
+				defer wg.Done()
+				entropy, _ := hex.DecodeString(entropyHex)
+
+				mnemonic, err := m.NewMnemonic(entropy, language)
+				if err != nil {
+					t.Errorf("NewMnemonic(%s, %s): %v", entropyHex, language, err)
+					return
+				}
+				if mnemonic != expected {
+					t.Errorf("NewMnemonic(%s, %s) = %q, want %q", entropyHex, language, mnemonic, expected)
+					return
+				}
+
+				decoded, err := m.EntropyFromMnemonic(mnemonic, language)
+				if err != nil || !bytes.Equal(decoded, entropy) {
+					t.Errorf("EntropyFromMnemonic(%q, %s) = %x, %v, want %s", mnemonic, language, decoded, err, entropyHex)
+				}
+			}(vector.entropy, vector.language, norm.NFKD.String(vector.mnemonic))
+		}
+	}
+	wg.Wait()
+}
@@ -0,0 +1,290 @@
+package repo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+	"swisswallet/logger"
+	"swisswallet/model"
+
+	. "swisswallet/constants"
+)
+
+type PaperRepository interface {
+	Render(paperWallet model.PaperWallet, format string) ([]byte, error)
+}
+
+type paperRepository struct {
+	logger *logger.Logger
+}
+
+func NewPaperRepository(logger *logger.Logger) PaperRepository {
+	return &paperRepository{
+		logger: logger,
+	}
+}
+
+// A4 in points. Every format is laid out from the same list of elements, with
+// coordinates measured from the top left corner of the page.
+const paperWidth = 595.0
+const paperHeight = 842.0
+const paperMargin = 50.0
+const paperQRSize = 220.0
+const paperTextSize = 10.0
+const paperLineHeight = 14.0
+
+type paperText struct {
+	x, y      float64
+	size      float64
+	bold      bool
+	monospace bool
+	text      string
+}
+
+type paperQR struct {
+	x, y    float64
+	size    float64
+	modules [][]bool
+}
+
+type paperLayout struct {
+	texts []paperText
+	qrs   []paperQR
+}
+
+func (p *paperRepository) Render(paperWallet model.PaperWallet, format string) ([]byte, error) {
+	p.logger.LogOnEntryWithContext(p.logger.GetContext(), paperWallet, format)
+
+	layout := layoutPaperWallet(paperWallet)
+
+	var document []byte
+	switch format {
+	case HTML_PAPER_FORMAT:
+		document = renderPaperHTML(paperWallet, layout)
+	case SVG_PAPER_FORMAT:
+		document = renderPaperSVG(layout)
+	case PDF_PAPER_FORMAT:
+		document = renderPaperPDF(layout)
+	default:
+		err := errors.New(fmt.Sprintf("Paper wallet format not supported: %s", format))
+		p.logger.LogOnBadRequestErrorWithContext(p.logger.GetContext(), err)
+		return nil, err
+	}
+
+	p.logger.LogOnExitWithContext(p.logger.GetContext(), len(document))
+	return document, nil
+}
+
+func layoutPaperWallet(paperWallet model.PaperWallet) paperLayout {
+	var layout paperLayout
+	y := 70.0
+	addText := func(text string, size float64, bold bool, monospace bool) {
+		layout.texts = append(layout.texts, paperText{x: paperMargin, y: y, size: size, bold: bold, monospace: monospace, text: text})
+		y += size * 1.4
+	}
+	addWrapped := func(text string, monospace bool) {
+		for _, line := range wrapPaperText(text, paperTextSize, monospace) {
+			addText(line, paperTextSize, false, monospace)
+		}
+	}
+
+	addText("SwissWallet Paper Wallet", 20, true, false)
+	y += paperLineHeight / 2
+	addText(fmt.Sprintf("Currency: %s", paperWallet.Currency), paperTextSize, false, false)
+	addText(fmt.Sprintf("Difficulty: %s", paperWallet.Difficulty), paperTextSize, false, false)
+	addText(fmt.Sprintf("Language: %s", paperWallet.Language), paperTextSize, false, false)
+	addText(fmt.Sprintf("Derivation Path: %s", paperWallet.DerivationPath), paperTextSize, false, false)
+
+	y += paperLineHeight
+	secretX := paperWidth - paperMargin - paperQRSize
+	layout.texts = append(layout.texts,
+		paperText{x: paperMargin, y: y, size: paperTextSize, bold: true, text: "Address (public)"},
+		paperText{x: secretX, y: y, size: paperTextSize, bold: true, text: fmt.Sprintf("%s (keep secret)", paperWallet.SecretName)},
+	)
+	y += paperLineHeight / 2
+	layout.qrs = append(layout.qrs,
+		paperQR{x: paperMargin, y: y, size: paperQRSize, modules: paperWallet.AddressQR},
+		paperQR{x: secretX, y: y, size: paperQRSize, modules: paperWallet.SecretQR},
+	)
+	y += paperQRSize + 2*paperLineHeight
+
+	addText("Address:", paperTextSize, true, false)
+	addWrapped(paperWallet.Address, true)
+	y += paperLineHeight / 2
+	addText(fmt.Sprintf("%s:", paperWallet.SecretName), paperTextSize, true, false)
+	addWrapped(paperWallet.Secret, true)
+	y += paperLineHeight / 2
+	addText("Regenerate with:", paperTextSize, true, false)
+	addWrapped(paperWallet.RegenerationHint, true)
+	y += paperLineHeight / 2
+	addWrapped("The password and salt are never printed on this wallet. Without them this paper is the only copy of the private key.", false)
+
+	return layout
+}
+
+// wrapPaperText breaks on spaces and only splits inside a word when the word
+// alone does not fit, so hex keys and BIP38 keys wrap at the margin.
+func wrapPaperText(text string, size float64, monospace bool) []string {
+	charWidth := size * 0.5
+	if monospace {
+		charWidth = size * 0.6
+	}
+	maxChars := int((paperWidth - 2*paperMargin) / charWidth)
+
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		for len([]rune(word)) > maxChars {
+			if line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+			lines = append(lines, string([]rune(word)[:maxChars]))
+			word = string([]rune(word)[maxChars:])
+		}
+		if line == "" {
+			line = word
+		} else if len([]rune(line))+1+len([]rune(word)) <= maxChars {
+			line += " " + word
+		} else {
+			lines = append(lines, line)
+			line = word
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func qrModuleSize(qr paperQR) float64 {
+	if len(qr.modules) == 0 {
+		return 0
+	}
+	return qr.size / float64(len(qr.modules))
+}
+
+func qrSVGPath(qr paperQR) string {
+	moduleSize := qrModuleSize(qr)
+	var path strings.Builder
+	for row, modules := range qr.modules {
+		for column, dark := range modules {
+			if dark {
+				fmt.Fprintf(&path, "M%.3f %.3fh%.3fv%.3fh-%.3fz", qr.x+float64(column)*moduleSize, qr.y+float64(row)*moduleSize, moduleSize, moduleSize, moduleSize)
+			}
+		}
+	}
+	return path.String()
+}
+
+func svgFontAttributes(text paperText) string {
+	family := "Helvetica, Arial, sans-serif"
+	if text.monospace {
+		family = "Courier New, Courier, monospace"
+	}
+	weight := "normal"
+	if text.bold {
+		weight = "bold"
+	}
+	return fmt.Sprintf(`font-family="%s" font-size="%.1f" font-weight="%s"`, family, text.size, weight)
+}
+
+func renderPaperSVG(layout paperLayout) []byte {
+	var document bytes.Buffer
+	document.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	fmt.Fprintf(&document, `<svg xmlns="http://www.w3.org/2000/svg" width="210mm" height="297mm" viewBox="0 0 %.0f %.0f">`+"\n", paperWidth, paperHeight)
+	fmt.Fprintf(&document, `<rect width="%.0f" height="%.0f" fill="#fff"/>`+"\n", paperWidth, paperHeight)
+	for _, text := range layout.texts {
+		fmt.Fprintf(&document, `<text x="%.1f" y="%.1f" %s>%s</text>`+"\n", text.x, text.y, svgFontAttributes(text), html.EscapeString(text.text))
+	}
+	for _, qr := range layout.qrs {
+		fmt.Fprintf(&document, `<path d="%s" fill="#000" shape-rendering="crispEdges"/>`+"\n", qrSVGPath(qr))
+	}
+	document.WriteString("</svg>\n")
+	return document.Bytes()
+}
+
+func renderPaperHTML(paperWallet model.PaperWallet, layout paperLayout) []byte {
+	var document bytes.Buffer
+	document.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&document, "<title>SwissWallet Paper Wallet - %s</title>\n", html.EscapeString(paperWallet.Currency))
+	document.WriteString("<style>\n@page { size: A4; margin: 0; }\nbody { margin: 0; background: #fff; }\nsvg { display: block; width: 210mm; height: 297mm; }\n</style>\n")
+	document.WriteString("</head>\n<body>\n")
+	svg := renderPaperSVG(layout)
+	document.Write(svg[bytes.IndexByte(svg, '\n')+1:])
+	document.WriteString("</body>\n</html>\n")
+	return document.Bytes()
+}
+
+// renderPaperPDF writes a single page PDF using the standard Type1 fonts, so it
+// needs no embedded font files. Those fonts only cover Latin text: characters
+// outside ASCII are printed as '?' and must be read from the QR codes instead.
+func renderPaperPDF(layout paperLayout) []byte {
+	var content bytes.Buffer
+	content.WriteString("0 0 0 rg\n")
+	for _, qr := range layout.qrs {
+		moduleSize := qrModuleSize(qr)
+		for row, modules := range qr.modules {
+			for column, dark := range modules {
+				if dark {
+					x := qr.x + float64(column)*moduleSize
+					y := paperHeight - qr.y - float64(row+1)*moduleSize
+					fmt.Fprintf(&content, "%.3f %.3f %.3f %.3f re\n", x, y, moduleSize, moduleSize)
+				}
+			}
+		}
+		content.WriteString("f\n")
+	}
+	for _, text := range layout.texts {
+		font := "F1"
+		if text.monospace {
+			font = "F3"
+		} else if text.bold {
+			font = "F2"
+		}
+		fmt.Fprintf(&content, "BT /%s %.1f Tf %.1f %.1f Td (%s) Tj ET\n", font, text.size, text.x, paperHeight-text.y, escapePDFString(text.text))
+	}
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 5 0 R /F2 6 0 R /F3 7 0 R >> >> /Contents 4 0 R >>", paperWidth, paperHeight),
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+	}
+
+	var document bytes.Buffer
+	document.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = document.Len()
+		fmt.Fprintf(&document, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xrefOffset := document.Len()
+	fmt.Fprintf(&document, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&document, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&document, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xrefOffset)
+	return document.Bytes()
+}
+
+func escapePDFString(text string) string {
+	var escaped strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			escaped.WriteRune('\\')
+			escaped.WriteRune(r)
+		case r < 0x20 || r > 0x7e:
+			escaped.WriteRune('?')
+		default:
+			escaped.WriteRune(r)
+		}
+	}
+	return escaped.String()
+}
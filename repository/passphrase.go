@@ -0,0 +1,54 @@
+package repo
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"swisswallet/logger"
+
+	. "swisswallet/constants"
+)
+
+// NewPassphrase picks words uniformly at random with crypto/rand from an EFF
+// diceware list or the BIP39 list of language, and returns the passphrase with
+// its entropy in bits.
+func (m *mnemonicRepository) NewPassphrase(wordList string, language string, words int, separator string) (string, float64, error) {
+	m.logger.LogOnEntryWithContext(m.logger.GetContext(), wordList, language, words, separator)
+
+	var list []string
+	var err error
+	switch wordList {
+	case EFF_LARGE_WORDLIST:
+		list = effLargeWordList
+	case EFF_SHORT_WORDLIST:
+		list = effShortWordList
+	case BIP39_WORDLIST:
+		list, err = m.GetWordList(language)
+		if err != nil {
+			m.logger.LogOnBadRequestErrorWithContext(m.logger.GetContext(), err)
+			return "", 0, err
+		}
+	default:
+		err = errors.New(fmt.Sprintf("Passphrase wordlist not supported: %s", wordList))
+		m.logger.LogOnBadRequestErrorWithContext(m.logger.GetContext(), err)
+		return "", 0, err
+	}
+
+	picked := make([]string, words)
+	for i := range picked {
+		index, err := rand.Int(rand.Reader, big.NewInt(int64(len(list))))
+		if err != nil {
+			m.logger.LogOnInternalErrorWithContext(m.logger.GetContext(), err)
+			return "", 0, err
+		}
+		picked[i] = list[index.Int64()]
+	}
+	passphrase := strings.Join(picked, separator)
+	entropy := float64(words) * math.Log2(float64(len(list)))
+
+	m.logger.LogOnExitWithContext(m.logger.GetContext(), logger.Secret(passphrase), entropy)
+	return passphrase, entropy, nil
+}
@@ -0,0 +1,91 @@
+package repo
+
+import (
+	"io/ioutil"
+	"math"
+	"strings"
+	"testing"
+
+	. "swisswallet/constants"
+	"swisswallet/logger"
+)
+
+func newTestMnemonicRepository() MnemonicRepository {
+	l := logger.NewLogger()
+	l.SetOutput(ioutil.Discard)
+	return NewMnemonicRepository(l)
+}
+
+func TestNewPassphraseEntropyAndWords(t *testing.T) {
+	m := newTestMnemonicRepository()
+
+	tests := []struct {
+		wordList       string
+		listLength     int
+		entropyPerWord float64
+	}{
+		{EFF_LARGE_WORDLIST, 7776, 12.925},
+		{EFF_SHORT_WORDLIST, 1296, 10.34},
+		{BIP39_WORDLIST, 2048, 11},
+	}
+	for _, test := range tests {
+		var list []string
+		switch test.wordList {
+		case EFF_LARGE_WORDLIST:
+			list = effLargeWordList
+		case EFF_SHORT_WORDLIST:
+			list = effShortWordList
+		default:
+			list = wordListsByLanguage[ENGLISH_LANGUAGE]
+		}
+		if len(list) != test.listLength {
+			t.Errorf("%s has %d words, want %d", test.wordList, len(list), test.listLength)
+		}
+		inList := map[string]bool{}
+		for _, word := range list {
+			inList[word] = true
+		}
+
+		for _, words := range []int{1, 8, PASSPHRASE_MAX_WORDS} {
+			passphrase, entropy, err := m.NewPassphrase(test.wordList, ENGLISH_LANGUAGE, words, " ")
+			if err != nil {
+				t.Fatalf("%s %d words: %v", test.wordList, words, err)
+			}
+			if math.Abs(entropy/float64(words)-test.entropyPerWord) > 0.001 {
+				t.Errorf("%s %d words: %.3f bits per word, want %.3f", test.wordList, words, entropy/float64(words), test.entropyPerWord)
+			}
+			picked := strings.Split(passphrase, " ")
+			if len(picked) != words {
+				t.Errorf("%s: %d words in %q, want %d", test.wordList, len(picked), passphrase, words)
+			}
+			for _, word := range picked {
+				if !inList[word] {
+					t.Errorf("%s: %q is not in the wordlist", test.wordList, word)
+				}
+			}
+		}
+	}
+}
+
+func TestNewPassphraseUsesTheLanguageAndSeparator(t *testing.T) {
+	m := newTestMnemonicRepository()
+
+	passphrase, _, err := m.NewPassphrase(BIP39_WORDLIST, SPANISH_LANGUAGE, 12, "-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, word := range strings.Split(passphrase, "-") {
+		if _, ok := wordIndexesByLanguage[SPANISH_LANGUAGE][word]; !ok {
+			t.Errorf("%q of %q is not a spanish BIP39 word", word, passphrase)
+		}
+	}
+
+	_, _, err = m.NewPassphrase("diceware", ENGLISH_LANGUAGE, 8, " ")
+	if err == nil {
+		t.Error("NewPassphrase accepted an unknown wordlist")
+	}
+	_, _, err = m.NewPassphrase(BIP39_WORDLIST, "klingon", 8, " ")
+	if err == nil {
+		t.Error("NewPassphrase accepted an unknown language")
+	}
+}
@@ -0,0 +1,52 @@
+package repo
+
+import (
+	"math"
+	"swisswallet/logger"
+	"swisswallet/model"
+
+	. "swisswallet/constants"
+
+	"github.com/nbutton23/zxcvbn-go"
+)
+
+// EstimatePasswordStrength runs the offline zxcvbn estimator, with the salt
+// and other user inputs as known words, and turns its entropy into the time an
+// attacker needs to try every guess at difficulty. The attacker is modelled
+// by memory bandwidth: every guess touches all the Argon2 and scrypt memory,
+// so the guess rate falls as the difficulty grows.
+func (c *cryptoRepository) EstimatePasswordStrength(password string, userInputs []string, difficulty string) (*model.PasswordStrength, error) {
+	c.logger.LogOnEntryWithContext(c.logger.GetContext(), logger.Secret(password), len(userInputs), difficulty)
+
+	bytesPerGuess, err := c.GetKdfBytesPerGuess(difficulty)
+	if err != nil {
+		c.logger.LogOnBadRequestErrorWithContext(c.logger.GetContext(), err)
+		return nil, err
+	}
+
+	strength := new(model.PasswordStrength)
+	strength.Entropy = zxcvbn.PasswordStrength(password, userInputs).Entropy
+	strength.Guesses = math.Pow(2, strength.Entropy)
+	strength.GuessesPerSecond = ATTACKER_BYTES_PER_SECOND / bytesPerGuess
+	strength.CrackSeconds = strength.Guesses / strength.GuessesPerSecond
+
+	c.logger.LogOnExitWithContext(c.logger.GetContext(), strength.GuessesPerSecond)
+	return strength, nil
+}
+
+// GetKdfBytesPerGuess returns the memory one guess reads and writes: Argon2id
+// fills its memory once per pass, scrypt writes and reads 128 * r * N bytes.
+func (c *cryptoRepository) GetKdfBytesPerGuess(difficulty string) (float64, error) {
+	time, memory, _, _, err := c.GetArgon2ParamsByDifficulty(difficulty)
+	if err != nil {
+		c.logger.LogOnBadRequestErrorWithContext(c.logger.GetContext(), err)
+		return 0, err
+	}
+	N, r, _, _, err := c.GetScryptParamsByDifficulty(difficulty)
+	if err != nil {
+		c.logger.LogOnBadRequestErrorWithContext(c.logger.GetContext(), err)
+		return 0, err
+	}
+
+	return float64(time)*float64(memory)*1024 + 2*128*float64(r)*float64(N), nil
+}
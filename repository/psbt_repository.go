@@ -0,0 +1,880 @@
+package repo
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"swisswallet/logger"
+	"swisswallet/model"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
+)
+
+type PsbtRepository interface {
+	Summarize(packet []byte, seed []byte, network *chaincfg.Params) (*model.PsbtSummary, error)
+	Sign(packet []byte, seed []byte, network *chaincfg.Params) ([]byte, int, error)
+	SelfTest() error
+}
+
+type psbtRepository struct {
+	logger *logger.Logger
+}
+
+func NewPsbtRepository(logger *logger.Logger) PsbtRepository {
+	return &psbtRepository{
+		logger: logger,
+	}
+}
+
+// Key types of BIP174, BIP370 and BIP371.
+const (
+	psbtMagic = "psbt\xff"
+
+	psbtGlobalUnsignedTx       = 0x00
+	psbtGlobalTxVersion        = 0x02
+	psbtGlobalFallbackLocktime = 0x03
+	psbtGlobalInputCount       = 0x04
+	psbtGlobalOutputCount      = 0x05
+	psbtGlobalVersion          = 0xfb
+
+	psbtInNonWitnessUtxo       = 0x00
+	psbtInWitnessUtxo          = 0x01
+	psbtInPartialSig           = 0x02
+	psbtInSighashType          = 0x03
+	psbtInRedeemScript         = 0x04
+	psbtInWitnessScript        = 0x05
+	psbtInBip32Derivation      = 0x06
+	psbtInFinalScriptSig       = 0x07
+	psbtInFinalScriptWitness   = 0x08
+	psbtInPreviousTxid         = 0x0e
+	psbtInOutputIndex          = 0x0f
+	psbtInSequence             = 0x10
+	psbtInRequiredTimeLocktime = 0x11
+	psbtInRequiredHeightLock   = 0x12
+	psbtInTapKeySig            = 0x13
+	psbtInTapBip32Derivation   = 0x16
+	psbtInTapInternalKey       = 0x17
+	psbtInTapMerkleRoot        = 0x18
+
+	psbtOutRedeemScript       = 0x00
+	psbtOutWitnessScript      = 0x01
+	psbtOutBip32Derivation    = 0x02
+	psbtOutAmount             = 0x03
+	psbtOutScript             = 0x04
+	psbtOutTapInternalKey     = 0x05
+	psbtOutTapBip32Derivation = 0x07
+
+	psbtMaxMapEntries = 1 << 16
+)
+
+// Key lengths, type byte included, of the key types this repository reads.
+// Keys carrying a public key allow its compressed and uncompressed form, or
+// the x-only form for taproot. The BIP370 types are only checked in version 2
+// packets, older ones may use them as unknown keys.
+var (
+	psbtGlobalKeyLengths = map[byte][]int{
+		psbtGlobalUnsignedTx: {1},
+		psbtGlobalVersion:    {1},
+	}
+	psbtV2GlobalKeyLengths = map[byte][]int{
+		psbtGlobalTxVersion:        {1},
+		psbtGlobalFallbackLocktime: {1},
+		psbtGlobalInputCount:       {1},
+		psbtGlobalOutputCount:      {1},
+	}
+	psbtInputKeyLengths = map[byte][]int{
+		psbtInNonWitnessUtxo:     {1},
+		psbtInWitnessUtxo:        {1},
+		psbtInPartialSig:         {34, 66},
+		psbtInSighashType:        {1},
+		psbtInRedeemScript:       {1},
+		psbtInWitnessScript:      {1},
+		psbtInBip32Derivation:    {34, 66},
+		psbtInFinalScriptSig:     {1},
+		psbtInFinalScriptWitness: {1},
+		psbtInTapKeySig:          {1},
+		psbtInTapBip32Derivation: {33},
+		psbtInTapInternalKey:     {1},
+		psbtInTapMerkleRoot:      {1},
+	}
+	psbtV2InputKeyLengths = map[byte][]int{
+		psbtInPreviousTxid:         {1},
+		psbtInOutputIndex:          {1},
+		psbtInSequence:             {1},
+		psbtInRequiredTimeLocktime: {1},
+		psbtInRequiredHeightLock:   {1},
+	}
+	psbtOutputKeyLengths = map[byte][]int{
+		psbtOutRedeemScript:       {1},
+		psbtOutWitnessScript:      {1},
+		psbtOutBip32Derivation:    {34, 66},
+		psbtOutTapInternalKey:     {1},
+		psbtOutTapBip32Derivation: {33},
+	}
+	psbtV2OutputKeyLengths = map[byte][]int{
+		psbtOutAmount: {1},
+		psbtOutScript: {1},
+	}
+)
+
+type psbtEntry struct {
+	key   []byte
+	value []byte
+}
+
+// psbtMap keeps the entries in their original order, so unknown and
+// unsupported fields are written back untouched.
+type psbtMap struct {
+	entries []psbtEntry
+}
+
+func (m *psbtMap) first(keyType byte) ([]byte, bool) {
+	for _, entry := range m.entries {
+		if len(entry.key) == 1 && entry.key[0] == keyType {
+			return entry.value, true
+		}
+	}
+	return nil, false
+}
+
+func (m *psbtMap) withType(keyType byte) []psbtEntry {
+	var entries []psbtEntry
+	for _, entry := range m.entries {
+		if entry.key[0] == keyType {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func (m *psbtMap) set(key []byte, value []byte) {
+	for i, entry := range m.entries {
+		if bytes.Equal(entry.key, key) {
+			m.entries[i].value = value
+			return
+		}
+	}
+	m.entries = append(m.entries, psbtEntry{key: key, value: value})
+}
+
+type psbtPacket struct {
+	version uint32
+	global  psbtMap
+	inputs  []psbtMap
+	outputs []psbtMap
+	tx      *wire.MsgTx
+}
+
+func readPsbtMap(reader io.Reader) (psbtMap, error) {
+	var m psbtMap
+	seen := map[string]bool{}
+	for len(m.entries) < psbtMaxMapEntries {
+		key, err := wire.ReadVarBytes(reader, 0, wire.MaxMessagePayload, "psbt key")
+		if err != nil {
+			return m, err
+		}
+		if len(key) == 0 {
+			return m, nil
+		}
+		value, err := wire.ReadVarBytes(reader, 0, wire.MaxMessagePayload, "psbt value")
+		if err != nil {
+			return m, err
+		}
+		if seen[string(key)] {
+			return m, errors.New(fmt.Sprintf("PSBT has a duplicate key: %x", key))
+		}
+		seen[string(key)] = true
+		m.entries = append(m.entries, psbtEntry{key: key, value: value})
+	}
+	return m, errors.New("PSBT map has too many entries")
+}
+
+func (packet *psbtPacket) checkKeys(m psbtMap, keyLengths map[byte][]int, v2KeyLengths map[byte][]int) error {
+	for _, entry := range m.entries {
+		lengths, ok := keyLengths[entry.key[0]]
+		if !ok && packet.version == 2 {
+			lengths, ok = v2KeyLengths[entry.key[0]]
+		}
+		if !ok {
+			continue
+		}
+		valid := false
+		for _, length := range lengths {
+			valid = valid || len(entry.key) == length
+		}
+		if !valid {
+			return errors.New(fmt.Sprintf("PSBT has a malformed key: %x", entry.key))
+		}
+	}
+	return nil
+}
+
+func writePsbtMap(writer io.Writer, m psbtMap) {
+	for _, entry := range m.entries {
+		wire.WriteVarBytes(writer, 0, entry.key)
+		wire.WriteVarBytes(writer, 0, entry.value)
+	}
+	writer.Write([]byte{0x00})
+}
+
+func readCompactSize(value []byte) (uint64, error) {
+	return wire.ReadVarInt(bytes.NewReader(value), 0)
+}
+
+func parsePsbt(encoded []byte) (*psbtPacket, error) {
+	if !bytes.HasPrefix(encoded, []byte(psbtMagic)) {
+		return nil, errors.New("Not a PSBT: missing magic bytes")
+	}
+	reader := bytes.NewReader(encoded[len(psbtMagic):])
+
+	packet := &psbtPacket{}
+	var err error
+	packet.global, err = readPsbtMap(reader)
+	if err != nil {
+		return nil, err
+	}
+	if version, ok := packet.global.first(psbtGlobalVersion); ok {
+		if len(version) != 4 {
+			return nil, errors.New("PSBT version field is malformed")
+		}
+		packet.version = binary.LittleEndian.Uint32(version)
+	}
+	err = packet.checkKeys(packet.global, psbtGlobalKeyLengths, psbtV2GlobalKeyLengths)
+	if err != nil {
+		return nil, err
+	}
+
+	var inputCount, outputCount uint64
+	switch packet.version {
+	case 0:
+		unsignedTx, ok := packet.global.first(psbtGlobalUnsignedTx)
+		if !ok {
+			return nil, errors.New("PSBT v0 has no unsigned transaction")
+		}
+		packet.tx = wire.NewMsgTx(wire.TxVersion)
+		err = packet.tx.DeserializeNoWitness(bytes.NewReader(unsignedTx))
+		if err != nil {
+			return nil, err
+		}
+		for _, txIn := range packet.tx.TxIn {
+			if len(txIn.SignatureScript) != 0 || len(txIn.Witness) != 0 {
+				return nil, errors.New("PSBT unsigned transaction has signatures")
+			}
+		}
+		inputCount, outputCount = uint64(len(packet.tx.TxIn)), uint64(len(packet.tx.TxOut))
+	case 2:
+		value, ok := packet.global.first(psbtGlobalInputCount)
+		if !ok {
+			return nil, errors.New("PSBT v2 has no input count")
+		}
+		inputCount, err = readCompactSize(value)
+		if err != nil {
+			return nil, err
+		}
+		value, ok = packet.global.first(psbtGlobalOutputCount)
+		if !ok {
+			return nil, errors.New("PSBT v2 has no output count")
+		}
+		outputCount, err = readCompactSize(value)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New(fmt.Sprintf("PSBT version not supported: %d", packet.version))
+	}
+	if inputCount > psbtMaxMapEntries || outputCount > psbtMaxMapEntries {
+		return nil, errors.New("PSBT has too many inputs or outputs")
+	}
+
+	for i := uint64(0); i < inputCount; i++ {
+		input, err := readPsbtMap(reader)
+		if err != nil {
+			return nil, err
+		}
+		err = packet.checkKeys(input, psbtInputKeyLengths, psbtV2InputKeyLengths)
+		if err != nil {
+			return nil, err
+		}
+		packet.inputs = append(packet.inputs, input)
+	}
+	for i := uint64(0); i < outputCount; i++ {
+		output, err := readPsbtMap(reader)
+		if err != nil {
+			return nil, err
+		}
+		err = packet.checkKeys(output, psbtOutputKeyLengths, psbtV2OutputKeyLengths)
+		if err != nil {
+			return nil, err
+		}
+		packet.outputs = append(packet.outputs, output)
+	}
+	if reader.Len() != 0 {
+		return nil, errors.New("PSBT has trailing data")
+	}
+
+	if packet.version == 2 {
+		packet.tx, err = buildPsbtV2Tx(packet)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return packet, nil
+}
+
+// buildPsbtV2Tx assembles the unsigned transaction of a BIP370 PSBT, choosing
+// the locktime from the input requirements as the BIP describes.
+func buildPsbtV2Tx(packet *psbtPacket) (*wire.MsgTx, error) {
+	tx := wire.NewMsgTx(2)
+	if version, ok := packet.global.first(psbtGlobalTxVersion); ok && len(version) == 4 {
+		tx.Version = int32(binary.LittleEndian.Uint32(version))
+	} else {
+		return nil, errors.New("PSBT v2 has no transaction version")
+	}
+
+	fallbackLocktime := uint32(0)
+	if locktime, ok := packet.global.first(psbtGlobalFallbackLocktime); ok && len(locktime) == 4 {
+		fallbackLocktime = binary.LittleEndian.Uint32(locktime)
+	}
+	heightSupported, timeSupported, anyRequired := true, true, false
+	var maxHeight, maxTime uint32
+
+	for _, input := range packet.inputs {
+		txid, ok := input.first(psbtInPreviousTxid)
+		if !ok || len(txid) != chainhash.HashSize {
+			return nil, errors.New("PSBT v2 input has no previous txid")
+		}
+		index, ok := input.first(psbtInOutputIndex)
+		if !ok || len(index) != 4 {
+			return nil, errors.New("PSBT v2 input has no output index")
+		}
+		hash, _ := chainhash.NewHash(txid)
+		txIn := wire.NewTxIn(wire.NewOutPoint(hash, binary.LittleEndian.Uint32(index)), nil, nil)
+		if sequence, ok := input.first(psbtInSequence); ok && len(sequence) == 4 {
+			txIn.Sequence = binary.LittleEndian.Uint32(sequence)
+		}
+		tx.AddTxIn(txIn)
+
+		requiredTime, hasTime := input.first(psbtInRequiredTimeLocktime)
+		requiredHeight, hasHeight := input.first(psbtInRequiredHeightLock)
+		if hasTime || hasHeight {
+			anyRequired = true
+			heightSupported = heightSupported && hasHeight
+			timeSupported = timeSupported && hasTime
+		}
+		if hasTime && len(requiredTime) == 4 && binary.LittleEndian.Uint32(requiredTime) > maxTime {
+			maxTime = binary.LittleEndian.Uint32(requiredTime)
+		}
+		if hasHeight && len(requiredHeight) == 4 && binary.LittleEndian.Uint32(requiredHeight) > maxHeight {
+			maxHeight = binary.LittleEndian.Uint32(requiredHeight)
+		}
+	}
+	switch {
+	case !anyRequired:
+		tx.LockTime = fallbackLocktime
+	case heightSupported:
+		tx.LockTime = maxHeight
+	case timeSupported:
+		tx.LockTime = maxTime
+	default:
+		return nil, errors.New("PSBT v2 inputs require incompatible locktimes")
+	}
+
+	for _, output := range packet.outputs {
+		amount, ok := output.first(psbtOutAmount)
+		if !ok || len(amount) != 8 {
+			return nil, errors.New("PSBT v2 output has no amount")
+		}
+		script, ok := output.first(psbtOutScript)
+		if !ok {
+			return nil, errors.New("PSBT v2 output has no script")
+		}
+		tx.AddTxOut(wire.NewTxOut(int64(binary.LittleEndian.Uint64(amount)), script))
+	}
+	return tx, nil
+}
+
+func serializePsbt(packet *psbtPacket) []byte {
+	var encoded bytes.Buffer
+	encoded.WriteString(psbtMagic)
+	writePsbtMap(&encoded, packet.global)
+	for _, input := range packet.inputs {
+		writePsbtMap(&encoded, input)
+	}
+	for _, output := range packet.outputs {
+		writePsbtMap(&encoded, output)
+	}
+	return encoded.Bytes()
+}
+
+// inputUtxo returns the output spent by input i, from the witness UTXO or
+// from the full previous transaction.
+func (packet *psbtPacket) inputUtxo(i int) (*wire.TxOut, bool, error) {
+	input := packet.inputs[i]
+	if witnessUtxo, ok := input.first(psbtInWitnessUtxo); ok {
+		reader := bytes.NewReader(witnessUtxo)
+		var amount uint64
+		err := binary.Read(reader, binary.LittleEndian, &amount)
+		if err != nil {
+			return nil, false, err
+		}
+		script, err := wire.ReadVarBytes(reader, 0, wire.MaxMessagePayload, "witness utxo script")
+		if err != nil {
+			return nil, false, err
+		}
+		return wire.NewTxOut(int64(amount), script), true, nil
+	}
+	if nonWitnessUtxo, ok := input.first(psbtInNonWitnessUtxo); ok {
+		previousTx := wire.NewMsgTx(wire.TxVersion)
+		err := previousTx.Deserialize(bytes.NewReader(nonWitnessUtxo))
+		if err != nil {
+			return nil, false, err
+		}
+		outpoint := packet.tx.TxIn[i].PreviousOutPoint
+		if previousTx.TxHash() != outpoint.Hash || int(outpoint.Index) >= len(previousTx.TxOut) {
+			return nil, false, errors.New(fmt.Sprintf("PSBT input %d previous transaction does not match its outpoint", i))
+		}
+		return previousTx.TxOut[outpoint.Index], true, nil
+	}
+	return nil, false, nil
+}
+
+func parseKeyOrigin(value []byte) ([]byte, []uint32, error) {
+	if len(value) < 4 || len(value)%4 != 0 {
+		return nil, nil, errors.New("PSBT key origin is malformed")
+	}
+	path := make([]uint32, 0, len(value)/4-1)
+	for i := 4; i < len(value); i += 4 {
+		path = append(path, binary.LittleEndian.Uint32(value[i:]))
+	}
+	return value[:4], path, nil
+}
+
+// parseTapKeyOrigin strips the leaf hashes of a BIP371 derivation and
+// returns how many there were along with the key origin.
+func parseTapKeyOrigin(value []byte) (uint64, []byte, []uint32, error) {
+	reader := bytes.NewReader(value)
+	leafCount, err := wire.ReadVarInt(reader, 0)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	if leafCount > uint64(reader.Len())/32 {
+		return 0, nil, nil, errors.New("PSBT taproot key origin is malformed")
+	}
+	origin := value[len(value)-reader.Len()+int(leafCount)*32:]
+	fingerprint, path, err := parseKeyOrigin(origin)
+	return leafCount, fingerprint, path, err
+}
+
+type psbtSigner struct {
+	master      *hdkeychain.ExtendedKey
+	fingerprint []byte
+}
+
+func newPsbtSigner(seed []byte, network *chaincfg.Params) (*psbtSigner, error) {
+	master, err := hdkeychain.NewMaster(seed, network)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := master.ECPubKey()
+	if err != nil {
+		return nil, err
+	}
+	return &psbtSigner{master: master, fingerprint: btcutil.Hash160(publicKey.SerializeCompressed())[:4]}, nil
+}
+
+// derive returns the private key at path when the origin is this seed.
+func (s *psbtSigner) derive(fingerprint []byte, path []uint32) (*btcec.PrivateKey, bool, error) {
+	if !bytes.Equal(fingerprint, s.fingerprint) {
+		return nil, false, nil
+	}
+	key := s.master
+	var err error
+	for _, index := range path {
+		key, err = key.Derive(index)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+	privateKey, err := key.ECPrivKey()
+	if err != nil {
+		return nil, false, err
+	}
+	return privateKey, true, nil
+}
+
+// psbtInputKey is what is needed to sign one input, nil for inputs that this
+// seed cannot or should not sign.
+type psbtInputKey struct {
+	privateKey *btcec.PrivateKey
+	taproot    bool
+	merkleRoot []byte
+	hashType   byte
+}
+
+func isP2WPKH(script []byte) bool {
+	return len(script) == 22 && script[0] == txscript.OP_0 && script[1] == 0x14
+}
+
+func isP2SH(script []byte) bool {
+	return len(script) == 23 && script[0] == txscript.OP_HASH160 && script[1] == 0x14 && script[22] == txscript.OP_EQUAL
+}
+
+func isP2TR(script []byte) bool {
+	return len(script) == 34 && script[0] == txscript.OP_1 && script[1] == 0x20
+}
+
+func (packet *psbtPacket) inputKey(i int, signer *psbtSigner) (*psbtInputKey, error) {
+	input := packet.inputs[i]
+	if _, ok := input.first(psbtInFinalScriptSig); ok {
+		return nil, nil
+	}
+	if _, ok := input.first(psbtInFinalScriptWitness); ok {
+		return nil, nil
+	}
+	utxo, ok, err := packet.inputUtxo(i)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	hashType := -1
+	if sighashType, ok := input.first(psbtInSighashType); ok {
+		if len(sighashType) != 4 {
+			return nil, errors.New(fmt.Sprintf("PSBT input %d sighash type is malformed", i))
+		}
+		hashType = int(binary.LittleEndian.Uint32(sighashType))
+	}
+
+	witnessProgram := utxo.PkScript
+	if isP2SH(utxo.PkScript) {
+		redeemScript, ok := input.first(psbtInRedeemScript)
+		if !ok || !isP2WPKH(redeemScript) || !bytes.Equal(btcutil.Hash160(redeemScript), utxo.PkScript[2:22]) {
+			return nil, nil
+		}
+		witnessProgram = redeemScript
+	}
+
+	switch {
+	case isP2WPKH(witnessProgram):
+		if hashType != -1 && hashType != int(txscript.SigHashAll) {
+			return nil, errors.New(fmt.Sprintf("PSBT input %d asks for sighash type %d, only SIGHASH_ALL is signed", i, hashType))
+		}
+		for _, entry := range input.withType(psbtInBip32Derivation) {
+			fingerprint, path, err := parseKeyOrigin(entry.value)
+			if err != nil {
+				return nil, err
+			}
+			privateKey, ok, err := signer.derive(fingerprint, path)
+			if err != nil || !ok {
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+			publicKey := privateKey.PubKey().SerializeCompressed()
+			if bytes.Equal(entry.key[1:], publicKey) && bytes.Equal(btcutil.Hash160(publicKey), witnessProgram[2:]) {
+				return &psbtInputKey{privateKey: privateKey, hashType: byte(txscript.SigHashAll)}, nil
+			}
+		}
+	case isP2TR(utxo.PkScript):
+		if hashType == -1 {
+			hashType = taprootSighashDefault
+		}
+		if hashType != taprootSighashDefault && hashType != taprootSighashAll {
+			return nil, errors.New(fmt.Sprintf("PSBT input %d asks for sighash type %d, only SIGHASH_DEFAULT and SIGHASH_ALL are signed", i, hashType))
+		}
+		merkleRoot, _ := input.first(psbtInTapMerkleRoot)
+		internalKey, hasInternalKey := input.first(psbtInTapInternalKey)
+		for _, entry := range input.withType(psbtInTapBip32Derivation) {
+			leafCount, fingerprint, path, err := parseTapKeyOrigin(entry.value)
+			if err != nil {
+				return nil, err
+			}
+			if leafCount != 0 || (hasInternalKey && !bytes.Equal(entry.key[1:], internalKey)) {
+				continue
+			}
+			privateKey, ok, err := signer.derive(fingerprint, path)
+			if err != nil || !ok {
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+			xOnly := privateKey.PubKey().SerializeCompressed()[1:]
+			outputKey, err := taprootOutputKey(xOnly, merkleRoot)
+			if err != nil {
+				return nil, err
+			}
+			if bytes.Equal(entry.key[1:], xOnly) && bytes.Equal(outputKey, utxo.PkScript[2:]) {
+				return &psbtInputKey{privateKey: privateKey, taproot: true, merkleRoot: merkleRoot, hashType: byte(hashType)}, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// isChange reports whether the output pays back to a key of this seed, as
+// described by its derivation fields.
+func (packet *psbtPacket) isChange(i int, signer *psbtSigner) bool {
+	output := packet.outputs[i]
+	script := packet.tx.TxOut[i].PkScript
+	for _, entry := range output.withType(psbtOutBip32Derivation) {
+		fingerprint, path, err := parseKeyOrigin(entry.value)
+		if err != nil {
+			continue
+		}
+		privateKey, ok, err := signer.derive(fingerprint, path)
+		if err != nil || !ok {
+			continue
+		}
+		publicKey := privateKey.PubKey().SerializeCompressed()
+		keyHash := btcutil.Hash160(publicKey)
+		p2wpkh := append([]byte{txscript.OP_0, 0x14}, keyHash...)
+		p2shP2wpkh := append(append([]byte{txscript.OP_HASH160, 0x14}, btcutil.Hash160(p2wpkh)...), txscript.OP_EQUAL)
+		if bytes.Equal(entry.key[1:], publicKey) && (bytes.Equal(script, p2wpkh) || bytes.Equal(script, p2shP2wpkh)) {
+			return true
+		}
+	}
+	for _, entry := range output.withType(psbtOutTapBip32Derivation) {
+		leafCount, fingerprint, path, err := parseTapKeyOrigin(entry.value)
+		if err != nil || leafCount != 0 {
+			continue
+		}
+		privateKey, ok, err := signer.derive(fingerprint, path)
+		if err != nil || !ok {
+			continue
+		}
+		xOnly := privateKey.PubKey().SerializeCompressed()[1:]
+		outputKey, err := taprootOutputKey(xOnly, nil)
+		if err == nil && bytes.Equal(entry.key[1:], xOnly) && isP2TR(script) && bytes.Equal(script[2:], outputKey) {
+			return true
+		}
+	}
+	return false
+}
+
+func scriptAddress(script []byte, network *chaincfg.Params) string {
+	switch {
+	case isP2WPKH(script), len(script) == 34 && script[0] == txscript.OP_0 && script[1] == 0x20:
+		address, err := encodeSegwitAddress(network.Bech32HRPSegwit, 0, script[2:])
+		if err == nil {
+			return address
+		}
+	case isP2TR(script):
+		address, err := encodeSegwitAddress(network.Bech32HRPSegwit, 1, script[2:])
+		if err == nil {
+			return address
+		}
+	case isP2SH(script):
+		address, err := btcutil.NewAddressScriptHashFromHash(script[2:22], network)
+		if err == nil {
+			return address.EncodeAddress()
+		}
+	case len(script) == 25 && script[0] == txscript.OP_DUP && script[1] == txscript.OP_HASH160 && script[2] == 0x14:
+		address, err := btcutil.NewAddressPubKeyHash(script[3:23], network)
+		if err == nil {
+			return address.EncodeAddress()
+		}
+	}
+	return fmt.Sprintf("script %x", script)
+}
+
+func (p *psbtRepository) Summarize(packet []byte, seed []byte, network *chaincfg.Params) (*model.PsbtSummary, error) {
+	p.logger.LogOnEntryWithContext(p.logger.GetContext(), len(packet), logger.SecretBytes(seed), network.Name)
+
+	parsed, err := parsePsbt(packet)
+	if err != nil {
+		p.logger.LogOnBadRequestErrorWithContext(p.logger.GetContext(), err)
+		return nil, err
+	}
+	signer, err := newPsbtSigner(seed, network)
+	if err != nil {
+		p.logger.LogOnInternalErrorWithContext(p.logger.GetContext(), err)
+		return nil, err
+	}
+
+	summary := &model.PsbtSummary{Version: parsed.version, FeeKnown: true}
+	for i, txIn := range parsed.tx.TxIn {
+		input := model.PsbtInput{Outpoint: txIn.PreviousOutPoint.String()}
+		utxo, ok, err := parsed.inputUtxo(i)
+		if err != nil {
+			p.logger.LogOnBadRequestErrorWithContext(p.logger.GetContext(), err)
+			return nil, err
+		}
+		if ok {
+			input.Address = scriptAddress(utxo.PkScript, network)
+			input.Amount = utxo.Value
+			input.AmountKnown = true
+			summary.Fee += utxo.Value
+		} else {
+			summary.FeeKnown = false
+		}
+		inputKey, err := parsed.inputKey(i, signer)
+		if err != nil {
+			p.logger.LogOnBadRequestErrorWithContext(p.logger.GetContext(), err)
+			return nil, err
+		}
+		input.Signable = inputKey != nil
+		summary.Inputs = append(summary.Inputs, input)
+	}
+	for i, txOut := range parsed.tx.TxOut {
+		summary.Outputs = append(summary.Outputs, model.PsbtOutput{
+			Address: scriptAddress(txOut.PkScript, network),
+			Amount:  txOut.Value,
+			Change:  parsed.isChange(i, signer),
+		})
+		summary.Fee -= txOut.Value
+	}
+	if !summary.FeeKnown {
+		summary.Fee = 0
+	}
+
+	p.logger.LogOnExitWithContext(p.logger.GetContext(), summary)
+	return summary, nil
+}
+
+// Sign adds a partial signature to every P2WPKH and P2SH-P2WPKH input, and a
+// key path signature to every P2TR input, whose derivation fields lead back
+// to this seed. Other inputs are left for their own signers.
+func (p *psbtRepository) Sign(packet []byte, seed []byte, network *chaincfg.Params) ([]byte, int, error) {
+	p.logger.LogOnEntryWithContext(p.logger.GetContext(), len(packet), logger.SecretBytes(seed), network.Name)
+
+	parsed, err := parsePsbt(packet)
+	if err != nil {
+		p.logger.LogOnBadRequestErrorWithContext(p.logger.GetContext(), err)
+		return nil, 0, err
+	}
+	signer, err := newPsbtSigner(seed, network)
+	if err != nil {
+		p.logger.LogOnInternalErrorWithContext(p.logger.GetContext(), err)
+		return nil, 0, err
+	}
+
+	sigHashes := txscript.NewTxSigHashes(parsed.tx)
+	signed := 0
+	for i := range parsed.inputs {
+		inputKey, err := parsed.inputKey(i, signer)
+		if err != nil {
+			p.logger.LogOnBadRequestErrorWithContext(p.logger.GetContext(), err)
+			return nil, 0, err
+		}
+		if inputKey == nil {
+			continue
+		}
+		utxo, _, _ := parsed.inputUtxo(i)
+
+		if inputKey.taproot {
+			signature, err := p.signTaprootInput(parsed, i, inputKey)
+			if err != nil {
+				p.logger.LogOnBadRequestErrorWithContext(p.logger.GetContext(), err)
+				return nil, 0, err
+			}
+			parsed.inputs[i].set([]byte{psbtInTapKeySig}, signature)
+		} else {
+			witnessProgram := utxo.PkScript
+			if isP2SH(utxo.PkScript) {
+				witnessProgram, _ = parsed.inputs[i].first(psbtInRedeemScript)
+			}
+			signature, err := txscript.RawTxInWitnessSignature(parsed.tx, sigHashes, i, utxo.Value, witnessProgram, txscript.SigHashType(inputKey.hashType), inputKey.privateKey)
+			if err != nil {
+				p.logger.LogOnInternalErrorWithContext(p.logger.GetContext(), err)
+				return nil, 0, err
+			}
+			parsed.inputs[i].set(append([]byte{psbtInPartialSig}, inputKey.privateKey.PubKey().SerializeCompressed()...), signature)
+		}
+		signed++
+	}
+
+	encoded := serializePsbt(parsed)
+	p.logger.LogOnExitWithContext(p.logger.GetContext(), len(encoded), signed)
+	return encoded, signed, nil
+}
+
+func (p *psbtRepository) signTaprootInput(packet *psbtPacket, i int, inputKey *psbtInputKey) ([]byte, error) {
+	amounts := make([]int64, len(packet.inputs))
+	scripts := make([][]byte, len(packet.inputs))
+	for j := range packet.inputs {
+		utxo, ok, err := packet.inputUtxo(j)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, errors.New(fmt.Sprintf("Taproot signing needs the UTXO of every input, input %d has none", j))
+		}
+		amounts[j] = utxo.Value
+		scripts[j] = utxo.PkScript
+	}
+
+	sighash, err := taprootKeySpendSighash(packet.tx, i, amounts, scripts, inputKey.hashType)
+	if err != nil {
+		return nil, err
+	}
+	tweakedKey, err := taprootTweakPrivateKey(inputKey.privateKey.Serialize(), inputKey.merkleRoot)
+	if err != nil {
+		return nil, err
+	}
+	auxRand := make([]byte, 32)
+	_, err = rand.Read(auxRand)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := schnorrSign(tweakedKey, sighash, auxRand)
+	if err != nil {
+		return nil, err
+	}
+	if inputKey.hashType != taprootSighashDefault {
+		signature = append(signature, inputKey.hashType)
+	}
+	return signature, nil
+}
+
+// Known answers from the BIP340 test vectors.
+var schnorrVectors = []struct {
+	privateKey, publicKey, auxRand, message, signature string
+}{
+	{
+		"0000000000000000000000000000000000000000000000000000000000000003",
+		"f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9",
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"e907831f80848d1069a5371b402410364bdf1c5f8307b0084c55f1ce2dca821525f66a4a85ea8b71e482a74f382d2ce5ebeee8fdb2172f477df4900d310536c0",
+	},
+	{
+		"b7e151628aed2a6abf7158809cf4f3c762e7160f38b4da56a784d9045190cfef",
+		"dff1d77f2a671c5f36183726db2341be58feae1da2deced843240f7b502ba659",
+		"0000000000000000000000000000000000000000000000000000000000000001",
+		"243f6a8885a308d313198a2e03707344a4093822299f31d0082efa98ec4e6c89",
+		"6896bd60eeae296db48a229ff71dfe071bde413e6d43f917dc8dcf8c78de33418906d11ac976abccb20b091292bff4ea897efcb639ea871cfa95f6de339e4b0a",
+	},
+}
+
+func (p *psbtRepository) SelfTest() error {
+	p.logger.LogOnEntryWithContext(p.logger.GetContext())
+
+	for _, vector := range schnorrVectors {
+		privateKey, _ := hex.DecodeString(vector.privateKey)
+		publicKey, _ := hex.DecodeString(vector.publicKey)
+		auxRand, _ := hex.DecodeString(vector.auxRand)
+		message, _ := hex.DecodeString(vector.message)
+		expected, _ := hex.DecodeString(vector.signature)
+
+		signature, err := schnorrSign(privateKey, message, auxRand)
+		if err != nil || !bytes.Equal(signature, expected) || !schnorrVerify(publicKey, message, expected) {
+			err = errors.New("Self-test failed: BIP340 signature does not match its known answer")
+			p.logger.LogOnInternalErrorWithContext(p.logger.GetContext(), err)
+			return err
+		}
+	}
+
+	p.logger.LogOnExitWithContext(p.logger.GetContext())
+	return nil
+}
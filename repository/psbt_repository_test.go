@@ -0,0 +1,225 @@
+package repo
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"swisswallet/logger"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
+)
+
+func newTestPsbtRepository() PsbtRepository {
+	l := logger.NewLogger()
+	l.SetOutput(ioutil.Discard)
+	return NewPsbtRepository(l)
+}
+
+// testdata/psbt_vectors.json holds the BIP174 test vectors, with two duplicate
+// key cases from btcutil/psbt appended to the invalid ones.
+type psbtVectors struct {
+	Valid   []string `json:"valid"`
+	Invalid []struct {
+		Description string `json:"description"`
+		Psbt        string `json:"psbt"`
+	} `json:"invalid"`
+}
+
+func readPsbtVectors(t *testing.T) psbtVectors {
+	data, err := ioutil.ReadFile("testdata/psbt_vectors.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vectors psbtVectors
+	err = json.Unmarshal(data, &vectors)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return vectors
+}
+
+func TestParsePsbtValidVectors(t *testing.T) {
+	for i, vector := range readPsbtVectors(t).Valid {
+		encoded, _ := hex.DecodeString(vector)
+		packet, err := parsePsbt(encoded)
+		if err != nil {
+			t.Errorf("valid vector %d: %v", i, err)
+			continue
+		}
+		if len(packet.inputs) != len(packet.tx.TxIn) || len(packet.outputs) != len(packet.tx.TxOut) {
+			t.Errorf("valid vector %d: %d inputs and %d outputs for a transaction with %d and %d", i, len(packet.inputs), len(packet.outputs), len(packet.tx.TxIn), len(packet.tx.TxOut))
+		}
+		for j := range packet.inputs {
+			_, _, err := packet.inputUtxo(j)
+			if err != nil {
+				t.Errorf("valid vector %d input %d: %v", i, j, err)
+			}
+		}
+		if !bytes.Equal(serializePsbt(packet), encoded) {
+			t.Errorf("valid vector %d does not serialize back to itself", i)
+		}
+	}
+}
+
+func TestParsePsbtInvalidVectors(t *testing.T) {
+	for _, vector := range readPsbtVectors(t).Invalid {
+		encoded, _ := hex.DecodeString(vector.Psbt)
+		_, err := parsePsbt(encoded)
+		if err == nil {
+			t.Errorf("%s: parsed", vector.Description)
+		}
+	}
+}
+
+func psbtEntryOf(keyType byte, keyData []byte, value []byte) psbtEntry {
+	return psbtEntry{key: append([]byte{keyType}, keyData...), value: value}
+}
+
+func psbtKeyOrigin(fingerprint []byte, path ...uint32) []byte {
+	origin := append([]byte{}, fingerprint...)
+	for _, index := range path {
+		origin = append(origin, byte(index), byte(index>>8), byte(index>>16), byte(index>>24))
+	}
+	return origin
+}
+
+func psbtWitnessUtxo(amount int64, script []byte) []byte {
+	var utxo bytes.Buffer
+	wire.WriteTxOut(&utxo, 0, 0, wire.NewTxOut(amount, script))
+	return utxo.Bytes()
+}
+
+// newTestPsbt returns a PSBT spending a P2WPKH and a P2TR output of seed, with
+// a payment to another wallet and a taproot change output.
+func newTestPsbt(t *testing.T, seed []byte) ([]byte, *wire.MsgTx, []int64, [][]byte) {
+	signer, err := newPsbtSigner(seed, &chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	segwitPath := []uint32{hdkeychain.HardenedKeyStart + 84, hdkeychain.HardenedKeyStart, hdkeychain.HardenedKeyStart, 0, 0}
+	taprootPath := []uint32{hdkeychain.HardenedKeyStart + 86, hdkeychain.HardenedKeyStart, hdkeychain.HardenedKeyStart, 0, 0}
+	changePath := []uint32{hdkeychain.HardenedKeyStart + 86, hdkeychain.HardenedKeyStart, hdkeychain.HardenedKeyStart, 1, 0}
+
+	segwitKey, _, _ := signer.derive(signer.fingerprint, segwitPath)
+	segwitPublicKey := segwitKey.PubKey().SerializeCompressed()
+	segwitScript := append([]byte{txscript.OP_0, 0x14}, btcutil.Hash160(segwitPublicKey)...)
+	taprootKey, _, _ := signer.derive(signer.fingerprint, taprootPath)
+	taprootInternalKey := taprootKey.PubKey().SerializeCompressed()[1:]
+	taprootOutput, _ := taprootOutputKey(taprootInternalKey, nil)
+	taprootScript := append([]byte{txscript.OP_1, 0x20}, taprootOutput...)
+	changeKey, _, _ := signer.derive(signer.fingerprint, changePath)
+	changeInternalKey := changeKey.PubKey().SerializeCompressed()[1:]
+	changeOutput, _ := taprootOutputKey(changeInternalKey, nil)
+	changeScript := append([]byte{txscript.OP_1, 0x20}, changeOutput...)
+	paymentScript := append([]byte{txscript.OP_0, 0x14}, bytes.Repeat([]byte{0x42}, 20)...)
+
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Hash: chainhash.Hash{1}, Index: 0}, nil, nil))
+	tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Hash: chainhash.Hash{2}, Index: 1}, nil, nil))
+	tx.AddTxOut(wire.NewTxOut(70000, paymentScript))
+	tx.AddTxOut(wire.NewTxOut(29000, changeScript))
+	amounts := []int64{40000, 60000}
+	scripts := [][]byte{segwitScript, taprootScript}
+
+	var unsignedTx bytes.Buffer
+	tx.SerializeNoWitness(&unsignedTx)
+	packet := &psbtPacket{
+		global: psbtMap{[]psbtEntry{psbtEntryOf(psbtGlobalUnsignedTx, nil, unsignedTx.Bytes())}},
+		inputs: []psbtMap{
+			{[]psbtEntry{
+				psbtEntryOf(psbtInWitnessUtxo, nil, psbtWitnessUtxo(amounts[0], scripts[0])),
+				psbtEntryOf(psbtInBip32Derivation, segwitPublicKey, psbtKeyOrigin(signer.fingerprint, segwitPath...)),
+			}},
+			{[]psbtEntry{
+				psbtEntryOf(psbtInWitnessUtxo, nil, psbtWitnessUtxo(amounts[1], scripts[1])),
+				psbtEntryOf(psbtInTapBip32Derivation, taprootInternalKey, append([]byte{0x00}, psbtKeyOrigin(signer.fingerprint, taprootPath...)...)),
+				psbtEntryOf(psbtInTapInternalKey, nil, taprootInternalKey),
+			}},
+		},
+		outputs: []psbtMap{
+			{},
+			{[]psbtEntry{
+				psbtEntryOf(psbtOutTapInternalKey, nil, changeInternalKey),
+				psbtEntryOf(psbtOutTapBip32Derivation, changeInternalKey, append([]byte{0x00}, psbtKeyOrigin(signer.fingerprint, changePath...)...)),
+			}},
+		},
+	}
+	return serializePsbt(packet), tx, amounts, scripts
+}
+
+func TestPsbtSummarize(t *testing.T) {
+	p := newTestPsbtRepository()
+	seed := bytes.Repeat([]byte{0x07}, 64)
+	packet, _, _, _ := newTestPsbt(t, seed)
+
+	summary, err := p.Summarize(packet, seed, &chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !summary.FeeKnown || summary.Fee != 1000 {
+		t.Errorf("fee %d, known %v, want 1000", summary.Fee, summary.FeeKnown)
+	}
+	if len(summary.Inputs) != 2 || !summary.Inputs[0].Signable || !summary.Inputs[1].Signable {
+		t.Errorf("inputs %+v, want two signable inputs", summary.Inputs)
+	}
+	if len(summary.Outputs) != 2 || summary.Outputs[0].Change || !summary.Outputs[1].Change {
+		t.Errorf("outputs %+v, want the second one as change", summary.Outputs)
+	}
+
+	other, err := p.Summarize(packet, bytes.Repeat([]byte{0x08}, 64), &chaincfg.MainNetParams)
+	if err != nil || other.Inputs[0].Signable || other.Inputs[1].Signable || other.Outputs[1].Change {
+		t.Errorf("another seed recognizes the inputs or the change: %+v, %v", other, err)
+	}
+}
+
+func TestPsbtSign(t *testing.T) {
+	p := newTestPsbtRepository()
+	seed := bytes.Repeat([]byte{0x07}, 64)
+	packet, tx, amounts, scripts := newTestPsbt(t, seed)
+
+	signedPacket, signed, err := p.Sign(packet, seed, &chaincfg.MainNetParams)
+	if err != nil || signed != 2 {
+		t.Fatalf("Sign = %d, %v, want 2 signed inputs", signed, err)
+	}
+	parsed, err := parsePsbt(signedPacket)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	partialSigs := parsed.inputs[0].withType(psbtInPartialSig)
+	if len(partialSigs) != 1 {
+		t.Fatalf("%d partial signatures on the P2WPKH input, want 1", len(partialSigs))
+	}
+	tx.TxIn[0].Witness = wire.TxWitness{partialSigs[0].value, partialSigs[0].key[1:]}
+	engine, err := txscript.NewEngine(scripts[0], tx, 0, txscript.StandardVerifyFlags, nil, txscript.NewTxSigHashes(tx), amounts[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = engine.Execute()
+	if err != nil {
+		t.Errorf("P2WPKH signature does not verify: %v", err)
+	}
+	tx.TxIn[0].Witness = nil
+
+	signature, ok := parsed.inputs[1].first(psbtInTapKeySig)
+	if !ok || len(signature) != 64 {
+		t.Fatalf("taproot key signature %x, want 64 bytes", signature)
+	}
+	sighash, err := taprootKeySpendSighash(tx, 1, amounts, scripts, taprootSighashDefault)
+	if err != nil || !schnorrVerify(scripts[1][2:], sighash, signature) {
+		t.Errorf("taproot key signature does not verify: %v", err)
+	}
+
+	_, signed, err = p.Sign(packet, bytes.Repeat([]byte{0x08}, 64), &chaincfg.MainNetParams)
+	if err != nil || signed != 0 {
+		t.Errorf("another seed signed %d inputs, %v", signed, err)
+	}
+}
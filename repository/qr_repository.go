@@ -0,0 +1,118 @@
+package repo
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"swisswallet/logger"
+
+	. "swisswallet/constants"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+type QrRepository interface {
+	Encode(content string, level string) ([][]bool, error)
+	EncodeVersion(content string, level string, version int) ([][]bool, error)
+	RenderTerminal(modules [][]bool, invert bool) string
+}
+
+type qrRepository struct {
+	logger *logger.Logger
+}
+
+func NewQrRepository(logger *logger.Logger) QrRepository {
+	return &qrRepository{
+		logger: logger,
+	}
+}
+
+var qrRecoveryLevels = map[string]qrcode.RecoveryLevel{
+	QR_LOW_LEVEL:     qrcode.Low,
+	QR_MEDIUM_LEVEL:  qrcode.Medium,
+	QR_HIGH_LEVEL:    qrcode.High,
+	QR_HIGHEST_LEVEL: qrcode.Highest,
+}
+
+// Encode returns the QR modules row by row, true meaning dark, including the
+// four module quiet zone required around the symbol.
+func (q *qrRepository) Encode(content string, level string) ([][]bool, error) {
+	q.logger.LogOnEntryWithContext(q.logger.GetContext(), logger.Secret(content), level)
+
+	bitmap, err := q.encode(content, level, 0)
+	if err != nil {
+		q.logger.LogOnBadRequestErrorWithContext(q.logger.GetContext(), err)
+		return nil, err
+	}
+
+	q.logger.LogOnExitWithContext(q.logger.GetContext(), len(bitmap), err)
+	return bitmap, err
+}
+
+// EncodeVersion is Encode with a fixed symbol version instead of the smallest
+// one the content fits in, for formats such as SeedQR that define the size.
+func (q *qrRepository) EncodeVersion(content string, level string, version int) ([][]bool, error) {
+	q.logger.LogOnEntryWithContext(q.logger.GetContext(), logger.Secret(content), level, version)
+
+	if version < 1 || version > 40 {
+		err := errors.New(fmt.Sprintf("QR version must be between 1 and 40: %d", version))
+		q.logger.LogOnBadRequestErrorWithContext(q.logger.GetContext(), err)
+		return nil, err
+	}
+	bitmap, err := q.encode(content, level, version)
+	if err != nil {
+		q.logger.LogOnBadRequestErrorWithContext(q.logger.GetContext(), err)
+		return nil, err
+	}
+
+	q.logger.LogOnExitWithContext(q.logger.GetContext(), len(bitmap), err)
+	return bitmap, err
+}
+
+// encode picks the smallest version that fits for version 0.
+func (q *qrRepository) encode(content string, level string, version int) ([][]bool, error) {
+	recoveryLevel, ok := qrRecoveryLevels[level]
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("QR error correction level not supported: %s", level))
+	}
+
+	var code *qrcode.QRCode
+	var err error
+	if version == 0 {
+		code, err = qrcode.New(content, recoveryLevel)
+	} else {
+		code, err = qrcode.NewWithForcedVersion(content, version, recoveryLevel)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return code.Bitmap(), nil
+}
+
+// RenderTerminal packs two rows of modules into each line using Unicode half
+// blocks. By default light modules are drawn as blocks, which reads as a
+// regular dark-on-light code on a terminal with a dark background.
+func (q *qrRepository) RenderTerminal(modules [][]bool, invert bool) string {
+	var terminal strings.Builder
+	for row := 0; row < len(modules); row += 2 {
+		for column := range modules[row] {
+			top := modules[row][column] == invert
+			bottom := !invert
+			if row+1 < len(modules) {
+				bottom = modules[row+1][column] == invert
+			}
+			switch {
+			case top && bottom:
+				terminal.WriteString("\u2588")
+			case top:
+				terminal.WriteString("\u2580")
+			case bottom:
+				terminal.WriteString("\u2584")
+			default:
+				terminal.WriteString(" ")
+			}
+		}
+		terminal.WriteString("\n")
+	}
+	return terminal.String()
+}
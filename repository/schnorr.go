@@ -0,0 +1,222 @@
+package repo
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// BIP340 Schnorr signatures and the BIP341 taproot key tweak and signature
+// hash. btcd only gained taproot support after the btcec/v2 split, so they are
+// built here on the secp256k1 arithmetic of btcec.
+
+const taprootSighashDefault = 0x00
+const taprootSighashAll = 0x01
+
+func taggedHash(tag string, data ...[]byte) []byte {
+	tagHash := sha256.Sum256([]byte(tag))
+	hash := sha256.New()
+	hash.Write(tagHash[:])
+	hash.Write(tagHash[:])
+	for _, d := range data {
+		hash.Write(d)
+	}
+	return hash.Sum(nil)
+}
+
+func bytes32(value *big.Int) []byte {
+	encoded := make([]byte, 32)
+	value.FillBytes(encoded)
+	return encoded
+}
+
+// liftX returns the point with the given x coordinate and an even y.
+func liftX(x []byte) (*big.Int, *big.Int, error) {
+	curve := btcec.S256()
+	px := new(big.Int).SetBytes(x)
+	if len(x) != 32 || px.Cmp(curve.P) >= 0 {
+		return nil, nil, errors.New("Invalid x-only public key")
+	}
+	ySquared := new(big.Int).Exp(px, big.NewInt(3), curve.P)
+	ySquared.Add(ySquared, big.NewInt(7))
+	ySquared.Mod(ySquared, curve.P)
+	exponent := new(big.Int).Add(curve.P, big.NewInt(1))
+	exponent.Rsh(exponent, 2)
+	py := new(big.Int).Exp(ySquared, exponent, curve.P)
+	if new(big.Int).Exp(py, big.NewInt(2), curve.P).Cmp(ySquared) != 0 {
+		return nil, nil, errors.New("Invalid x-only public key")
+	}
+	if py.Bit(0) == 1 {
+		py.Sub(curve.P, py)
+	}
+	return px, py, nil
+}
+
+// evenPrivateKey returns the scalar whose public key has an even y, which is
+// the private key behind the x-only public key, and that x-only key.
+func evenPrivateKey(privateKey []byte) (*big.Int, []byte, error) {
+	curve := btcec.S256()
+	d := new(big.Int).SetBytes(privateKey)
+	if d.Sign() == 0 || d.Cmp(curve.N) >= 0 {
+		return nil, nil, errors.New("Invalid private key")
+	}
+	px, py := curve.ScalarBaseMult(bytes32(d))
+	if py.Bit(0) == 1 {
+		d.Sub(curve.N, d)
+	}
+	return d, bytes32(px), nil
+}
+
+// taprootTweak returns the tweak of BIP341 for an internal key and the merkle
+// root of its script tree, empty for a key path only output.
+func taprootTweak(internalKey []byte, merkleRoot []byte) (*big.Int, error) {
+	tweak := new(big.Int).SetBytes(taggedHash("TapTweak", internalKey, merkleRoot))
+	if tweak.Cmp(btcec.S256().N) >= 0 {
+		return nil, errors.New("Taproot tweak is out of range")
+	}
+	return tweak, nil
+}
+
+// taprootOutputKey returns the x-only output key Q = P + tG of BIP341.
+func taprootOutputKey(internalKey []byte, merkleRoot []byte) ([]byte, error) {
+	curve := btcec.S256()
+	px, py, err := liftX(internalKey)
+	if err != nil {
+		return nil, err
+	}
+	tweak, err := taprootTweak(internalKey, merkleRoot)
+	if err != nil {
+		return nil, err
+	}
+	tx, ty := curve.ScalarBaseMult(bytes32(tweak))
+	qx, _ := curve.Add(px, py, tx, ty)
+	return bytes32(qx), nil
+}
+
+// taprootTweakPrivateKey returns the private key of the taproot output key.
+func taprootTweakPrivateKey(privateKey []byte, merkleRoot []byte) ([]byte, error) {
+	d, internalKey, err := evenPrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	tweak, err := taprootTweak(internalKey, merkleRoot)
+	if err != nil {
+		return nil, err
+	}
+	d.Add(d, tweak)
+	d.Mod(d, btcec.S256().N)
+	if d.Sign() == 0 {
+		return nil, errors.New("Tweaked private key is zero")
+	}
+	return bytes32(d), nil
+}
+
+func schnorrSign(privateKey []byte, message []byte, auxRand []byte) ([]byte, error) {
+	curve := btcec.S256()
+	d, publicKey, err := evenPrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	t := taggedHash("BIP0340/aux", auxRand)
+	dBytes := bytes32(d)
+	for i := range t {
+		t[i] ^= dBytes[i]
+	}
+	k := new(big.Int).SetBytes(taggedHash("BIP0340/nonce", t, publicKey, message))
+	k.Mod(k, curve.N)
+	if k.Sign() == 0 {
+		return nil, errors.New("Schnorr nonce is zero")
+	}
+	rx, ry := curve.ScalarBaseMult(bytes32(k))
+	if ry.Bit(0) == 1 {
+		k.Sub(curve.N, k)
+	}
+	r := bytes32(rx)
+
+	e := new(big.Int).SetBytes(taggedHash("BIP0340/challenge", r, publicKey, message))
+	e.Mod(e, curve.N)
+	s := new(big.Int).Mul(e, d)
+	s.Add(s, k)
+	s.Mod(s, curve.N)
+
+	signature := append(r, bytes32(s)...)
+	if !schnorrVerify(publicKey, message, signature) {
+		return nil, errors.New("Schnorr signature does not verify")
+	}
+	return signature, nil
+}
+
+func schnorrVerify(publicKey []byte, message []byte, signature []byte) bool {
+	curve := btcec.S256()
+	if len(signature) != 64 {
+		return false
+	}
+	px, py, err := liftX(publicKey)
+	if err != nil {
+		return false
+	}
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).SetBytes(signature[32:])
+	if r.Cmp(curve.P) >= 0 || s.Cmp(curve.N) >= 0 {
+		return false
+	}
+	e := new(big.Int).SetBytes(taggedHash("BIP0340/challenge", signature[:32], publicKey, message))
+	e.Mod(e, curve.N)
+	e.Sub(curve.N, e)
+
+	sx, sy := curve.ScalarBaseMult(bytes32(s))
+	ex, ey := curve.ScalarMult(px, py, bytes32(e))
+	rx, ry := curve.Add(sx, sy, ex, ey)
+	if rx.Sign() == 0 && ry.Sign() == 0 {
+		return false
+	}
+	return ry.Bit(0) == 0 && rx.Cmp(r) == 0
+}
+
+// taprootKeySpendSighash is the BIP341 signature hash of a key path spend
+// without annex, for SIGHASH_DEFAULT and SIGHASH_ALL only.
+func taprootKeySpendSighash(tx *wire.MsgTx, index int, amounts []int64, scripts [][]byte, hashType byte) ([]byte, error) {
+	if hashType != taprootSighashDefault && hashType != taprootSighashAll {
+		return nil, errors.New("Only SIGHASH_DEFAULT and SIGHASH_ALL are supported for taproot")
+	}
+
+	var prevouts, amountsBuffer, scriptsBuffer, sequences, outputs bytes.Buffer
+	for i, txIn := range tx.TxIn {
+		prevouts.Write(txIn.PreviousOutPoint.Hash[:])
+		writeUint32(&prevouts, txIn.PreviousOutPoint.Index)
+		writeUint64(&amountsBuffer, uint64(amounts[i]))
+		wire.WriteVarBytes(&scriptsBuffer, 0, scripts[i])
+		writeUint32(&sequences, txIn.Sequence)
+	}
+	for _, txOut := range tx.TxOut {
+		wire.WriteTxOut(&outputs, 0, 0, txOut)
+	}
+
+	var message bytes.Buffer
+	message.WriteByte(0x00)
+	message.WriteByte(hashType)
+	writeUint32(&message, uint32(tx.Version))
+	writeUint32(&message, tx.LockTime)
+	for _, buffer := range []*bytes.Buffer{&prevouts, &amountsBuffer, &scriptsBuffer, &sequences, &outputs} {
+		hash := sha256.Sum256(buffer.Bytes())
+		message.Write(hash[:])
+	}
+	message.WriteByte(0x00)
+	writeUint32(&message, uint32(index))
+
+	return taggedHash("TapSighash", message.Bytes()), nil
+}
+
+func writeUint32(buffer *bytes.Buffer, value uint32) {
+	buffer.Write([]byte{byte(value), byte(value >> 8), byte(value >> 16), byte(value >> 24)})
+}
+
+func writeUint64(buffer *bytes.Buffer, value uint64) {
+	writeUint32(buffer, uint32(value))
+	writeUint32(buffer, uint32(value>>32))
+}
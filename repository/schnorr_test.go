@@ -0,0 +1,173 @@
+package repo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+// testdata/bip340_vectors.csv is test-vectors.csv from BIP340. Rows without a
+// secret key only test verification.
+func TestSchnorrBip340Vectors(t *testing.T) {
+	file, err := os.Open("testdata/bip340_vectors.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, row := range rows[1:] {
+		index, comment := row[0], row[7]
+		secretKey, _ := hex.DecodeString(row[1])
+		publicKey, _ := hex.DecodeString(row[2])
+		auxRand, _ := hex.DecodeString(row[3])
+		message, _ := hex.DecodeString(row[4])
+		signature, _ := hex.DecodeString(row[5])
+		expected := row[6] == "TRUE"
+
+		if len(secretKey) != 0 {
+			_, derived, err := evenPrivateKey(secretKey)
+			if err != nil || !bytes.Equal(derived, publicKey) {
+				t.Errorf("vector %s: public key %x, %v, want %x", index, derived, err, publicKey)
+			}
+			signed, err := schnorrSign(secretKey, message, auxRand)
+			if err != nil || !bytes.Equal(signed, signature) {
+				t.Errorf("vector %s: signature %x, %v, want %x", index, signed, err, signature)
+			}
+		}
+		if schnorrVerify(publicKey, message, signature) != expected {
+			t.Errorf("vector %s (%s): verification is %v, want %v", index, comment, !expected, expected)
+		}
+	}
+}
+
+// Key path outputs of the scriptPubKey vectors in wallet-test-vectors.json of
+// BIP341.
+var taprootOutputKeyVectors = []struct {
+	internalKey, merkleRoot, outputKey string
+}{
+	{"d6889cb081036e0faefa3a35157ad71086b123b2b144b649798b494c300a961d", "", "53a1f6e454df1aa2776a2814a721372d6258050de330b3c6d10ee8f4e0dda343"},
+	{"187791b6f712a8ea41c8ecdd0ee77fab3e85263b37e1ec18a3651926b3a6cf27", "5b75adecf53548f3ec6ad7d78383bf84cc57b55a3127c72b9a2481752dd88b21", "147c9c57132f6e7ecddba9800bb0c4449251c92a1e60371ee77557b6620f3ea3"},
+	{"93478e9488f956df2396be2ce6c5cced75f900dfa18e7dabd2428aae78451820", "c525714a7f49c28aedbbba78c005931a81c234b2f6c99a73e4d06082adc8bf2b", "e4d810fd50586274face62b8a807eb9719cef49c04177cc6b76a9a4251d5450e"},
+}
+
+func TestTaprootOutputKeyVectors(t *testing.T) {
+	for _, vector := range taprootOutputKeyVectors {
+		internalKey, _ := hex.DecodeString(vector.internalKey)
+		merkleRoot, _ := hex.DecodeString(vector.merkleRoot)
+
+		outputKey, err := taprootOutputKey(internalKey, merkleRoot)
+		if err != nil || hex.EncodeToString(outputKey) != vector.outputKey {
+			t.Errorf("taprootOutputKey(%s) = %x, %v, want %s", vector.internalKey, outputKey, err, vector.outputKey)
+		}
+	}
+}
+
+func TestTaprootTweakPrivateKeyMatchesOutputKey(t *testing.T) {
+	for _, merkleRoot := range [][]byte{nil, bytes.Repeat([]byte{0x5b}, 32)} {
+		for i := byte(1); i < 8; i++ {
+			privateKey := bytes.Repeat([]byte{i}, 32)
+			_, internalKey, err := evenPrivateKey(privateKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			outputKey, err := taprootOutputKey(internalKey, merkleRoot)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tweaked, err := taprootTweakPrivateKey(privateKey, merkleRoot)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, tweakedKey, err := evenPrivateKey(tweaked)
+			if err != nil || !bytes.Equal(tweakedKey, outputKey) {
+				t.Errorf("tweaked private key of %x gives %x, want %x", privateKey, tweakedKey, outputKey)
+			}
+		}
+	}
+}
+
+// testdata/taproot_keypath_vectors.json holds the key path spends with
+// SIGHASH_DEFAULT or SIGHASH_ALL among the Bitcoin Core script assets, see
+// testdata/BITCOIN_CORE_LICENSE. Prevouts are serialized outputs, the
+// signatures are the witness of the spent input.
+type taprootKeyPathVector struct {
+	Tx       string   `json:"tx"`
+	Prevouts []string `json:"prevouts"`
+	Index    int      `json:"index"`
+	Comment  string   `json:"comment"`
+	Success  string   `json:"success"`
+	Failure  string   `json:"failure"`
+}
+
+func TestTaprootKeySpendSighashVectors(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/taproot_keypath_vectors.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vectors []taprootKeyPathVector
+	err = json.Unmarshal(data, &vectors)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, vector := range vectors {
+		encoded, _ := hex.DecodeString(vector.Tx)
+		tx := wire.NewMsgTx(wire.TxVersion)
+		err := tx.Deserialize(bytes.NewReader(encoded))
+		if err != nil {
+			t.Fatalf("%s: %v", vector.Comment, err)
+		}
+		amounts := make([]int64, len(vector.Prevouts))
+		scripts := make([][]byte, len(vector.Prevouts))
+		for i, prevout := range vector.Prevouts {
+			encoded, _ := hex.DecodeString(prevout)
+			script, err := wire.ReadVarBytes(bytes.NewReader(encoded[8:]), 0, wire.MaxMessagePayload, "script")
+			if err != nil {
+				t.Fatalf("%s: %v", vector.Comment, err)
+			}
+			amounts[i], scripts[i] = int64(binary.LittleEndian.Uint64(encoded[:8])), script
+		}
+		outputKey := scripts[vector.Index][2:]
+
+		verify := func(witness string) bool {
+			signature, _ := hex.DecodeString(witness)
+			hashType := byte(taprootSighashDefault)
+			if len(signature) == 65 {
+				hashType = signature[64]
+			}
+			sighash, err := taprootKeySpendSighash(tx, vector.Index, amounts, scripts, hashType)
+			if err != nil {
+				t.Fatalf("%s: %v", vector.Comment, err)
+			}
+			return schnorrVerify(outputKey, sighash, signature[:64])
+		}
+		if !verify(vector.Success) {
+			t.Errorf("%s: signature of the valid spend does not verify", vector.Comment)
+		}
+		if vector.Failure != "" && verify(vector.Failure) {
+			t.Errorf("%s: signature of the invalid spend verifies", vector.Comment)
+		}
+	}
+}
+
+func TestTaprootKeySpendSighashRejectsOtherHashTypes(t *testing.T) {
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{}, nil, nil))
+	for _, hashType := range []byte{0x02, 0x03, 0x81, 0x83} {
+		_, err := taprootKeySpendSighash(tx, 0, []int64{1}, [][]byte{nil}, hashType)
+		if err == nil {
+			t.Errorf("taprootKeySpendSighash accepted hash type %#x", hashType)
+		}
+	}
+}
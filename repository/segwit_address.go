@@ -0,0 +1,111 @@
+package repo
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcutil/bech32"
+)
+
+// Segwit addresses per BIP173 and BIP350: version 0 programs use the bech32
+// checksum constant and later versions the bech32m one. The bech32 package of
+// btcutil only knows the former.
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+const bech32Constant = 1
+const bech32mConstant = 0x2bc830a3
+
+var bech32Generator = [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+
+func bech32PolymodValues(hrp string, data []byte) uint32 {
+	checksum := uint32(1)
+	step := func(value byte) {
+		top := checksum >> 25
+		checksum = (checksum&0x1ffffff)<<5 ^ uint32(value)
+		for i := uint(0); i < 5; i++ {
+			if top>>i&1 == 1 {
+				checksum ^= bech32Generator[i]
+			}
+		}
+	}
+	for i := 0; i < len(hrp); i++ {
+		step(hrp[i] >> 5)
+	}
+	step(0)
+	for i := 0; i < len(hrp); i++ {
+		step(hrp[i] & 31)
+	}
+	for _, value := range data {
+		step(value)
+	}
+	return checksum
+}
+
+func encodeSegwitAddress(hrp string, version byte, program []byte) (string, error) {
+	converted, err := bech32.ConvertBits(program, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+	data := append([]byte{version}, converted...)
+	constant := uint32(bech32Constant)
+	if version > 0 {
+		constant = bech32mConstant
+	}
+	checksum := bech32PolymodValues(hrp, append(append([]byte{}, data...), 0, 0, 0, 0, 0, 0)) ^ constant
+
+	var address strings.Builder
+	address.WriteString(hrp)
+	address.WriteByte('1')
+	for _, value := range data {
+		address.WriteByte(bech32Charset[value])
+	}
+	for i := 0; i < 6; i++ {
+		address.WriteByte(bech32Charset[(checksum>>(5*uint(5-i)))&31])
+	}
+	return address.String(), nil
+}
+
+// decodeSegwitAddress returns the human readable part, witness version and
+// program, checking the checksum constant that matches the version.
+func decodeSegwitAddress(address string) (string, byte, []byte, error) {
+	if strings.ToLower(address) != address && strings.ToUpper(address) != address {
+		return "", 0, nil, errors.New("Segwit address mixes upper and lower case")
+	}
+	address = strings.ToLower(address)
+	separator := strings.LastIndexByte(address, '1')
+	if separator < 1 || separator+7 > len(address) || len(address) > 90 {
+		return "", 0, nil, errors.New("Segwit address has an invalid length or separator")
+	}
+	hrp := address[:separator]
+	data := make([]byte, 0, len(address)-separator-1)
+	for _, c := range address[separator+1:] {
+		value := strings.IndexRune(bech32Charset, c)
+		if value < 0 {
+			return "", 0, nil, errors.New(fmt.Sprintf("Segwit address has an invalid character: %q", c))
+		}
+		data = append(data, byte(value))
+	}
+	if len(data) < 7 {
+		return "", 0, nil, errors.New("Segwit address has no witness program")
+	}
+
+	version := data[0]
+	constant := uint32(bech32Constant)
+	if version > 0 {
+		constant = bech32mConstant
+	}
+	if bech32PolymodValues(hrp, data) != constant {
+		return "", 0, nil, errors.New("Segwit address checksum does not match")
+	}
+	if version > 16 {
+		return "", 0, nil, errors.New(fmt.Sprintf("Segwit address has an invalid witness version: %d", version))
+	}
+	program, err := bech32.ConvertBits(data[1:len(data)-6], 5, 8, false)
+	if err != nil {
+		return "", 0, nil, err
+	}
+	if len(program) < 2 || len(program) > 40 || (version == 0 && len(program) != 20 && len(program) != 32) {
+		return "", 0, nil, errors.New(fmt.Sprintf("Segwit address has an invalid program length: %d", len(program)))
+	}
+	return hrp, version, program, nil
+}
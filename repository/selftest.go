@@ -0,0 +1,164 @@
+package repo
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	. "swisswallet/constants"
+
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/tyler-smith/go-bip39"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Published known-answer vectors: argon2id from golang.org/x/crypto, scrypt
+// from RFC 7914, AES-256-CBC from NIST SP 800-38A F.2.5, and BIP39 and
+// SLIP-0039 from the Trezor reference vectors.
+const (
+	argon2idVector = "145db9733a9f4ee43edf33c509be96b934d505a4efb33c5a"
+	scryptVector   = "fdbabe1c9d3472007856e7190d01e9fe7c6ad7cbc8237830e77376634b3731622eaf30d92e22a3886ff109279d9830dac727afb94a83ee6d8360cbdfa2cc0640"
+
+	aesKeyVector        = "603deb1015ca71be2b73aef0857d77811f352c073b6108d72d9810a30914dff4"
+	aesIVVector         = "000102030405060708090a0b0c0d0e0f"
+	aesPlaintextVector  = "6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e51"
+	aesCiphertextVector = "f58c4c04d6e5f1ba779eabfb5f7bfbd69cfc4e967edb808d679f777bc6702c7d"
+
+	keccakVector = "c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470"
+
+	bip39EntropyVector  = "7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f"
+	bip39MnemonicVector = "legal winner thank year wave sausage worth useful legal winner thank year wave sausage worth useful legal winner thank year wave sausage worth title"
+	bip39SeedVector     = "bc09fca1804f7e69da93c2f2028eb238c227f2e9dda30cd63699232578480a4021b146ad717fbb7e451ce9eb835f43620bf5c514db0f8add49f5d121449d3e87"
+)
+
+const slip39VectorPassphrase = "TREZOR"
+
+var slip39Vectors = []struct {
+	mnemonics    []string
+	masterSecret string
+}{
+	{
+		mnemonics: []string{
+			"duckling enlarge academic academic agency result length solution fridge kidney coal piece deal husband erode duke ajar critical decision keyboard",
+		},
+		masterSecret: "bb54aac4b89dc868ba37d9cc21b2cece",
+	},
+	{
+		mnemonics: []string{
+			"shadow pistol academic always adequate wildlife fancy gross oasis cylinder mustang wrist rescue view short owner flip making coding armed",
+			"shadow pistol academic acid actress prayer class unknown daughter sweater depict flip twice unkind craft early superior advocate guest smoking",
+		},
+		masterSecret: "b43ceb7e57a0ea8766221624d01b0864",
+	},
+	{
+		mnemonics: []string{
+			"theory painting academic academic armed sweater year military elder discuss acne wildlife boring employer fused large satoshi bundle carbon diagnose anatomy hamster leaves tracks paces beyond phantom capital marvel lips brave detect luck",
+		},
+		masterSecret: "989baf9dcaad5b10ca33dfd8cc75e42477025dce88ae83e75a230086a0e00e92",
+	},
+}
+
+func (c *cryptoRepository) SelfTest() error {
+	c.logger.LogOnEntryWithContext(c.logger.GetContext())
+
+	argon2Key := argon2.IDKey([]byte("password"), []byte("somesalt"), 4, 4096, 4, 24)
+	err := checkKnownAnswer("argon2id", argon2Key, argon2idVector)
+	if err != nil {
+		c.logger.LogOnInternalErrorWithContext(c.logger.GetContext(), err)
+		return err
+	}
+
+	scryptKey, err := scrypt.Key([]byte("password"), []byte("NaCl"), 1024, 8, 16, 64)
+	if err != nil {
+		c.logger.LogOnInternalErrorWithContext(c.logger.GetContext(), err)
+		return err
+	}
+	err = checkKnownAnswer("scrypt", scryptKey, scryptVector)
+	if err != nil {
+		c.logger.LogOnInternalErrorWithContext(c.logger.GetContext(), err)
+		return err
+	}
+
+	key, _ := hex.DecodeString(aesKeyVector)
+	iv, _ := hex.DecodeString(aesIVVector)
+	plaintext, _ := hex.DecodeString(aesPlaintextVector)
+	ciphertext, err := c.AesEncrypt(plaintext, key, iv)
+	if err != nil {
+		c.logger.LogOnInternalErrorWithContext(c.logger.GetContext(), err)
+		return err
+	}
+	err = checkKnownAnswer("AES-256-CBC encryption", ciphertext, aesCiphertextVector)
+	if err != nil {
+		c.logger.LogOnInternalErrorWithContext(c.logger.GetContext(), err)
+		return err
+	}
+
+	decrypted, err := c.AesDecrypt(ciphertext, key, iv)
+	if err != nil {
+		c.logger.LogOnInternalErrorWithContext(c.logger.GetContext(), err)
+		return err
+	}
+	err = checkKnownAnswer("AES-256-CBC decryption", decrypted, aesPlaintextVector)
+	if err != nil {
+		c.logger.LogOnInternalErrorWithContext(c.logger.GetContext(), err)
+		return err
+	}
+
+	err = checkKnownAnswer("Keccak-256", ethcrypto.Keccak256(nil), keccakVector)
+	if err != nil {
+		c.logger.LogOnInternalErrorWithContext(c.logger.GetContext(), err)
+		return err
+	}
+
+	c.logger.LogOnExitWithContext(c.logger.GetContext(), err)
+	return err
+}
+
+func (m *mnemonicRepository) SelfTest() error {
+	m.logger.LogOnEntryWithContext(m.logger.GetContext())
+
+	entropy, _ := hex.DecodeString(bip39EntropyVector)
+	mnemonic, err := m.NewMnemonic(entropy, ENGLISH_LANGUAGE)
+	if err != nil {
+		m.logger.LogOnInternalErrorWithContext(m.logger.GetContext(), err)
+		return err
+	}
+	if mnemonic != bip39MnemonicVector {
+		err = errors.New("Self-test failed: BIP39 mnemonic encoding does not match its known answer")
+		m.logger.LogOnInternalErrorWithContext(m.logger.GetContext(), err)
+		return err
+	}
+
+	decoded, err := m.EntropyFromMnemonic(mnemonic, ENGLISH_LANGUAGE)
+	if err != nil {
+		m.logger.LogOnInternalErrorWithContext(m.logger.GetContext(), err)
+		return err
+	}
+	err = checkKnownAnswer("BIP39 mnemonic decoding", decoded, bip39EntropyVector)
+	if err != nil {
+		m.logger.LogOnInternalErrorWithContext(m.logger.GetContext(), err)
+		return err
+	}
+
+	err = checkKnownAnswer("BIP39 seed", bip39.NewSeed(mnemonic, "TREZOR"), bip39SeedVector)
+	if err != nil {
+		m.logger.LogOnInternalErrorWithContext(m.logger.GetContext(), err)
+		return err
+	}
+
+	m.logger.LogOnExitWithContext(m.logger.GetContext(), err)
+	return err
+}
+
+func checkKnownAnswer(name string, got []byte, expectedHex string) error {
+	expected, err := hex.DecodeString(expectedHex)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(got, expected) {
+		return errors.New(fmt.Sprintf("Self-test failed: %s does not match its known answer", name))
+	}
+	return nil
+}
@@ -0,0 +1,540 @@
+package repo
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"swisswallet/logger"
+	"swisswallet/model"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+type ShamirRepository interface {
+	SplitMnemonics(masterSecret []byte, passphrase string, groupThreshold int, groups []model.Slip39Group, iterationExponent int) ([][]string, error)
+	CombineMnemonics(mnemonics []string, passphrase string) ([]byte, error)
+	SelfTest() error
+}
+
+type shamirRepository struct {
+	logger *logger.Logger
+}
+
+func NewShamirRepository(logger *logger.Logger) ShamirRepository {
+	return &shamirRepository{
+		logger: logger,
+	}
+}
+
+// SLIP-0039 parameters, see https://github.com/satoshilabs/slips/blob/master/slip-0039.md
+const (
+	slip39RadixBits          = 10
+	slip39IdLengthBits       = 15
+	slip39MaxShareCount      = 16
+	slip39ChecksumWords      = 3
+	slip39MinMnemonicWords   = 20
+	slip39MinSecretLength    = 16
+	slip39DigestLength       = 4
+	slip39DigestIndex        = 254
+	slip39SecretIndex        = 255
+	slip39BaseIterationCount = 10000
+	slip39RoundCount         = 4
+	slip39Customization      = "shamir"
+	slip39ExtCustomization   = "shamir_extendable"
+)
+
+var slip39Generator = [10]uint32{0xE0E040, 0x1C1C080, 0x3838100, 0x7070200, 0xE0E0009, 0x1C0C2412, 0x38086C24, 0x3090FC48, 0x21B1F890, 0x3F3F120}
+
+var slip39WordIndexes = buildSlip39WordIndexes()
+
+// GF(256) with the AES polynomial, the field SLIP-0039 interpolates in.
+var gf256Exp, gf256Log = buildGF256Tables()
+
+type slip39Share struct {
+	identifier        int
+	extendable        bool
+	iterationExponent int
+	groupIndex        int
+	groupThreshold    int
+	groupCount        int
+	memberIndex       int
+	memberThreshold   int
+	value             []byte
+}
+
+type slip39Point struct {
+	x     int
+	value []byte
+}
+
+func (s *shamirRepository) SplitMnemonics(masterSecret []byte, passphrase string, groupThreshold int, groups []model.Slip39Group, iterationExponent int) ([][]string, error) {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), logger.SecretBytes(masterSecret), logger.Secret(passphrase), groupThreshold, groups, iterationExponent)
+
+	err := checkSlip39SplitParameters(masterSecret, passphrase, groupThreshold, groups, iterationExponent)
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return nil, err
+	}
+
+	identifierBytes := make([]byte, 2)
+	_, err = rand.Read(identifierBytes)
+	if err != nil {
+		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+		return nil, err
+	}
+	identifier := (int(identifierBytes[0])<<8 | int(identifierBytes[1])) & (1<<slip39IdLengthBits - 1)
+
+	// New shares are not extendable, the only variant every Trezor firmware
+	// release can recover.
+	encryptedSecret := slip39Feistel(masterSecret, passphrase, iterationExponent, identifier, false, true)
+
+	groupPoints, err := slip39SplitSecret(groupThreshold, len(groups), encryptedSecret)
+	if err != nil {
+		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+		return nil, err
+	}
+
+	mnemonics := make([][]string, len(groups))
+	for groupIndex, group := range groups {
+		memberPoints, err := slip39SplitSecret(group.MemberThreshold, group.MemberCount, groupPoints[groupIndex].value)
+		if err != nil {
+			s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+			return nil, err
+		}
+		for _, memberPoint := range memberPoints {
+			share := slip39Share{
+				identifier:        identifier,
+				iterationExponent: iterationExponent,
+				groupIndex:        groupIndex,
+				groupThreshold:    groupThreshold,
+				groupCount:        len(groups),
+				memberIndex:       memberPoint.x,
+				memberThreshold:   group.MemberThreshold,
+				value:             memberPoint.value,
+			}
+			mnemonics[groupIndex] = append(mnemonics[groupIndex], encodeSlip39Share(share))
+		}
+	}
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), len(mnemonics), err)
+	return mnemonics, nil
+}
+
+func (s *shamirRepository) CombineMnemonics(mnemonics []string, passphrase string) ([]byte, error) {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), len(mnemonics), logger.Secret(passphrase))
+
+	if len(mnemonics) == 0 {
+		err := errors.New("No SLIP-0039 mnemonics provided")
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return nil, err
+	}
+
+	err := checkSlip39Passphrase(passphrase)
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return nil, err
+	}
+
+	shares := make([]slip39Share, len(mnemonics))
+	for i, mnemonic := range mnemonics {
+		shares[i], err = decodeSlip39Share(mnemonic)
+		if err != nil {
+			err = errors.New(fmt.Sprintf("SLIP-0039 mnemonic number %d: %s", i+1, err))
+			s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+			return nil, err
+		}
+	}
+
+	encryptedSecret, err := recoverSlip39EncryptedSecret(shares)
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return nil, err
+	}
+
+	first := shares[0]
+	masterSecret := slip39Feistel(encryptedSecret, passphrase, first.iterationExponent, first.identifier, first.extendable, false)
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), logger.SecretBytes(masterSecret))
+	return masterSecret, nil
+}
+
+func checkSlip39SplitParameters(masterSecret []byte, passphrase string, groupThreshold int, groups []model.Slip39Group, iterationExponent int) error {
+	if len(masterSecret) < slip39MinSecretLength || len(masterSecret)%2 != 0 {
+		return errors.New(fmt.Sprintf("SLIP-0039 master secret must be an even number of bytes, at least %d", slip39MinSecretLength))
+	}
+	if iterationExponent < 0 || iterationExponent > 15 {
+		return errors.New("SLIP-0039 iteration exponent must be between 0 and 15")
+	}
+	if len(groups) == 0 || len(groups) > slip39MaxShareCount {
+		return errors.New(fmt.Sprintf("SLIP-0039 needs between 1 and %d groups", slip39MaxShareCount))
+	}
+	if groupThreshold < 1 || groupThreshold > len(groups) {
+		return errors.New("SLIP-0039 group threshold must be between 1 and the number of groups")
+	}
+	for i, group := range groups {
+		if group.MemberCount < 1 || group.MemberCount > slip39MaxShareCount || group.MemberThreshold < 1 || group.MemberThreshold > group.MemberCount {
+			return errors.New(fmt.Sprintf("SLIP-0039 group %d must have 1 to %d members and a threshold not above them", i+1, slip39MaxShareCount))
+		}
+		if group.MemberThreshold == 1 && group.MemberCount > 1 {
+			return errors.New(fmt.Sprintf("SLIP-0039 group %d: use 1of1 instead of several members with threshold 1", i+1))
+		}
+	}
+	return checkSlip39Passphrase(passphrase)
+}
+
+func checkSlip39Passphrase(passphrase string) error {
+	for _, r := range passphrase {
+		if r < 32 || r > 126 {
+			return errors.New("SLIP-0039 passphrase must only contain printable ASCII characters")
+		}
+	}
+	return nil
+}
+
+func recoverSlip39EncryptedSecret(shares []slip39Share) ([]byte, error) {
+	first := shares[0]
+	membersByGroup := map[int][]slip39Point{}
+	memberThresholds := map[int]int{}
+	for _, share := range shares {
+		if share.identifier != first.identifier || share.extendable != first.extendable || share.iterationExponent != first.iterationExponent {
+			return nil, errors.New("SLIP-0039 mnemonics do not belong to the same secret")
+		}
+		if share.groupThreshold != first.groupThreshold || share.groupCount != first.groupCount || len(share.value) != len(first.value) {
+			return nil, errors.New("SLIP-0039 mnemonics have inconsistent group parameters")
+		}
+		if threshold, ok := memberThresholds[share.groupIndex]; ok && threshold != share.memberThreshold {
+			return nil, errors.New(fmt.Sprintf("SLIP-0039 mnemonics of group %d have inconsistent member thresholds", share.groupIndex+1))
+		}
+		memberThresholds[share.groupIndex] = share.memberThreshold
+
+		duplicate := false
+		for _, member := range membersByGroup[share.groupIndex] {
+			if member.x == share.memberIndex {
+				if !bytes.Equal(member.value, share.value) {
+					return nil, errors.New(fmt.Sprintf("SLIP-0039 group %d has two different mnemonics with the same member index", share.groupIndex+1))
+				}
+				duplicate = true
+			}
+		}
+		if !duplicate {
+			membersByGroup[share.groupIndex] = append(membersByGroup[share.groupIndex], slip39Point{x: share.memberIndex, value: share.value})
+		}
+	}
+
+	var groupIndexes []int
+	for groupIndex, members := range membersByGroup {
+		if len(members) >= memberThresholds[groupIndex] {
+			groupIndexes = append(groupIndexes, groupIndex)
+		}
+	}
+	if len(groupIndexes) < first.groupThreshold {
+		return nil, errors.New(fmt.Sprintf("Insufficient SLIP-0039 mnemonics: %d complete groups of the %d required", len(groupIndexes), first.groupThreshold))
+	}
+	sort.Ints(groupIndexes)
+
+	groupPoints := make([]slip39Point, first.groupThreshold)
+	for i, groupIndex := range groupIndexes[:first.groupThreshold] {
+		threshold := memberThresholds[groupIndex]
+		groupSecret, err := slip39RecoverSecret(threshold, membersByGroup[groupIndex][:threshold])
+		if err != nil {
+			return nil, err
+		}
+		groupPoints[i] = slip39Point{x: groupIndex, value: groupSecret}
+	}
+
+	return slip39RecoverSecret(first.groupThreshold, groupPoints)
+}
+
+func slip39SplitSecret(threshold int, shareCount int, secret []byte) ([]slip39Point, error) {
+	if threshold == 1 {
+		points := make([]slip39Point, shareCount)
+		for i := range points {
+			points[i] = slip39Point{x: i, value: secret}
+		}
+		return points, nil
+	}
+
+	points := make([]slip39Point, 0, shareCount)
+	for i := 0; i < threshold-2; i++ {
+		value := make([]byte, len(secret))
+		_, err := rand.Read(value)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, slip39Point{x: i, value: value})
+	}
+
+	randomPart := make([]byte, len(secret)-slip39DigestLength)
+	_, err := rand.Read(randomPart)
+	if err != nil {
+		return nil, err
+	}
+	digest := slip39Digest(randomPart, secret)
+	basePoints := append(append([]slip39Point{}, points...),
+		slip39Point{x: slip39DigestIndex, value: append(digest, randomPart...)},
+		slip39Point{x: slip39SecretIndex, value: secret},
+	)
+
+	for i := threshold - 2; i < shareCount; i++ {
+		points = append(points, slip39Point{x: i, value: gf256Interpolate(basePoints, i)})
+	}
+	return points, nil
+}
+
+func slip39RecoverSecret(threshold int, points []slip39Point) ([]byte, error) {
+	if threshold == 1 {
+		return points[0].value, nil
+	}
+
+	secret := gf256Interpolate(points, slip39SecretIndex)
+	digestPoint := gf256Interpolate(points, slip39DigestIndex)
+	if !hmac.Equal(digestPoint[:slip39DigestLength], slip39Digest(digestPoint[slip39DigestLength:], secret)) {
+		return nil, errors.New("Invalid SLIP-0039 digest: the mnemonics do not combine into a valid secret")
+	}
+	return secret, nil
+}
+
+func slip39Digest(randomPart []byte, secret []byte) []byte {
+	mac := hmac.New(sha256.New, randomPart)
+	mac.Write(secret)
+	return mac.Sum(nil)[:slip39DigestLength]
+}
+
+// slip39Feistel runs the four round Feistel network that turns the master
+// secret into the encrypted secret that is actually split, and back.
+func slip39Feistel(input []byte, passphrase string, iterationExponent int, identifier int, extendable bool, encrypt bool) []byte {
+	half := len(input) / 2
+	left := append([]byte{}, input[:half]...)
+	right := append([]byte{}, input[half:]...)
+
+	var saltPrefix []byte
+	if !extendable {
+		saltPrefix = append([]byte(slip39Customization), byte(identifier>>8), byte(identifier))
+	}
+	iterations := (slip39BaseIterationCount << uint(iterationExponent)) / slip39RoundCount
+
+	for i := 0; i < slip39RoundCount; i++ {
+		round := i
+		if !encrypt {
+			round = slip39RoundCount - 1 - i
+		}
+		password := append([]byte{byte(round)}, passphrase...)
+		salt := append(append([]byte{}, saltPrefix...), right...)
+		roundKey := pbkdf2.Key(password, salt, iterations, len(right), sha256.New)
+		for j := range left {
+			left[j] ^= roundKey[j]
+		}
+		left, right = right, left
+	}
+	return append(right, left...)
+}
+
+func encodeSlip39Share(share slip39Share) string {
+	extendable := 0
+	if share.extendable {
+		extendable = 1
+	}
+	idExponent := share.identifier<<5 | extendable<<4 | share.iterationExponent
+	groupParameters := share.groupIndex<<16 | (share.groupThreshold-1)<<12 | (share.groupCount-1)<<8 | share.memberIndex<<4 | (share.memberThreshold - 1)
+
+	valueWordCount := (len(share.value)*8 + slip39RadixBits - 1) / slip39RadixBits
+	data := []int{idExponent >> slip39RadixBits, idExponent & 0x3ff, groupParameters >> slip39RadixBits, groupParameters & 0x3ff}
+	data = append(data, bytesToSlip39Words(share.value, valueWordCount)...)
+	data = append(data, slip39Checksum(data, share.extendable)...)
+
+	words := make([]string, len(data))
+	for i, index := range data {
+		words[i] = slip39WordList[index]
+	}
+	return strings.Join(words, " ")
+}
+
+func decodeSlip39Share(mnemonic string) (slip39Share, error) {
+	var share slip39Share
+	words := strings.Fields(strings.ToLower(mnemonic))
+	if len(words) < slip39MinMnemonicWords {
+		return share, errors.New(fmt.Sprintf("a SLIP-0039 mnemonic has at least %d words", slip39MinMnemonicWords))
+	}
+
+	data := make([]int, len(words))
+	for i, word := range words {
+		index, ok := slip39WordIndexes[word]
+		if !ok {
+			return share, errors.New(fmt.Sprintf("word number %d not found in the SLIP-0039 wordlist", i+1))
+		}
+		data[i] = index
+	}
+
+	idExponent := data[0]<<slip39RadixBits | data[1]
+	share.identifier = idExponent >> 5
+	share.extendable = idExponent>>4&1 == 1
+	share.iterationExponent = idExponent & 0xf
+
+	if slip39Polymod(slip39ChecksumPrefix(share.extendable), data) != 1 {
+		return share, errors.New("invalid SLIP-0039 checksum")
+	}
+
+	valueWords := data[4 : len(data)-slip39ChecksumWords]
+	paddingLength := slip39RadixBits * len(valueWords) % 16
+	if paddingLength > 8 {
+		return share, errors.New("invalid SLIP-0039 mnemonic length")
+	}
+	value, err := slip39WordsToBytes(valueWords, (slip39RadixBits*len(valueWords)-paddingLength)/8)
+	if err != nil {
+		return share, err
+	}
+	share.value = value
+
+	groupParameters := data[2]<<slip39RadixBits | data[3]
+	share.groupIndex = groupParameters >> 16
+	share.groupThreshold = groupParameters>>12&0xf + 1
+	share.groupCount = groupParameters>>8&0xf + 1
+	share.memberIndex = groupParameters >> 4 & 0xf
+	share.memberThreshold = groupParameters&0xf + 1
+	if share.groupThreshold > share.groupCount {
+		return share, errors.New("SLIP-0039 group threshold exceeds the number of groups")
+	}
+	return share, nil
+}
+
+func bytesToSlip39Words(value []byte, wordCount int) []int {
+	number := new(big.Int).SetBytes(value)
+	words := make([]int, wordCount)
+	mask := big.NewInt(0x3ff)
+	for i := wordCount - 1; i >= 0; i-- {
+		words[i] = int(new(big.Int).And(number, mask).Int64())
+		number.Rsh(number, slip39RadixBits)
+	}
+	return words
+}
+
+func slip39WordsToBytes(words []int, length int) ([]byte, error) {
+	number := new(big.Int)
+	for _, word := range words {
+		number.Lsh(number, slip39RadixBits)
+		number.Or(number, big.NewInt(int64(word)))
+	}
+	if number.BitLen() > length*8 {
+		return nil, errors.New("invalid SLIP-0039 padding")
+	}
+	value := make([]byte, length)
+	number.FillBytes(value)
+	return value, nil
+}
+
+func slip39ChecksumPrefix(extendable bool) []int {
+	customization := slip39Customization
+	if extendable {
+		customization = slip39ExtCustomization
+	}
+	prefix := make([]int, len(customization))
+	for i, c := range []byte(customization) {
+		prefix[i] = int(c)
+	}
+	return prefix
+}
+
+func slip39Checksum(data []int, extendable bool) []int {
+	values := append(append([]int{}, data...), make([]int, slip39ChecksumWords)...)
+	polymod := slip39Polymod(slip39ChecksumPrefix(extendable), values) ^ 1
+	checksum := make([]int, slip39ChecksumWords)
+	for i := range checksum {
+		checksum[i] = int(polymod>>(slip39RadixBits*uint(slip39ChecksumWords-1-i))) & 0x3ff
+	}
+	return checksum
+}
+
+// slip39Polymod is the RS1024 checksum over the customization string followed
+// by the word indexes.
+func slip39Polymod(prefix []int, data []int) uint32 {
+	checksum := uint32(1)
+	for _, value := range append(append([]int{}, prefix...), data...) {
+		top := checksum >> 20
+		checksum = (checksum&0xfffff)<<slip39RadixBits ^ uint32(value)
+		for i := uint(0); i < 10; i++ {
+			if top>>i&1 == 1 {
+				checksum ^= slip39Generator[i]
+			}
+		}
+	}
+	return checksum
+}
+
+func gf256Interpolate(points []slip39Point, x int) []byte {
+	for _, point := range points {
+		if point.x == x {
+			return append([]byte{}, point.value...)
+		}
+	}
+
+	logProduct := 0
+	for _, point := range points {
+		logProduct += gf256Log[point.x^x]
+	}
+
+	result := make([]byte, len(points[0].value))
+	for _, point := range points {
+		logBasis := logProduct - gf256Log[point.x^x]
+		for _, other := range points {
+			if other.x != point.x {
+				logBasis -= gf256Log[point.x^other.x]
+			}
+		}
+		logBasis = (logBasis%255 + 255) % 255
+		for i, value := range point.value {
+			if value != 0 {
+				result[i] ^= gf256Exp[(gf256Log[value]+logBasis)%255]
+			}
+		}
+	}
+	return result
+}
+
+func buildGF256Tables() ([255]byte, [256]int) {
+	var exp [255]byte
+	var log [256]int
+	polynomial := 1
+	for i := 0; i < 255; i++ {
+		exp[i] = byte(polynomial)
+		log[polynomial] = i
+		polynomial = polynomial<<1 ^ polynomial
+		if polynomial&0x100 != 0 {
+			polynomial ^= 0x11b
+		}
+	}
+	return exp, log
+}
+
+func buildSlip39WordIndexes() map[string]int {
+	indexes := make(map[string]int, len(slip39WordList))
+	for i, word := range slip39WordList {
+		indexes[word] = i
+	}
+	return indexes
+}
+
+func (s *shamirRepository) SelfTest() error {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext())
+
+	for _, vector := range slip39Vectors {
+		masterSecret, err := s.CombineMnemonics(vector.mnemonics, slip39VectorPassphrase)
+		if err != nil {
+			s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+			return err
+		}
+		err = checkKnownAnswer("SLIP-0039 combine", masterSecret, vector.masterSecret)
+		if err != nil {
+			s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+			return err
+		}
+	}
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext())
+	return nil
+}
@@ -0,0 +1,105 @@
+package repo
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"swisswallet/logger"
+	"swisswallet/model"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil/hdkeychain"
+)
+
+const slip39TestPassphrase = "TREZOR"
+
+func newTestShamirRepository() ShamirRepository {
+	l := logger.NewLogger()
+	l.SetOutput(ioutil.Discard)
+	return NewShamirRepository(l)
+}
+
+// testdata/slip39_vectors.json uses the layout of vectors.json from
+// python-shamir-mnemonic, [description, mnemonics, master secret, xprv], with
+// an empty master secret for mnemonics that must be rejected. The official
+// file can be dropped in as is.
+func TestSlip39Vectors(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/slip39_vectors.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vectors [][]interface{}
+	err = json.Unmarshal(data, &vectors)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := newTestShamirRepository()
+	for _, vector := range vectors {
+		description := vector[0].(string)
+		var mnemonics []string
+		for _, mnemonic := range vector[1].([]interface{}) {
+			mnemonics = append(mnemonics, mnemonic.(string))
+		}
+		expected := vector[2].(string)
+		xprv := vector[3].(string)
+
+		masterSecret, err := s.CombineMnemonics(mnemonics, slip39TestPassphrase)
+		if expected == "" {
+			if err == nil {
+				t.Errorf("%s: combined into %x, want an error", description, masterSecret)
+			}
+			continue
+		}
+		if err != nil || hex.EncodeToString(masterSecret) != expected {
+			t.Errorf("%s: combined into %x, %v, want %s", description, masterSecret, err, expected)
+			continue
+		}
+
+		if xprv != "" {
+			key, err := hdkeychain.NewMaster(masterSecret, &chaincfg.MainNetParams)
+			if err != nil || key.String() != xprv {
+				t.Errorf("%s: master key %v, %v, want %s", description, key, err, xprv)
+			}
+		}
+	}
+}
+
+func TestSlip39SplitCombine(t *testing.T) {
+	s := newTestShamirRepository()
+	masterSecret, _ := hex.DecodeString("989baf9dcaad5b10ca33dfd8cc75e42477025dce88ae83e75a230086a0e00e92")
+	groups := []model.Slip39Group{{MemberThreshold: 2, MemberCount: 3}, {MemberThreshold: 3, MemberCount: 5}, {MemberThreshold: 1, MemberCount: 1}}
+
+	mnemonics, err := s.SplitMnemonics(masterSecret, slip39TestPassphrase, 2, groups, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mnemonics) != len(groups) {
+		t.Fatalf("split into %d groups, want %d", len(mnemonics), len(groups))
+	}
+
+	combinations := [][]string{
+		{mnemonics[0][0], mnemonics[0][2], mnemonics[2][0]},
+		{mnemonics[1][4], mnemonics[1][1], mnemonics[1][3], mnemonics[0][1], mnemonics[0][0]},
+		{mnemonics[2][0], mnemonics[1][0], mnemonics[1][1], mnemonics[1][2]},
+	}
+	for i, combination := range combinations {
+		combined, err := s.CombineMnemonics(combination, slip39TestPassphrase)
+		if err != nil || !bytes.Equal(combined, masterSecret) {
+			t.Errorf("combination %d: %x, %v, want %x", i, combined, err, masterSecret)
+		}
+	}
+
+	combined, err := s.CombineMnemonics([]string{mnemonics[0][0], mnemonics[0][1], mnemonics[1][0]}, slip39TestPassphrase)
+	if err == nil {
+		t.Errorf("combined one complete group out of two into %x", combined)
+	}
+
+	combined, err = s.CombineMnemonics(combinations[0], "wrong")
+	if err != nil || bytes.Equal(combined, masterSecret) {
+		t.Errorf("another passphrase combined into %x, %v, want a different secret", combined, err)
+	}
+}
@@ -0,0 +1,228 @@
+package repo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"swisswallet/logger"
+	"swisswallet/model"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// RLP payloads of unsigned transactions, without the signature values. Legacy
+// transactions may carry the EIP-155 chain ID followed by two zeros.
+type unsignedLegacyTx struct {
+	Nonce    uint64
+	GasPrice *big.Int
+	Gas      uint64
+	To       *common.Address `rlp:"nil"`
+	Value    *big.Int
+	Data     []byte
+	ChainID  *big.Int `rlp:"optional"`
+	Zero1    *big.Int `rlp:"optional"`
+	Zero2    *big.Int `rlp:"optional"`
+}
+
+type unsignedAccessListTx struct {
+	ChainID    *big.Int
+	Nonce      uint64
+	GasPrice   *big.Int
+	Gas        uint64
+	To         *common.Address `rlp:"nil"`
+	Value      *big.Int
+	Data       []byte
+	AccessList types.AccessList
+}
+
+type unsignedDynamicFeeTx struct {
+	ChainID    *big.Int
+	Nonce      uint64
+	GasTipCap  *big.Int
+	GasFeeCap  *big.Int
+	Gas        uint64
+	To         *common.Address `rlp:"nil"`
+	Value      *big.Int
+	Data       []byte
+	AccessList types.AccessList
+}
+
+// ParseUnsignedTransaction reads a legacy, EIP-2930 or EIP-1559 transaction
+// given as eth_signTransaction JSON or as unsigned RLP hex, and rejects a
+// chain ID that differs from the one it will be signed for.
+func (c *cryptoRepository) ParseUnsignedTransaction(input string, chainID *big.Int) (*types.Transaction, error) {
+	c.logger.LogOnEntryWithContext(c.logger.GetContext(), input, chainID)
+
+	input = strings.TrimSpace(input)
+	var tx *types.Transaction
+	var txChainID *big.Int
+	var err error
+	if strings.HasPrefix(input, "{") {
+		tx, txChainID, err = parseTransactionJSON([]byte(input), chainID)
+	} else {
+		tx, txChainID, err = parseTransactionRLP(input)
+	}
+	if err != nil {
+		c.logger.LogOnBadRequestErrorWithContext(c.logger.GetContext(), err)
+		return nil, err
+	}
+
+	if txChainID != nil && txChainID.Sign() != 0 && txChainID.Cmp(chainID) != 0 {
+		err = errors.New(fmt.Sprintf("Transaction chain ID %s does not match the chain ID %s", txChainID, chainID))
+		c.logger.LogOnBadRequestErrorWithContext(c.logger.GetContext(), err)
+		return nil, err
+	}
+
+	c.logger.LogOnExitWithContext(c.logger.GetContext(), tx.Type(), err)
+	return tx, err
+}
+
+func parseTransactionJSON(input []byte, defaultChainID *big.Int) (*types.Transaction, *big.Int, error) {
+	var unsigned model.UnsignedTransaction
+	err := json.Unmarshal(input, &unsigned)
+	if err != nil {
+		return nil, nil, err
+	}
+	if unsigned.V != nil || unsigned.R != nil || unsigned.S != nil {
+		return nil, nil, errors.New("Transaction is already signed")
+	}
+	if unsigned.Nonce == nil || unsigned.Gas == nil {
+		return nil, nil, errors.New("Transaction JSON needs a nonce and a gas limit")
+	}
+
+	txType := uint64(types.LegacyTxType)
+	if unsigned.Type != nil {
+		txType = uint64(*unsigned.Type)
+	} else if unsigned.MaxFeePerGas != nil || unsigned.MaxPriorityFeePerGas != nil {
+		txType = types.DynamicFeeTxType
+	} else if unsigned.AccessList != nil {
+		txType = types.AccessListTxType
+	}
+
+	data := unsigned.Data
+	if data == nil {
+		data = unsigned.Input
+	} else if unsigned.Input != nil && !strings.EqualFold(data.String(), unsigned.Input.String()) {
+		return nil, nil, errors.New("Transaction JSON has different data and input fields")
+	}
+	value := new(big.Int)
+	if unsigned.Value != nil {
+		value = unsigned.Value.ToInt()
+	}
+	var accessList types.AccessList
+	if unsigned.AccessList != nil {
+		accessList = *unsigned.AccessList
+	}
+	chainID := defaultChainID
+	if unsigned.ChainID != nil {
+		chainID = unsigned.ChainID.ToInt()
+	}
+	var payload []byte
+	if data != nil {
+		payload = *data
+	}
+
+	switch txType {
+	case types.LegacyTxType, types.AccessListTxType:
+		if unsigned.GasPrice == nil {
+			return nil, nil, errors.New("Legacy and EIP-2930 transactions need a gasPrice")
+		}
+		if txType == types.LegacyTxType {
+			return types.NewTx(&types.LegacyTx{Nonce: uint64(*unsigned.Nonce), GasPrice: unsigned.GasPrice.ToInt(), Gas: uint64(*unsigned.Gas), To: unsigned.To, Value: value, Data: payload}), chainID, nil
+		}
+		return types.NewTx(&types.AccessListTx{ChainID: chainID, Nonce: uint64(*unsigned.Nonce), GasPrice: unsigned.GasPrice.ToInt(), Gas: uint64(*unsigned.Gas), To: unsigned.To, Value: value, Data: payload, AccessList: accessList}), chainID, nil
+	case types.DynamicFeeTxType:
+		if unsigned.MaxFeePerGas == nil || unsigned.MaxPriorityFeePerGas == nil {
+			return nil, nil, errors.New("EIP-1559 transactions need a maxFeePerGas and a maxPriorityFeePerGas")
+		}
+		return types.NewTx(&types.DynamicFeeTx{ChainID: chainID, Nonce: uint64(*unsigned.Nonce), GasTipCap: unsigned.MaxPriorityFeePerGas.ToInt(), GasFeeCap: unsigned.MaxFeePerGas.ToInt(), Gas: uint64(*unsigned.Gas), To: unsigned.To, Value: value, Data: payload, AccessList: accessList}), chainID, nil
+	default:
+		return nil, nil, errors.New(fmt.Sprintf("Transaction type not supported: %d", txType))
+	}
+}
+
+func parseTransactionRLP(input string) (*types.Transaction, *big.Int, error) {
+	if !strings.HasPrefix(input, "0x") {
+		input = "0x" + input
+	}
+	encoded, err := hexutil.Decode(input)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(encoded) == 0 {
+		return nil, nil, errors.New("Transaction is empty")
+	}
+
+	switch {
+	case encoded[0] >= 0xc0:
+		var unsigned unsignedLegacyTx
+		err = rlp.DecodeBytes(encoded, &unsigned)
+		if err != nil {
+			return nil, nil, err
+		}
+		if unsigned.ChainID != nil && (unsigned.Zero1 == nil || unsigned.Zero2 == nil || unsigned.Zero1.Sign() != 0 || unsigned.Zero2.Sign() != 0) {
+			return nil, nil, errors.New("Transaction is already signed or is not an EIP-155 signing payload")
+		}
+		return types.NewTx(&types.LegacyTx{Nonce: unsigned.Nonce, GasPrice: unsigned.GasPrice, Gas: unsigned.Gas, To: unsigned.To, Value: unsigned.Value, Data: unsigned.Data}), unsigned.ChainID, nil
+	case encoded[0] == types.AccessListTxType:
+		if isSignedTypedTx(encoded[1:], 8) {
+			return nil, nil, errors.New("Transaction is already signed")
+		}
+		var unsigned unsignedAccessListTx
+		err = rlp.DecodeBytes(encoded[1:], &unsigned)
+		if err != nil {
+			return nil, nil, err
+		}
+		return types.NewTx(&types.AccessListTx{ChainID: unsigned.ChainID, Nonce: unsigned.Nonce, GasPrice: unsigned.GasPrice, Gas: unsigned.Gas, To: unsigned.To, Value: unsigned.Value, Data: unsigned.Data, AccessList: unsigned.AccessList}), unsigned.ChainID, nil
+	case encoded[0] == types.DynamicFeeTxType:
+		if isSignedTypedTx(encoded[1:], 9) {
+			return nil, nil, errors.New("Transaction is already signed")
+		}
+		var unsigned unsignedDynamicFeeTx
+		err = rlp.DecodeBytes(encoded[1:], &unsigned)
+		if err != nil {
+			return nil, nil, err
+		}
+		return types.NewTx(&types.DynamicFeeTx{ChainID: unsigned.ChainID, Nonce: unsigned.Nonce, GasTipCap: unsigned.GasTipCap, GasFeeCap: unsigned.GasFeeCap, Gas: unsigned.Gas, To: unsigned.To, Value: unsigned.Value, Data: unsigned.Data, AccessList: unsigned.AccessList}), unsigned.ChainID, nil
+	default:
+		return nil, nil, errors.New(fmt.Sprintf("Transaction type not supported: %d", encoded[0]))
+	}
+}
+
+// isSignedTypedTx reports whether the payload of a typed transaction has the
+// three signature values after its fields.
+func isSignedTypedTx(payload []byte, fields int) bool {
+	content, _, err := rlp.SplitList(payload)
+	if err != nil {
+		return false
+	}
+	count, err := rlp.CountValues(content)
+	return err == nil && count == fields+3
+}
+
+// SignTransaction signs with the London signer, which covers EIP-155 replay
+// protection for legacy transactions as well as the typed envelopes.
+func (c *cryptoRepository) SignTransaction(tx *types.Transaction, chainID *big.Int, privateKey []byte) (*types.Transaction, error) {
+	c.logger.LogOnEntryWithContext(c.logger.GetContext(), tx.Hash().Hex(), chainID, logger.SecretBytes(privateKey))
+
+	ecdsaKey, err := ethcrypto.ToECDSA(privateKey)
+	if err != nil {
+		c.logger.LogOnBadRequestErrorWithContext(c.logger.GetContext(), err)
+		return nil, err
+	}
+
+	signedTx, err := types.SignTx(tx, types.NewLondonSigner(chainID), ecdsaKey)
+	if err != nil {
+		c.logger.LogOnBadRequestErrorWithContext(c.logger.GetContext(), err)
+		return nil, err
+	}
+
+	c.logger.LogOnExitWithContext(c.logger.GetContext(), signedTx.Hash().Hex(), err)
+	return signedTx, err
+}
@@ -0,0 +1,144 @@
+package repo
+
+import (
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+// The key and the legacy transaction are the example of EIP-155.
+const (
+	transactionTestKey     = "4646464646464646464646464646464646464646464646464646464646464646"
+	transactionTestAddress = "0x9d8A62f656a8d1615C1294fd71e9CFb3E4855A4F"
+)
+
+var unsignedTransactionVectors = []struct {
+	name   string
+	json   string
+	rlp    string
+	txType uint8
+	// signed is the expected raw transaction signed for chain ID 1.
+	signed string
+}{
+	{
+		name:   "legacy EIP-155",
+		json:   `{"nonce":"0x9","gasPrice":"0x4a817c800","gas":"0x5208","to":"0x3535353535353535353535353535353535353535","value":"0xde0b6b3a7640000","chainId":"0x1"}`,
+		rlp:    "0xec098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a764000080018080",
+		txType: types.LegacyTxType,
+		signed: "0xf86c098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a76400008025a028ef61340bd939bc2195fe537567866003e1a15d3c71ff63e1590620aa636276a067cbe9d8997f761aecb703304b3800ccf555c9f3dc64214b297fb1966a3b6d83",
+	},
+	{
+		name:   "legacy without chain ID",
+		json:   `{"nonce":"0x9","gasPrice":"0x4a817c800","gas":"0x5208","to":"0x3535353535353535353535353535353535353535","value":"0xde0b6b3a7640000"}`,
+		rlp:    "e9098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a764000080",
+		txType: types.LegacyTxType,
+		signed: "0xf86c098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a76400008025a028ef61340bd939bc2195fe537567866003e1a15d3c71ff63e1590620aa636276a067cbe9d8997f761aecb703304b3800ccf555c9f3dc64214b297fb1966a3b6d83",
+	},
+	{
+		name:   "EIP-2930",
+		json:   `{"type":"0x1","chainId":"0x1","nonce":"0x9","gasPrice":"0x4a817c800","gas":"0x7530","to":"0x3535353535353535353535353535353535353535","value":"0xde0b6b3a7640000","data":"0x1234","accessList":[{"address":"0x3535353535353535353535353535353535353535","storageKeys":["0x0000000000000000000000000000000000000000000000000000000000000001"]}]}`,
+		rlp:    "0x01f86601098504a817c800827530943535353535353535353535353535353535353535880de0b6b3a7640000821234f838f7943535353535353535353535353535353535353535e1a00000000000000000000000000000000000000000000000000000000000000001",
+		txType: types.AccessListTxType,
+	},
+	{
+		name:   "EIP-1559",
+		json:   `{"chainId":"0x1","nonce":"0x9","maxPriorityFeePerGas":"0x77359400","maxFeePerGas":"0x6fc23ac00","gas":"0x5208","to":"0x3535353535353535353535353535353535353535","value":"0xde0b6b3a7640000","input":"0x"}`,
+		rlp:    "0x02f0010984773594008506fc23ac00825208943535353535353535353535353535353535353535880de0b6b3a764000080c0",
+		txType: types.DynamicFeeTxType,
+		signed: "0x02f873010984773594008506fc23ac00825208943535353535353535353535353535353535353535880de0b6b3a764000080c080a02b03b67e070f45175ce9d07c4512720168bd468a24edb6997977a53d48c87a12a0733d775fdd689d306e08ac8ab399f34b5a0253b47ed81b8bf2d2a6ea607fcac7",
+	},
+	{
+		name:   "EIP-1559 contract creation",
+		json:   `{"type":"0x2","chainId":"0x1","nonce":"0x0","maxPriorityFeePerGas":"0x77359400","maxFeePerGas":"0x6fc23ac00","gas":"0x186a0","data":"0x6000"}`,
+		rlp:    "0x02d7018084773594008506fc23ac00830186a08080826000c0",
+		txType: types.DynamicFeeTxType,
+	},
+}
+
+func TestParseAndSignUnsignedTransactions(t *testing.T) {
+	c := newTestCryptoRepository()
+	chainID := big.NewInt(1)
+	signer := types.NewLondonSigner(chainID)
+	privateKey, _ := hex.DecodeString(transactionTestKey)
+
+	for _, vector := range unsignedTransactionVectors {
+		encoded, _ := hexutil.Decode("0x" + strings.TrimPrefix(vector.rlp, "0x"))
+		var sighash []byte
+		for form, input := range map[string]string{"json": vector.json, "rlp": vector.rlp} {
+			tx, err := c.ParseUnsignedTransaction(input, chainID)
+			if err != nil {
+				t.Errorf("%s %s: %v", vector.name, form, err)
+				continue
+			}
+			if tx.Type() != vector.txType {
+				t.Errorf("%s %s: type %d, want %d", vector.name, form, tx.Type(), vector.txType)
+			}
+			// The signing hash of an unsigned typed or EIP-155 payload is the
+			// hash of the payload itself.
+			hash := signer.Hash(tx).Bytes()
+			if vector.name != "legacy without chain ID" && hexutil.Encode(hash) != ethcrypto.Keccak256Hash(encoded).Hex() {
+				t.Errorf("%s %s: signing hash %x is not the hash of the payload", vector.name, form, hash)
+			}
+			if sighash != nil && hexutil.Encode(hash) != hexutil.Encode(sighash) {
+				t.Errorf("%s: the JSON and RLP forms have different signing hashes", vector.name)
+			}
+			sighash = hash
+
+			signedTx, err := c.SignTransaction(tx, chainID, privateKey)
+			if err != nil {
+				t.Fatalf("%s %s: %v", vector.name, form, err)
+			}
+			raw, _ := signedTx.MarshalBinary()
+			var decoded types.Transaction
+			err = decoded.UnmarshalBinary(raw)
+			if err != nil {
+				t.Fatalf("%s %s: %v", vector.name, form, err)
+			}
+			sender, err := types.Sender(signer, &decoded)
+			if err != nil || sender.Hex() != transactionTestAddress {
+				t.Errorf("%s %s: sender %s, %v, want %s", vector.name, form, sender.Hex(), err, transactionTestAddress)
+			}
+			if vector.signed != "" && hexutil.Encode(raw) != vector.signed {
+				t.Errorf("%s %s: signed %x, want %s", vector.name, form, raw, vector.signed)
+			}
+		}
+	}
+}
+
+func TestParseUnsignedTransactionRejectsOtherChainIDs(t *testing.T) {
+	c := newTestCryptoRepository()
+
+	for _, vector := range unsignedTransactionVectors {
+		if vector.name == "legacy without chain ID" {
+			continue
+		}
+		for form, input := range map[string]string{"json": vector.json, "rlp": vector.rlp} {
+			_, err := c.ParseUnsignedTransaction(input, big.NewInt(5))
+			if err == nil || !strings.Contains(err.Error(), "does not match the chain ID 5") {
+				t.Errorf("%s %s: signing for chain ID 5 gives %v", vector.name, form, err)
+			}
+		}
+	}
+}
+
+func TestParseUnsignedTransactionRejectsSignedTransactions(t *testing.T) {
+	c := newTestCryptoRepository()
+
+	signed := []string{
+		unsignedTransactionVectors[0].signed,
+		unsignedTransactionVectors[3].signed,
+		"0x01f8a901098504a817c800827530943535353535353535353535353535353535353535880de0b6b3a7640000821234f838f7943535353535353535353535353535353535353535e1a0000000000000000000000000000000000000000000000000000000000000000180a02b03b67e070f45175ce9d07c4512720168bd468a24edb6997977a53d48c87a12a0733d775fdd689d306e08ac8ab399f34b5a0253b47ed81b8bf2d2a6ea607fcac7",
+		`{"nonce":"0x9","gasPrice":"0x4a817c800","gas":"0x5208","to":"0x3535353535353535353535353535353535353535","value":"0xde0b6b3a7640000","chainId":"0x1","v":"0x25","r":"0x28ef61340bd939bc2195fe537567866003e1a15d3c71ff63e1590620aa636276","s":"0x67cbe9d8997f761aecb703304b3800ccf555c9f3dc64214b297fb1966a3b6d83"}`,
+	}
+	for _, input := range signed {
+		_, err := c.ParseUnsignedTransaction(input, big.NewInt(1))
+		if err == nil || !strings.Contains(err.Error(), "already signed") {
+			t.Errorf("signed transaction %.40s... gives %v", input, err)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package repo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+// EIP-712 typed structured data. The signer package of go-ethereum implements
+// it too, but pulls in the USB hardware wallet drivers along with it.
+
+type typedDataField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type typedData struct {
+	Types       map[string][]typedDataField `json:"types"`
+	PrimaryType string                      `json:"primaryType"`
+	Domain      map[string]interface{}      `json:"domain"`
+	Message     map[string]interface{}      `json:"message"`
+}
+
+const typedDataDomainType = "EIP712Domain"
+const typedDataMaxDepth = 32
+
+var typedDataArrayType = regexp.MustCompile(`^(.+)\[(\d*)\]$`)
+var typedDataIntegerType = regexp.MustCompile(`^(u?)int(\d*)$`)
+var typedDataBytesType = regexp.MustCompile(`^bytes(\d+)$`)
+
+// typedDataHash returns the digest that is signed for EIP-712 data, that is
+// keccak256(0x19 0x01 domainSeparator hashStruct(message)).
+func typedDataHash(encoded []byte) ([]byte, error) {
+	var data typedData
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	err := decoder.Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := data.Types[typedDataDomainType]; !ok {
+		return nil, errors.New("Typed data has no EIP712Domain type")
+	}
+	if _, ok := data.Types[data.PrimaryType]; !ok {
+		return nil, errors.New(fmt.Sprintf("Typed data has no type for its primary type: %q", data.PrimaryType))
+	}
+
+	domainSeparator, err := data.hashStruct(typedDataDomainType, data.Domain, 0)
+	if err != nil {
+		return nil, err
+	}
+	digest := append([]byte{0x19, 0x01}, domainSeparator...)
+	if data.PrimaryType != typedDataDomainType {
+		messageHash, err := data.hashStruct(data.PrimaryType, data.Message, 0)
+		if err != nil {
+			return nil, err
+		}
+		digest = append(digest, messageHash...)
+	}
+	return ethcrypto.Keccak256(digest), nil
+}
+
+func baseType(fieldType string) string {
+	for {
+		match := typedDataArrayType.FindStringSubmatch(fieldType)
+		if match == nil {
+			return fieldType
+		}
+		fieldType = match[1]
+	}
+}
+
+func (t *typedData) dependencies(primaryType string, found map[string]bool) {
+	if found[primaryType] {
+		return
+	}
+	fields, ok := t.Types[primaryType]
+	if !ok {
+		return
+	}
+	found[primaryType] = true
+	for _, field := range fields {
+		t.dependencies(baseType(field.Type), found)
+	}
+}
+
+func (t *typedData) encodeType(primaryType string) string {
+	found := map[string]bool{}
+	t.dependencies(primaryType, found)
+	delete(found, primaryType)
+	dependencies := make([]string, 0, len(found))
+	for dependency := range found {
+		dependencies = append(dependencies, dependency)
+	}
+	sort.Strings(dependencies)
+
+	var encoded strings.Builder
+	for _, name := range append([]string{primaryType}, dependencies...) {
+		fields := make([]string, len(t.Types[name]))
+		for i, field := range t.Types[name] {
+			fields[i] = field.Type + " " + field.Name
+		}
+		encoded.WriteString(name + "(" + strings.Join(fields, ",") + ")")
+	}
+	return encoded.String()
+}
+
+func (t *typedData) hashStruct(primaryType string, data map[string]interface{}, depth int) ([]byte, error) {
+	if depth > typedDataMaxDepth {
+		return nil, errors.New("Typed data is nested too deeply")
+	}
+	encoded := ethcrypto.Keccak256([]byte(t.encodeType(primaryType)))
+	for _, field := range t.Types[primaryType] {
+		value, err := t.encodeValue(field.Type, data[field.Name], depth)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("Typed data field %s.%s: %s", primaryType, field.Name, err))
+		}
+		encoded = append(encoded, value...)
+	}
+	return ethcrypto.Keccak256(encoded), nil
+}
+
+func (t *typedData) encodeValue(fieldType string, value interface{}, depth int) ([]byte, error) {
+	if match := typedDataArrayType.FindStringSubmatch(fieldType); match != nil {
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil, errors.New("expected an array")
+		}
+		if match[2] != "" {
+			length, _ := strconv.Atoi(match[2])
+			if len(items) != length {
+				return nil, errors.New(fmt.Sprintf("expected %d items", length))
+			}
+		}
+		var encoded []byte
+		for _, item := range items {
+			itemEncoded, err := t.encodeValue(match[1], item, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			encoded = append(encoded, itemEncoded...)
+		}
+		return ethcrypto.Keccak256(encoded), nil
+	}
+
+	if _, ok := t.Types[fieldType]; ok {
+		if value == nil {
+			return make([]byte, 32), nil
+		}
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, errors.New("expected an object")
+		}
+		return t.hashStruct(fieldType, fields, depth+1)
+	}
+
+	switch fieldType {
+	case "string":
+		text, ok := value.(string)
+		if !ok {
+			return nil, errors.New("expected a string")
+		}
+		return ethcrypto.Keccak256([]byte(text)), nil
+	case "bytes":
+		decoded, err := typedDataBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		return ethcrypto.Keccak256(decoded), nil
+	case "bool":
+		flag, ok := value.(bool)
+		if !ok {
+			return nil, errors.New("expected a boolean")
+		}
+		encoded := make([]byte, 32)
+		if flag {
+			encoded[31] = 1
+		}
+		return encoded, nil
+	case "address":
+		decoded, err := typedDataBytes(value)
+		if err != nil || len(decoded) != 20 {
+			return nil, errors.New("expected a 20 byte address")
+		}
+		return append(make([]byte, 12), decoded...), nil
+	}
+
+	if match := typedDataBytesType.FindStringSubmatch(fieldType); match != nil {
+		length, _ := strconv.Atoi(match[1])
+		decoded, err := typedDataBytes(value)
+		if err != nil || length < 1 || length > 32 || len(decoded) != length {
+			return nil, errors.New(fmt.Sprintf("expected %d bytes", length))
+		}
+		return append(decoded, make([]byte, 32-length)...), nil
+	}
+
+	if match := typedDataIntegerType.FindStringSubmatch(fieldType); match != nil {
+		bits := 256
+		if match[2] != "" {
+			bits, _ = strconv.Atoi(match[2])
+		}
+		if bits < 8 || bits > 256 || bits%8 != 0 {
+			return nil, errors.New(fmt.Sprintf("unsupported integer type %s", fieldType))
+		}
+		number, err := typedDataInteger(value)
+		if err != nil {
+			return nil, err
+		}
+		minimum, maximum := new(big.Int), new(big.Int).Lsh(big.NewInt(1), uint(bits))
+		if match[1] == "" {
+			maximum.Rsh(maximum, 1)
+			minimum.Neg(maximum)
+		}
+		if number.Cmp(minimum) < 0 || number.Cmp(maximum) >= 0 {
+			return nil, errors.New(fmt.Sprintf("%s out of range for %s", number, fieldType))
+		}
+		if number.Sign() < 0 {
+			number.Add(number, new(big.Int).Lsh(big.NewInt(1), 256))
+		}
+		return bytes32(number), nil
+	}
+
+	return nil, errors.New(fmt.Sprintf("unknown type %s", fieldType))
+}
+
+func typedDataBytes(value interface{}) ([]byte, error) {
+	text, ok := value.(string)
+	if !ok {
+		return nil, errors.New("expected a hex string")
+	}
+	return hexutil.Decode(text)
+}
+
+// typedDataInteger accepts JSON numbers as well as decimal and 0x prefixed
+// hex strings, since wallets send large integers as strings.
+func typedDataInteger(value interface{}) (*big.Int, error) {
+	var text string
+	switch v := value.(type) {
+	case json.Number:
+		text = v.String()
+	case string:
+		text = v
+	default:
+		return nil, errors.New("expected an integer")
+	}
+	base := 10
+	if strings.HasPrefix(text, "0x") || strings.HasPrefix(text, "0X") {
+		text, base = text[2:], 16
+	}
+	number, ok := new(big.Int).SetString(text, base)
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("invalid integer %q", text))
+	}
+	return number, nil
+}
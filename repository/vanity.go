@@ -0,0 +1,16 @@
+package repo
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+// VanityEntropy mixes a vanity counter into the entropy the KDF produced, as
+// SHA-256(entropy || counter), so a vanity wallet costs one KDF run plus one
+// hash per counter tried.
+func (c *cryptoRepository) VanityEntropy(entropy []byte, counter uint64) []byte {
+	counterBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(counterBytes, counter)
+	hash := sha256.Sum256(append(append([]byte{}, entropy...), counterBytes...))
+	return hash[:]
+}
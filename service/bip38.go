@@ -0,0 +1,109 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	. "swisswallet/constants"
+	"swisswallet/logger"
+	"swisswallet/model"
+
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+func (s *service) CheckBip38Arguments(arguments model.Arguments) (*chaincfg.Params, error) {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), arguments)
+
+	network, err := s.GetBitcoinNetwork(arguments.Currency)
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return nil, err
+	}
+
+	err = s.simpleUtils.CheckIfSupported(arguments.Bip38Mode, s.simpleUtils.GetSupportedBip38Modes())
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return nil, err
+	}
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), network.Name, err)
+	return network, err
+}
+
+func (s *service) EncryptBip38Key(privateKey []byte, arguments model.Arguments) (string, string, error) {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), logger.SecretBytes(privateKey), arguments)
+
+	network, err := s.CheckBip38Arguments(arguments)
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return "", "", err
+	}
+
+	encryptedKey, err := s.cryptoRepository.Bip38Encrypt(privateKey, arguments.Bip38Passphrase, network)
+	if err != nil {
+		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+		return "", "", err
+	}
+
+	address, err := s.cryptoRepository.GetBitcoinAddress(privateKey, true, network)
+	if err != nil {
+		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+		return "", "", err
+	}
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), address, encryptedKey, err)
+	return address, encryptedKey, err
+}
+
+func (s *service) DecryptBip38Wallet(arguments model.Arguments) error {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), arguments)
+
+	if arguments.AddressIsEmpty() {
+		err := errors.New("BIP38 Private Key and address are required in decryption mode")
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	network, err := s.CheckBip38Arguments(arguments)
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	passphrase := arguments.Bip38Passphrase
+	if arguments.Bip38PassphraseIsEmpty() {
+		passphrase = arguments.Password
+	}
+
+	privateKey, compressed, err := s.cryptoRepository.Bip38Decrypt(arguments.Key, passphrase, network)
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	address, err := s.cryptoRepository.GetBitcoinAddress(privateKey, compressed, network)
+	if err != nil {
+		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	if arguments.Address == address {
+		wif, err := s.cryptoRepository.GetBitcoinWIF(privateKey, compressed, network)
+		if err != nil {
+			s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+			return err
+		}
+		fmt.Println("Private Key successfully decrypted")
+		fmt.Printf("Decrypted Private Key: %x\n", privateKey)
+		fmt.Printf("WIF: %s\n", wif)
+	} else {
+		fmt.Println("Private Key does not match the provided address, or wrong output mode")
+	}
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), err)
+	return err
+}
+
+func isBip38Key(key string) bool {
+	return strings.HasPrefix(key, BIP38_PREFIX)
+}
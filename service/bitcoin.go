@@ -0,0 +1,23 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+var bitcoinNetworksByCurrency = map[string]*chaincfg.Params{
+	"bitcoin": &chaincfg.MainNetParams,
+	"testnet": &chaincfg.TestNet3Params,
+}
+
+func (s *service) GetBitcoinNetwork(currency string) (*chaincfg.Params, error) {
+	network, ok := bitcoinNetworksByCurrency[currency]
+	if !ok {
+		err := errors.New(fmt.Sprintf("Only supported for bitcoin and testnet, not: %s", currency))
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return nil, err
+	}
+	return network, nil
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	. "swisswallet/constants"
+	"swisswallet/model"
+)
+
+// CheckDecoyArguments validates -profile, -decoy-index, -decoys and
+// -duress-suffix before the KDF runs.
+func (s *service) CheckDecoyArguments(arguments model.Arguments) error {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), arguments)
+
+	err := s.simpleUtils.CheckIfSupported(arguments.Profile, s.simpleUtils.GetSupportedProfiles())
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+	if arguments.DecoyIndex < 1 {
+		err = errors.New("Decoy index must be at least 1")
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+	if arguments.Decoys < 0 || arguments.Decoys > MAX_DECOYS {
+		err = errors.New(fmt.Sprintf("Number of decoys must be between 0 and %d", MAX_DECOYS))
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+	if arguments.DuressSuffix == "" && (arguments.Decoys > 0 || arguments.Profile == DECOY_PROFILE) {
+		err = errors.New("Duress suffix cannot be empty, the duress password would be the real one")
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+	if arguments.Decoys > 0 && arguments.Profile == DECOY_PROFILE {
+		err = errors.New("Decoys can only be listed with -profile real")
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), err)
+	return err
+}
+
+// DecoyArguments returns the arguments of decoy wallet index: the same ones
+// with the duress password, the password followed by -duress-suffix and, from
+// decoy 2 on, by the index. A decoy is thus an ordinary wallet of its duress
+// password, which a coerced user can hand over instead of the real password.
+func (s *service) DecoyArguments(arguments model.Arguments, index int) model.Arguments {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), arguments, index)
+
+	decoy := arguments
+	decoy.Password = arguments.Password + arguments.DuressSuffix
+	if index > 1 {
+		decoy.Password += strconv.Itoa(index)
+	}
+	decoy.Profile = REAL_PROFILE
+	decoy.DecoyIndex = 1
+	decoy.Decoys = 0
+	s.logger.AddSecrets(decoy.Password)
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), decoy)
+	return decoy
+}
+
+// DecoyAddresses returns the addresses of decoys 1 to -decoys, the wallets
+// -profile decoy -decoy-index N derives, without vanity search. Each decoy
+// runs the KDFs again on its duress password.
+func (s *service) DecoyAddresses(arguments model.Arguments) ([]string, error) {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), arguments)
+
+	addresses := make([]string, arguments.Decoys)
+	for i := range addresses {
+		decoy := s.DecoyArguments(arguments, i+1)
+		decoy.Vanity = ""
+		decoy.VanityCounter = 0
+		wallet, err := s.walletFromPassword(decoy)
+		if err != nil {
+			s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+			return nil, err
+		}
+		addresses[i] = wallet.Address
+	}
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), addresses)
+	return addresses, nil
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	. "swisswallet/constants"
+	"swisswallet/model"
+)
+
+func TestDecoyIsTheWalletOfItsDuressPassword(t *testing.T) {
+	s, _, _ := newFakeService()
+
+	duressPasswords := []string{"correct horse battery staple!", "correct horse battery staple!2", "correct horse battery staple!3"}
+	for i, duressPassword := range duressPasswords {
+		arguments := testArguments("ethereum", MINIMUM_DIFFICULTY, ENGLISH_LANGUAGE, MNEMONIC_OUTPUT)
+		arguments.Profile = DECOY_PROFILE
+		arguments.DecoyIndex = i + 1
+		decoy, err := s.DeriveWallet(arguments)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		arguments = testArguments("ethereum", MINIMUM_DIFFICULTY, ENGLISH_LANGUAGE, MNEMONIC_OUTPUT)
+		arguments.Password = duressPassword
+		wallet, err := s.DeriveWallet(arguments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decoy.Address != wallet.Address || decoy.Mnemonic != wallet.Mnemonic {
+			t.Errorf("decoy %d derives %s, the duress password %q derives %s", i+1, decoy.Address, duressPassword, wallet.Address)
+		}
+	}
+}
+
+func TestDeriveWalletListsDecoys(t *testing.T) {
+	s, _, _ := newFakeService()
+	arguments := testArguments("ethereum", MINIMUM_DIFFICULTY, ENGLISH_LANGUAGE, RAW_OUTPUT)
+	arguments.Decoys = 3
+	arguments.Vanity = "a"
+
+	wallet, err := s.DeriveWallet(arguments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wallet.DecoyAddresses) != arguments.Decoys {
+		t.Fatalf("%d decoy addresses, want %d", len(wallet.DecoyAddresses), arguments.Decoys)
+	}
+	for i, address := range wallet.DecoyAddresses {
+		decoyArguments := testArguments("ethereum", MINIMUM_DIFFICULTY, ENGLISH_LANGUAGE, RAW_OUTPUT)
+		decoyArguments.Profile = DECOY_PROFILE
+		decoyArguments.DecoyIndex = i + 1
+		decoy, err := s.DeriveWallet(decoyArguments)
+		if err != nil || decoy.Address != address {
+			t.Errorf("decoy %d listed as %s, derived as %v, %v", i+1, address, decoy, err)
+		}
+		if address == wallet.Address {
+			t.Errorf("decoy %d has the real address %s", i+1, address)
+		}
+	}
+}
+
+func TestCheckDecoyArguments(t *testing.T) {
+	s, _, _ := newFakeService()
+
+	tests := []struct {
+		profile      string
+		decoyIndex   int
+		decoys       int
+		duressSuffix string
+		wantErr      bool
+	}{
+		{REAL_PROFILE, 1, 0, DEFAULT_DURESS_SUFFIX, false},
+		{REAL_PROFILE, 1, 0, "", false},
+		{REAL_PROFILE, 1, MAX_DECOYS, " please", false},
+		{REAL_PROFILE, 1, MAX_DECOYS + 1, DEFAULT_DURESS_SUFFIX, true},
+		{REAL_PROFILE, 1, 2, "", true},
+		{DECOY_PROFILE, 2, 0, DEFAULT_DURESS_SUFFIX, false},
+		{DECOY_PROFILE, 0, 0, DEFAULT_DURESS_SUFFIX, true},
+		{DECOY_PROFILE, 1, 0, "", true},
+		{DECOY_PROFILE, 1, 2, DEFAULT_DURESS_SUFFIX, true},
+		{"duress", 1, 0, DEFAULT_DURESS_SUFFIX, true},
+	}
+	for _, test := range tests {
+		arguments := testArguments("ethereum", MINIMUM_DIFFICULTY, ENGLISH_LANGUAGE, RAW_OUTPUT)
+		arguments.Profile, arguments.DecoyIndex, arguments.Decoys, arguments.DuressSuffix = test.profile, test.decoyIndex, test.decoys, test.duressSuffix
+		err := s.CheckDecoyArguments(arguments)
+		if (err != nil) != test.wantErr {
+			t.Errorf("CheckDecoyArguments(%s, %d, %d, %q) = %v, want error %v", test.profile, test.decoyIndex, test.decoys, test.duressSuffix, err, test.wantErr)
+		}
+	}
+}
+
+func TestPaperRegenerationHintHasDecoyProfile(t *testing.T) {
+	arguments := testArguments("ethereum", MINIMUM_DIFFICULTY, ENGLISH_LANGUAGE, RAW_OUTPUT)
+	arguments.Profile = DECOY_PROFILE
+	arguments.DecoyIndex = 2
+
+	hint := paperRegenerationHint(model.PaperWallet{}, arguments)
+	if !strings.Contains(hint, " -profile decoy -decoy-index 2") || strings.Contains(hint, "-duress-suffix") {
+		t.Errorf("hint %q, want -profile decoy -decoy-index 2 and the default duress suffix", hint)
+	}
+	arguments.DuressSuffix = " please"
+	hint = paperRegenerationHint(model.PaperWallet{}, arguments)
+	if !strings.Contains(hint, " -duress-suffix <duress suffix>") || strings.Contains(hint, "please") {
+		t.Errorf("hint %q, want a placeholder for the duress suffix", hint)
+	}
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	. "swisswallet/constants"
+	"swisswallet/model"
+)
+
+// Single key script types, each on the account of its BIP44, BIP49, BIP84 or
+// BIP86 derivation path.
+var descriptorScripts = []struct {
+	path   string
+	script string
+}{
+	{BIP44_DERIVATION_PATH, "pkh(%s)"},
+	{BIP49_DERIVATION_PATH, "sh(wpkh(%s))"},
+	{BIP84_DERIVATION_PATH, "wpkh(%s)"},
+	{BIP86_DERIVATION_PATH, "tr(%s)"},
+}
+
+func (s *service) CheckDescriptorArguments(arguments model.Arguments) error {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), arguments)
+
+	_, err := s.GetBitcoinNetwork(arguments.Currency)
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	if arguments.Output != MNEMONIC_OUTPUT {
+		err = errors.New("Descriptors can only be printed from mnemonic output")
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), err)
+	return err
+}
+
+// PrintDescriptors prints the receive and change descriptors of every script
+// type, first with the account xpub and then with the account xprv.
+func (s *service) PrintDescriptors(mnemonic string, arguments model.Arguments) error {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), arguments)
+
+	err := s.CheckDescriptorArguments(arguments)
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+	network, err := s.GetBitcoinNetwork(arguments.Currency)
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	seed, err := s.mnemonicRepository.NewSeedFromMnemonic(mnemonic, arguments.Language)
+	if err != nil {
+		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	for _, private := range []bool{false, true} {
+		for _, descriptorScript := range descriptorScripts {
+			path := fmt.Sprintf(descriptorScript.path, network.HDCoinType)
+			accountKey, fingerprint, err := s.descriptorRepository.DeriveAccountKey(seed, path, private, network)
+			if err != nil {
+				s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+				return err
+			}
+			if private {
+				s.logger.AddSecrets(accountKey)
+			}
+
+			keyExpression := fmt.Sprintf("[%s%s]%s", fingerprint, strings.TrimPrefix(path, "m"), accountKey)
+			for branch, name := range []string{"Receive", "Change"} {
+				descriptor, err := s.descriptorRepository.AddChecksum(fmt.Sprintf(descriptorScript.script, fmt.Sprintf("%s/%d/*", keyExpression, branch)))
+				if err != nil {
+					s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+					return err
+				}
+				if private {
+					fmt.Printf("Private %s Descriptor: %s\n", name, descriptor)
+				} else {
+					fmt.Printf("%s Descriptor: %s\n", name, descriptor)
+				}
+			}
+		}
+	}
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), err)
+	return err
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	. "swisswallet/constants"
+)
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+// The account keys of the test mnemonic are the ones published with BIP44,
+// BIP49, BIP84 and BIP86, fingerprint 73c5da0a. The checksums are those of
+// AddChecksum, which the BIP380 vectors pin.
+var testMnemonicDescriptors = []string{
+	"Receive Descriptor: pkh([73c5da0a/44'/0'/0']xpub6BosfCnifzxcFwrSzQiqu2DBVTshkCXacvNsWGYJVVhhawA7d4R5WSWGFNbi8Aw6ZRc1brxMyWMzG3DSSSSoekkudhUd9yLb6qx39T9nMdj/0/*)#8w4z8fed",
+	"Change Descriptor: pkh([73c5da0a/44'/0'/0']xpub6BosfCnifzxcFwrSzQiqu2DBVTshkCXacvNsWGYJVVhhawA7d4R5WSWGFNbi8Aw6ZRc1brxMyWMzG3DSSSSoekkudhUd9yLb6qx39T9nMdj/1/*)#k6sr6uf4",
+	"Receive Descriptor: sh(wpkh([73c5da0a/49'/0'/0']xpub6C6nQwHaWbSrzs5tZ1q7m5R9cPK9eYpNMFesiXsYrgc1P8bvLLAet9JfHjYXKjToD8cBRswJXXbbFpXgwsswVPAZzKMa1jUp2kVkGVUaJa7/0/*))#gvfpdstz",
+	"Change Descriptor: sh(wpkh([73c5da0a/49'/0'/0']xpub6C6nQwHaWbSrzs5tZ1q7m5R9cPK9eYpNMFesiXsYrgc1P8bvLLAet9JfHjYXKjToD8cBRswJXXbbFpXgwsswVPAZzKMa1jUp2kVkGVUaJa7/1/*))#ad8h407a",
+	"Receive Descriptor: wpkh([73c5da0a/84'/0'/0']xpub6CatWdiZiodmUeTDp8LT5or8nmbKNcuyvz7WyksVFkKB4RHwCD3XyuvPEbvqAQY3rAPshWcMLoP2fMFMKHPJ4ZeZXYVUhLv1VMrjPC7PW6V/0/*)#wc3n3van",
+	"Change Descriptor: wpkh([73c5da0a/84'/0'/0']xpub6CatWdiZiodmUeTDp8LT5or8nmbKNcuyvz7WyksVFkKB4RHwCD3XyuvPEbvqAQY3rAPshWcMLoP2fMFMKHPJ4ZeZXYVUhLv1VMrjPC7PW6V/1/*)#lv5jvedt",
+	"Receive Descriptor: tr([73c5da0a/86'/0'/0']xpub6BgBgsespWvERF3LHQu6CnqdvfEvtMcQjYrcRzx53QJjSxarj2afYWcLteoGVky7D3UKDP9QyrLprQ3VCECoY49yfdDEHGCtMMj92pReUsQ/0/*)#rg247h69",
+	"Change Descriptor: tr([73c5da0a/86'/0'/0']xpub6BgBgsespWvERF3LHQu6CnqdvfEvtMcQjYrcRzx53QJjSxarj2afYWcLteoGVky7D3UKDP9QyrLprQ3VCECoY49yfdDEHGCtMMj92pReUsQ/1/*)#ju05rz2a",
+	"Private Receive Descriptor: pkh([73c5da0a/44'/0'/0']xprv9xpXFhFpqdQK3TmytPBqXtGSwS3DLjojFhTGht8gwAAii8py5X6pxeBnQ6ehJiyJ6nDjWGJfZ95WxByFXVkDxHXrqu53WCRGypk2ttuqncb/0/*)#h57st0h9",
+	"Private Change Descriptor: pkh([73c5da0a/44'/0'/0']xprv9xpXFhFpqdQK3TmytPBqXtGSwS3DLjojFhTGht8gwAAii8py5X6pxeBnQ6ehJiyJ6nDjWGJfZ95WxByFXVkDxHXrqu53WCRGypk2ttuqncb/1/*)#xqm3k68a",
+	"Private Receive Descriptor: sh(wpkh([73c5da0a/49'/0'/0']xprv9y7S1RkggDtZnP1RSzJ7PwUR4MUfF66Wz2jGv9TwJM52WLGmnnrQLLzBSTi7rNtBk4SGeQHBj5G4CuQvPXSn58BmhvX9vk6YzcMm37VuNYD/0/*))#w0tugtc7",
+	"Private Change Descriptor: sh(wpkh([73c5da0a/49'/0'/0']xprv9y7S1RkggDtZnP1RSzJ7PwUR4MUfF66Wz2jGv9TwJM52WLGmnnrQLLzBSTi7rNtBk4SGeQHBj5G4CuQvPXSn58BmhvX9vk6YzcMm37VuNYD/1/*))#mw92s5dp",
+	"Private Receive Descriptor: wpkh([73c5da0a/84'/0'/0']xprv9ybY78BftS5UGANki6oSifuQEjkpyAC8ZmBvBNTshQnCBcxnefjHS7buPMkkqhcRzmoGZ5bokx7GuyDAiktd5HemohAU4wV1ZPMDRmLpBMm/0/*)#jfrjsuen",
+	"Private Change Descriptor: wpkh([73c5da0a/84'/0'/0']xprv9ybY78BftS5UGANki6oSifuQEjkpyAC8ZmBvBNTshQnCBcxnefjHS7buPMkkqhcRzmoGZ5bokx7GuyDAiktd5HemohAU4wV1ZPMDRmLpBMm/1/*)#raxndfft",
+	"Private Receive Descriptor: tr([73c5da0a/86'/0'/0']xprv9xgqHN7yz9MwCkxsBPN5qetuNdQSUttZNKw1dcYTV4mkaAFiBVGQziHs3NRSWMkCzvgjEe3n9xV8oYywvM8at9yRqyaZVz6TYYhX98VjsUk/0/*)#0z0kya55",
+	"Private Change Descriptor: tr([73c5da0a/86'/0'/0']xprv9xgqHN7yz9MwCkxsBPN5qetuNdQSUttZNKw1dcYTV4mkaAFiBVGQziHs3NRSWMkCzvgjEe3n9xV8oYywvM8at9yRqyaZVz6TYYhX98VjsUk/1/*)#7k2hegyv",
+}
+
+func TestPrintDescriptorsOfTheTestMnemonic(t *testing.T) {
+	s, _, _ := newFakeService()
+	arguments := testArguments("bitcoin", MINIMUM_DIFFICULTY, ENGLISH_LANGUAGE, MNEMONIC_OUTPUT)
+
+	var err error
+	stdout := captureOutput(t, &os.Stdout, func() {
+		err = s.PrintDescriptors(testMnemonic, arguments)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != len(testMnemonicDescriptors) {
+		t.Fatalf("%d descriptors, want %d: %s", len(lines), len(testMnemonicDescriptors), stdout)
+	}
+	for i, line := range lines {
+		if line != testMnemonicDescriptors[i] {
+			t.Errorf("descriptor %d is\n%s\nwant\n%s", i, line, testMnemonicDescriptors[i])
+		}
+	}
+}
+
+func TestCheckDescriptorArguments(t *testing.T) {
+	s, _, _ := newFakeService()
+
+	tests := []struct {
+		currency, output string
+		wantErr          bool
+	}{
+		{"bitcoin", MNEMONIC_OUTPUT, false},
+		{"testnet", MNEMONIC_OUTPUT, false},
+		{"bitcoin", RAW_OUTPUT, true},
+		{"ethereum", MNEMONIC_OUTPUT, true},
+	}
+	for _, test := range tests {
+		arguments := testArguments(test.currency, MINIMUM_DIFFICULTY, ENGLISH_LANGUAGE, test.output)
+		err := s.CheckDescriptorArguments(arguments)
+		if (err != nil) != test.wantErr {
+			t.Errorf("CheckDescriptorArguments(%s, %s) = %v, want error %v", test.currency, test.output, err, test.wantErr)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"swisswallet/logger"
+	"swisswallet/model"
+)
+
+// ReadKeyfile returns the content of -keyfile byte for byte, so a hex secret
+// must be kept exactly as written, trailing newline included.
+func (s *service) ReadKeyfile(arguments model.Arguments) ([]byte, error) {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), arguments)
+
+	keyfile, err := ioutil.ReadFile(arguments.Keyfile)
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return nil, err
+	}
+	if len(keyfile) == 0 {
+		err = errors.New(fmt.Sprintf("Keyfile is empty: %s", arguments.Keyfile))
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return nil, err
+	}
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), logger.SecretBytes(keyfile))
+	return keyfile, nil
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	. "swisswallet/constants"
+	"swisswallet/logger"
+	"swisswallet/model"
+)
+
+func (s *service) CheckKeystoreArguments(arguments model.Arguments) error {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), arguments)
+
+	if arguments.KeystorePasswordIsEmpty() {
+		err := errors.New("Keystore password is required to read or write a keystore")
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	err := s.simpleUtils.CheckIfSupported(arguments.KeystoreKdf, s.simpleUtils.GetSupportedKeystoreKdfs())
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), err)
+	return err
+}
+
+func (s *service) ExportKeystore(privateKey []byte, arguments model.Arguments) error {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), logger.SecretBytes(privateKey), arguments)
+
+	var metadata *model.KeystoreMetadata
+	if !arguments.KeyfileIsEmpty() {
+		metadata = &model.KeystoreMetadata{KeyfileRequired: true, Note: KEYFILE_REQUIRED}
+	}
+	keystoreJSON, err := s.cryptoRepository.EncryptKeystore(privateKey, arguments.KeystorePassword, arguments.KeystoreKdf, metadata)
+	if err != nil {
+		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	if arguments.KeystoreOutIsEmpty() {
+		fmt.Printf("Keystore: %s\n", keystoreJSON)
+	} else {
+		err = ioutil.WriteFile(arguments.KeystoreOut, keystoreJSON, 0600)
+		if err != nil {
+			s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+			return err
+		}
+		fmt.Printf("Keystore written to: %s\n", arguments.KeystoreOut)
+	}
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), err)
+	return err
+}
+
+func (s *service) ImportKeystore(arguments model.Arguments) (string, error) {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), arguments)
+
+	if !arguments.KeyIsEmpty() || !arguments.MnemonicIsEmpty() {
+		err := errors.New("Keystore cannot be combined with a Private Key or Mnemonic")
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return "", err
+	}
+
+	err := s.CheckKeystoreArguments(arguments)
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return "", err
+	}
+
+	keystoreJSON, err := ioutil.ReadFile(arguments.Keystore)
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return "", err
+	}
+
+	privateKey, err := s.cryptoRepository.DecryptKeystore(keystoreJSON, arguments.KeystorePassword)
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return "", err
+	}
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), err)
+	return hex.EncodeToString(privateKey), err
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "swisswallet/constants"
+	"swisswallet/model"
+)
+
+func TestExportKeystoreRecordsKeyfileRequirement(t *testing.T) {
+	s, _, _ := newFakeService()
+	directory, err := ioutil.TempDir("", "keystore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+	privateKey := bytes.Repeat([]byte{0x11}, 32)
+
+	for _, keyfile := range []string{"", filepath.Join(directory, "keyfile")} {
+		arguments := testArguments("ethereum", MINIMUM_DIFFICULTY, ENGLISH_LANGUAGE, KEYSTORE_OUTPUT)
+		arguments.Keyfile = keyfile
+		arguments.KeystorePassword = "keystore password"
+		arguments.KeystoreKdf = PBKDF2_KEYSTORE_KDF
+		arguments.KeystoreOut = filepath.Join(directory, "keystore.json")
+
+		err := s.ExportKeystore(privateKey, arguments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keystoreJSON, err := ioutil.ReadFile(arguments.KeystoreOut)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var keystore model.Keystore
+		err = json.Unmarshal(keystoreJSON, &keystore)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if keyfile == "" && keystore.Metadata != nil {
+			t.Errorf("keystore without keyfile has metadata %+v", keystore.Metadata)
+		}
+		if keyfile != "" && (keystore.Metadata == nil || !keystore.Metadata.KeyfileRequired || keystore.Metadata.Note != KEYFILE_REQUIRED) {
+			t.Errorf("keystore derived with a keyfile has metadata %+v, want the keyfile requirement", keystore.Metadata)
+		}
+
+		decrypted, err := s.cryptoRepository.DecryptKeystore(keystoreJSON, arguments.KeystorePassword)
+		if err != nil || !bytes.Equal(decrypted, privateKey) {
+			t.Errorf("keystore with keyfile %q does not decrypt: %v", keyfile, err)
+		}
+	}
+}
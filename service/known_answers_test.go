@@ -0,0 +1,267 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sort"
+	"testing"
+
+	. "swisswallet/constants"
+	"swisswallet/model"
+)
+
+// Golden wallets for a fixed password and salt. Any change in the derivation
+// pipeline or in the underlying argon2, scrypt or BIP39 libraries shows up as
+// a mismatch against these values. Normal and strong difficulties take minutes
+// and only run with SWISSWALLET_SLOW_TESTS=1; super_strong and
+// ridiculously_strong need more memory than CI has and are covered by
+// TestKdfParamsByDifficulty and TestDerivationGuard instead.
+const KNOWN_ANSWER_PASSWORD string = "correct horse battery staple"
+const KNOWN_ANSWER_SALT string = "satoshi@example.com"
+
+// SLOW_TESTS_VARIABLE enables the known answers of the normal and strong
+// difficulties, for example before a release:
+//
+//	SWISSWALLET_SLOW_TESTS=1 go test -timeout 30m ./service/
+const SLOW_TESTS_VARIABLE string = "SWISSWALLET_SLOW_TESTS"
+
+type knownAnswer struct {
+	currency   string
+	difficulty string
+	language   string
+	output     string
+	address    string
+	privateKey string
+	mnemonic   string
+}
+
+func (k *knownAnswer) GetArguments() model.Arguments {
+	return testArguments(k.currency, k.difficulty, k.language, k.output)
+}
+
+var knownAnswers = []knownAnswer{
+	{currency: "testnet", difficulty: MINIMUM_DIFFICULTY, language: ENGLISH_LANGUAGE, output: RAW_OUTPUT,
+		address:    "0xff1e1077A6b39b42E661921Fdc5F25a68EbAAB69",
+		privateKey: "0189b2558b07e1f70c1bad39b85e0f687d7eeb13961ba5c1d233b64515517dda"},
+	{currency: "testnet", difficulty: MINIMUM_DIFFICULTY, language: ENGLISH_LANGUAGE, output: MNEMONIC_OUTPUT,
+		address:  "0xde66E7fA48610C84fEACA273469e496D5Ce17D68",
+		mnemonic: "account eternal nice bid lawn wine corn interest defy seed long special subject intact exact sell place attend mind hold eyebrow post wash emerge"},
+	{currency: "bitcoin", difficulty: MINIMUM_DIFFICULTY, language: ENGLISH_LANGUAGE, output: RAW_OUTPUT,
+		address:    "0xA52b641B119B4e0cAe059E0d4858aA7C895F5a0F",
+		privateKey: "f8dc50cc867de2671982f913f0aaf460de552e67497cea7754af370c517c6a3e"},
+	{currency: "bitcoin", difficulty: MINIMUM_DIFFICULTY, language: ENGLISH_LANGUAGE, output: MNEMONIC_OUTPUT,
+		address:  "0x69FfB3327d32aA4622f3f77858cB62B02824ABDc",
+		mnemonic: "web tip creek artefact taste crime gravity game become luxury rug script torch now outdoor convince tuna rival cloth host shallow sail pottery taxi"},
+	{currency: "ethereum", difficulty: MINIMUM_DIFFICULTY, language: ENGLISH_LANGUAGE, output: RAW_OUTPUT,
+		address:    "0x25650494562eb8c781b9bF9Fd543cCA60f122998",
+		privateKey: "6e945706a369c6eb2e4d080a9cd9c83e2759c3c86670431f05ac27b6a3fec0cb"},
+	{currency: "ethereum", difficulty: MINIMUM_DIFFICULTY, language: ENGLISH_LANGUAGE, output: MNEMONIC_OUTPUT,
+		address:  "0xbF4c36026A1F33C8d249a03a25af70f0C26116Ae",
+		mnemonic: "hub pencil script egg organ intact rice patient appear traffic improve labor intact tiger canoe sock drink wealth help exhaust health youth add gorilla"},
+	{currency: "litecoin", difficulty: MINIMUM_DIFFICULTY, language: ENGLISH_LANGUAGE, output: RAW_OUTPUT,
+		address:    "0x249327CbD4D2A731f77f538e95E0255E5E6673Cc",
+		privateKey: "306183ec7be7c400fa27573f88295737576e6ecd0ce22e2a25b3fcb2d56abd23"},
+	{currency: "litecoin", difficulty: MINIMUM_DIFFICULTY, language: ENGLISH_LANGUAGE, output: MNEMONIC_OUTPUT,
+		address:  "0x14003E5Ec600E6De400e5c8a5F06DfF300831910",
+		mnemonic: "corn army wild water labor about trigger turtle display donor fiction huge ivory danger crouch ordinary comfort eye hollow west coil relief virus job"},
+	{currency: "monero", difficulty: MINIMUM_DIFFICULTY, language: ENGLISH_LANGUAGE, output: RAW_OUTPUT,
+		address:    "0xdF1100e6008bA5A4c6Da4E1573279D8fB163d87c",
+		privateKey: "aa7082af781f6d556ade727f95a549c1070268c8614b77e172204575af827c7c"},
+	{currency: "monero", difficulty: MINIMUM_DIFFICULTY, language: ENGLISH_LANGUAGE, output: MNEMONIC_OUTPUT,
+		address:  "0x5Aec9BD67C614509A482245795424085Ec742Dfa",
+		mnemonic: "price lottery profit usual walnut primary problem soft legend pudding fame link ice crucial canoe city jewel argue marine memory foot scorpion vehicle margin"},
+	{currency: "cosmos", difficulty: MINIMUM_DIFFICULTY, language: ENGLISH_LANGUAGE, output: RAW_OUTPUT,
+		address:    "0x5aA948bCFe540B07E50250e19368FED4219fa2E9",
+		privateKey: "318529a697f649b2862c3bdd68ded01836b890bc035b0f7a02711fee511090aa"},
+	{currency: "cosmos", difficulty: MINIMUM_DIFFICULTY, language: ENGLISH_LANGUAGE, output: MNEMONIC_OUTPUT,
+		address:  "0x4F7472713F3D7D2cfe22d077A36D88fd9a82B217",
+		mnemonic: "cover citizen have copper goose sun board manual talent eight reduce corn high embark useless hidden author source ordinary divert topic marriage dress fantasy"},
+	{currency: "polkadot", difficulty: MINIMUM_DIFFICULTY, language: ENGLISH_LANGUAGE, output: RAW_OUTPUT,
+		address:    "0x1B24a2E63414060E62b1418fc769C0888e6F151d",
+		privateKey: "42b117754d2c0d6988fb3f944b841caa473e21e22a7822bc8bc414ce9d20897b"},
+	{currency: "polkadot", difficulty: MINIMUM_DIFFICULTY, language: ENGLISH_LANGUAGE, output: MNEMONIC_OUTPUT,
+		address:  "0xC68a817e8aA11e6Eecd82bAaFB12e7fD012e2b06",
+		mnemonic: "dress master tail olympic school regular catalog gun nephew found already fee initial axis master excuse cargo tone valve civil truth motion chalk involve"},
+	{currency: "ethereum", difficulty: MINIMUM_DIFFICULTY, language: SPANISH_LANGUAGE, output: MNEMONIC_OUTPUT,
+		address:  "0x5Cd52f76EE74e834E112Fc7b48987B576FD2fDAB",
+		mnemonic: "huelga olla rápido dosis novela jarra poseer oeste altura tiempo impulso leal jarra tarjeta botín rubor diamante ver helado escudo hamaca zarza acto golfo"},
+	{currency: "ethereum", difficulty: MINIMUM_DIFFICULTY, language: CHINESE_TRADITIONAL_LANGUAGE, output: MNEMONIC_OUTPUT,
+		address:  "0xe7AE9F140475823Ae5a2E386979920b38400FE20",
+		mnemonic: "倍 秘 忍 葉 寸 屋 曉 氨 物 坯 憲 稅 屋 緯 北 譯 落 湘 沉 吸 豐 吞 到 茶"},
+	{currency: "ethereum", difficulty: MINIMUM_DIFFICULTY, language: CHINESE_SIMPLIFIED_LANGUAGE, output: MNEMONIC_OUTPUT,
+		address:  "0x75e34123995C27E1AaeFE9F64E84BA2E890FF123",
+		mnemonic: "倍 秘 忍 叶 寸 屋 晓 氨 物 坯 宪 税 屋 纬 北 译 落 湘 沉 吸 丰 吞 到 茶"},
+	{currency: "ethereum", difficulty: MINIMUM_DIFFICULTY, language: CZECH_LANGUAGE, output: MNEMONIC_OUTPUT,
+		address:  "0x4f1055FEE9FF60542b8C6cde173aa0ec78c19da0",
+		mnemonic: "mozol poskok sloupek kapybara podoba nastat schovat popisek bodlina vodivost nahodile nutnost nastat veletrh dorazit synek jurta zeptat mlok konfese minulost zvesela autobus makak"},
+	{currency: "ethereum", difficulty: MINIMUM_DIFFICULTY, language: FRENCH_LANGUAGE, output: MNEMONIC_OUTPUT,
+		address:  "0xA60be38bDea5Eb1072cABfbcf9485985DF881C91",
+		mnemonic: "furieux nation priver diminuer minéral grenat pierre musicien alourdir tailler gibier housse grenat sphère bougie rester déphaser vénérer foudre égarer fongible yacht accroche femme"},
+	{currency: "ethereum", difficulty: MINIMUM_DIFFICULTY, language: ITALIAN_LANGUAGE, output: MNEMONIC_OUTPUT,
+		address:  "0xeBFeB9c74A8f6963BA07F886dAaF57b58Df99bdF",
+		mnemonic: "laddove pizzico saziato epatite pensare luminoso ristoro pillola ammenda tipografo lido melis luminoso tale bruno sfuggito egemonia verticale intonaco febbre insano zotico adottare ignorato"},
+	{currency: "ethereum", difficulty: MINIMUM_DIFFICULTY, language: JAPANESE_LANGUAGE, output: MNEMONIC_OUTPUT,
+		address:  "0x6570B33Abbf96F2E191814363a6CEc49206DFa4f",
+		mnemonic: "すおどり てんいん はくしゅ げぼく てあて せっきゃく ねぼう てぶくろ いそがしい みんか すまい せんろ せっきゃく まもる おくじょう ひほう けしき りこう しゅみ こける しゃりん わしつ あてな じてん"},
+	{currency: "ethereum", difficulty: MINIMUM_DIFFICULTY, language: KOREAN_LANGUAGE, output: MNEMONIC_OUTPUT,
+		address:  "0x6F62d00Df91d5EbD8E0fa5A44CD055d9eb34311F",
+		mnemonic: "속옷 은행 조명 반대 우선 스물 전라도 유럽 결혼 팝송 수석 식생활 스물 테스트 기적 집단 물체 형편 세상 베이징 성인 희곡 간판 생물"},
+	{currency: "ethereum", difficulty: MINIMUM_DIFFICULTY, language: ENGLISH_LANGUAGE, output: KEYSTORE_OUTPUT,
+		address:    "0x25650494562eb8c781b9bF9Fd543cCA60f122998",
+		privateKey: "6e945706a369c6eb2e4d080a9cd9c83e2759c3c86670431f05ac27b6a3fec0cb"},
+	{currency: "ethereum", difficulty: LOW_DIFFICULTY, language: ENGLISH_LANGUAGE, output: RAW_OUTPUT,
+		address:    "0x3d00a900A4E97491dcdE6e5C4B1803bE73714ACA",
+		privateKey: "e7c7cf645268663667c9dfee109e837bd4e89526a620af984ba06f159b0d67f5"},
+	{currency: "ethereum", difficulty: LOW_DIFFICULTY, language: ENGLISH_LANGUAGE, output: MNEMONIC_OUTPUT,
+		address:  "0xf8D2eC2cFe49cd57a3a6fcF845cF33f9C9BdAb28",
+		mnemonic: "treat dinosaur suit pill major brass palace desk unlock lumber patch waste excess enhance once series question seat trend taste fluid mammal panther peasant"},
+	{currency: "ethereum", difficulty: NORMAL_DIFFICULTY, language: ENGLISH_LANGUAGE, output: RAW_OUTPUT,
+		address:    "0x9D82C6514fb1D586A3f9121C862854f4d46F41D1",
+		privateKey: "37abdfb399b907090b1cc8419ac4222f56bbe9ebf6e2e09f1ceede8a9024ccde"},
+	{currency: "ethereum", difficulty: NORMAL_DIFFICULTY, language: ENGLISH_LANGUAGE, output: MNEMONIC_OUTPUT,
+		address:  "0x4c3EE04fD047Dd07A4b44C1D07781b8737Cb9f62",
+		mnemonic: "dash galaxy undo cricket motor loyal cluster october dose stomach ancient gadget hill visual quit sword they wedding desert rug poverty bar cricket swear"},
+	{currency: "ethereum", difficulty: STRONG_DIFFICULTY, language: ENGLISH_LANGUAGE, output: RAW_OUTPUT,
+		address:    "0x655543FCe3518559714Ebed961080780cb89b0C2",
+		privateKey: "62ac331a76cb6318b1bd7f55714b7a8df49b3b5afd6c4768d77eeab9371fbe38"},
+	{currency: "ethereum", difficulty: STRONG_DIFFICULTY, language: ENGLISH_LANGUAGE, output: MNEMONIC_OUTPUT,
+		address:  "0x08F3abC565f6672BabB871CacB39115D63d61c0C",
+		mnemonic: "glance ghost shoot unique renew middle shoulder quiz fever medal team brief endless oval hip pulse electric effort text step nature more wedding away"},
+}
+
+func TestKnownAnswers(t *testing.T) {
+	s := newTestService(nil, nil)
+
+	for _, knownAnswer := range knownAnswers {
+		knownAnswer := knownAnswer
+		name := fmt.Sprintf("%s/%s/%s/%s", knownAnswer.currency, knownAnswer.difficulty, knownAnswer.language, knownAnswer.output)
+		t.Run(name, func(t *testing.T) {
+			slow := knownAnswer.difficulty != MINIMUM_DIFFICULTY && knownAnswer.difficulty != LOW_DIFFICULTY
+			if slow && (testing.Short() || os.Getenv(SLOW_TESTS_VARIABLE) != "1") {
+				t.Skipf("skipping slow difficulty, set %s=1 to run it", SLOW_TESTS_VARIABLE)
+			}
+
+			wallet, err := s.DeriveWallet(knownAnswer.GetArguments())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if wallet.Address != knownAnswer.address {
+				t.Errorf("address = %s, want %s", wallet.Address, knownAnswer.address)
+			}
+			if knownAnswer.output == MNEMONIC_OUTPUT {
+				if wallet.Mnemonic != knownAnswer.mnemonic {
+					t.Errorf("mnemonic = %q, want %q", wallet.Mnemonic, knownAnswer.mnemonic)
+				}
+			} else if hex.EncodeToString(wallet.PrivateKey) != knownAnswer.privateKey {
+				t.Errorf("private key = %x, want %s", wallet.PrivateKey, knownAnswer.privateKey)
+			}
+		})
+	}
+}
+
+func TestKnownAnswersCoverEverything(t *testing.T) {
+	s := newTestService(nil, nil)
+
+	covered := map[string]bool{}
+	for _, knownAnswer := range knownAnswers {
+		covered[knownAnswer.currency] = true
+		covered[knownAnswer.difficulty] = true
+		covered[knownAnswer.language] = true
+		covered[knownAnswer.output] = true
+	}
+
+	var expected []string
+	for currency := range CurrencyCode {
+		if currency != "unknown" {
+			expected = append(expected, currency)
+		}
+	}
+	expected = append(expected, s.simpleUtils.GetSupportedLanguages()...)
+	expected = append(expected, s.simpleUtils.GetSupportedOutputs()...)
+	for _, difficulty := range s.simpleUtils.GetSupportedDifficulties() {
+		if difficulty != SUPER_STRONG_DIFFICULTY && difficulty != RIDICULOUSLY_STRONG_DIFFICULTY {
+			expected = append(expected, difficulty)
+		}
+	}
+
+	for _, value := range expected {
+		if !covered[value] {
+			t.Errorf("no known answer for %s", value)
+		}
+	}
+}
+
+// The KDF parameters are part of the wallet: changing any of them changes
+// every address derived at that difficulty.
+func TestKdfParamsByDifficulty(t *testing.T) {
+	s := newTestService(nil, nil)
+
+	expected := map[string]string{
+		MINIMUM_DIFFICULTY:             "argon2id t=4 m=262144 p=4 scrypt N=262144 r=8 p=1",
+		LOW_DIFFICULTY:                 "argon2id t=8 m=524288 p=4 scrypt N=524288 r=8 p=1",
+		NORMAL_DIFFICULTY:              "argon2id t=16 m=1048576 p=4 scrypt N=1048576 r=8 p=1",
+		STRONG_DIFFICULTY:              "argon2id t=32 m=2097152 p=4 scrypt N=2097152 r=8 p=1",
+		SUPER_STRONG_DIFFICULTY:        "argon2id t=64 m=4194304 p=4 scrypt N=4194304 r=8 p=1",
+		RIDICULOUSLY_STRONG_DIFFICULTY: "argon2id t=128 m=8388608 p=4 scrypt N=8388608 r=8 p=1",
+	}
+
+	for _, difficulty := range s.simpleUtils.GetSupportedDifficulties() {
+		time, memory, threads, argon2KeyLen, err := s.cryptoRepository.GetArgon2ParamsByDifficulty(difficulty)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n, r, p, scryptKeyLen, err := s.cryptoRepository.GetScryptParamsByDifficulty(difficulty)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		params := fmt.Sprintf("argon2id t=%d m=%d p=%d scrypt N=%d r=%d p=%d", time, memory, threads, n, r, p)
+		if params != expected[difficulty] {
+			t.Errorf("%s: %s, want %s", difficulty, params, expected[difficulty])
+		}
+		if argon2KeyLen != 32 || scryptKeyLen != 32 {
+			t.Errorf("%s: key lengths %d and %d, want 32", difficulty, argon2KeyLen, scryptKeyLen)
+		}
+	}
+}
+
+// Only update after confirming that a derivation change is intended, since
+// every existing wallet changes with it.
+const DERIVATION_GUARD_DIGEST string = "36695377443e27a9f7460a9ba26b698c77b676d9be28c5bc58a9b7b3cee3a7ff"
+
+// TestDerivationGuard runs every currency, difficulty, language and output
+// through the service with the KDFs faked out and pins a digest of all the
+// resulting wallets. It fails on any change in how passwords and salts are
+// built, how the AES step is applied or how wallets are encoded.
+func TestDerivationGuard(t *testing.T) {
+	s, _, _ := newFakeService()
+
+	var currencies []string
+	for currency := range CurrencyCode {
+		if currency != "unknown" {
+			currencies = append(currencies, currency)
+		}
+	}
+	sort.Strings(currencies)
+
+	digest := sha256.New()
+	for _, currency := range currencies {
+		for _, difficulty := range s.simpleUtils.GetSupportedDifficulties() {
+			for _, language := range s.simpleUtils.GetSupportedLanguages() {
+				for _, output := range s.simpleUtils.GetSupportedOutputs() {
+					wallet, err := s.DeriveWallet(testArguments(currency, difficulty, language, output))
+					if err != nil {
+						t.Fatalf("%s/%s/%s/%s: %v", currency, difficulty, language, output, err)
+					}
+					fmt.Fprintf(digest, "%s %s %s %s %s %x %s\n", currency, difficulty, language, output, wallet.Address, wallet.PrivateKey, wallet.Mnemonic)
+				}
+			}
+		}
+	}
+
+	if sum := hex.EncodeToString(digest.Sum(nil)); sum != DERIVATION_GUARD_DIGEST {
+		t.Errorf("derivation digest = %s, want %s", sum, DERIVATION_GUARD_DIGEST)
+	}
+}
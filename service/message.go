@@ -0,0 +1,214 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	. "swisswallet/constants"
+	"swisswallet/model"
+)
+
+// Receive account paths of the Bitcoin address types messages are signed with.
+var messageDerivationPaths = map[string]string{
+	P2PKH_ADDRESS_TYPE:       BIP44_DERIVATION_PATH,
+	P2SH_P2WPKH_ADDRESS_TYPE: BIP49_DERIVATION_PATH,
+	P2WPKH_ADDRESS_TYPE:      BIP84_DERIVATION_PATH,
+	P2TR_ADDRESS_TYPE:        BIP86_DERIVATION_PATH,
+}
+
+// ReadMessage returns the message from -message, -message-file or
+// -typed-data, and whether it is EIP-712 typed data.
+func (s *service) ReadMessage(arguments model.Arguments) ([]byte, bool, error) {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), arguments)
+
+	given := 0
+	for _, empty := range []bool{arguments.MessageIsEmpty(), arguments.MessageFileIsEmpty(), arguments.TypedDataIsEmpty()} {
+		if !empty {
+			given++
+		}
+	}
+	if given != 1 {
+		err := errors.New("Exactly one of -message, -message-file or -typed-data is required")
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return nil, false, err
+	}
+
+	typed := !arguments.TypedDataIsEmpty()
+	if typed && arguments.Currency != "ethereum" {
+		err := errors.New("EIP-712 typed data can only be signed with ethereum")
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return nil, false, err
+	}
+
+	if !arguments.MessageIsEmpty() {
+		s.logger.LogOnExitWithContext(s.logger.GetContext(), len(arguments.Message))
+		return []byte(arguments.Message), false, nil
+	}
+	file := arguments.MessageFile
+	if typed {
+		file = arguments.TypedData
+	}
+	message, err := ioutil.ReadFile(file)
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return nil, false, err
+	}
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), len(message), typed)
+	return message, typed, nil
+}
+
+// SignMessage proves ownership of an address regenerated from the password and
+// salt: EIP-191 or EIP-712 for ethereum, and signmessage or BIP322 for the
+// receive address of -address-type and -address-index for bitcoin.
+func (s *service) SignMessage(arguments model.Arguments) error {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), arguments)
+
+	message, typed, err := s.ReadMessage(arguments)
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	var address, signature string
+	if arguments.Currency == "ethereum" {
+		address, signature, err = s.SignEthereumMessage(message, typed, arguments)
+	} else {
+		address, signature, err = s.SignBitcoinMessage(message, arguments)
+	}
+	if err != nil {
+		s.logger.LogOnErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+	if !arguments.AddressIsEmpty() && !strings.EqualFold(arguments.Address, address) {
+		err = errors.New(fmt.Sprintf("Password and salt derive %s, not %s", address, arguments.Address))
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	fmt.Printf("Address: %s\n", address)
+	fmt.Printf("Signature: %s\n", signature)
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), address, err)
+	return err
+}
+
+func (s *service) SignEthereumMessage(message []byte, typed bool, arguments model.Arguments) (string, string, error) {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), len(message), typed, arguments)
+
+	err := s.CheckEthereumAddressArgument(arguments)
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return "", "", err
+	}
+	wallet, err := s.DeriveWallet(arguments)
+	if err != nil {
+		s.logger.LogOnErrorWithContext(s.logger.GetContext(), err)
+		return "", "", err
+	}
+	privateKey, err := s.GetEthereumPrivateKey(wallet, arguments)
+	if err != nil {
+		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+		return "", "", err
+	}
+	signature, err := s.messageRepository.SignEthereumMessage(privateKey, message, typed)
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return "", "", err
+	}
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), wallet.Address, signature)
+	return wallet.Address, signature, nil
+}
+
+func (s *service) SignBitcoinMessage(message []byte, arguments model.Arguments) (string, string, error) {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), len(message), arguments)
+
+	network, err := s.GetBitcoinNetwork(arguments.Currency)
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return "", "", err
+	}
+	err = s.simpleUtils.CheckIfSupported(arguments.AddressType, s.simpleUtils.GetSupportedAddressTypes())
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return "", "", err
+	}
+	if arguments.Output != MNEMONIC_OUTPUT {
+		err = errors.New("Bitcoin messages can only be signed from mnemonic output")
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return "", "", err
+	}
+	if arguments.AddressIndex < 0 {
+		err = errors.New("Address index must not be negative")
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return "", "", err
+	}
+
+	wallet, err := s.DeriveWallet(arguments)
+	if err != nil {
+		s.logger.LogOnErrorWithContext(s.logger.GetContext(), err)
+		return "", "", err
+	}
+	seed, err := s.mnemonicRepository.NewSeedFromMnemonic(wallet.Mnemonic, arguments.Language)
+	if err != nil {
+		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+		return "", "", err
+	}
+	path := fmt.Sprintf(messageDerivationPaths[arguments.AddressType], network.HDCoinType) + fmt.Sprintf("/0/%d", arguments.AddressIndex)
+	privateKey, err := s.descriptorRepository.DerivePrivateKey(seed, path, network)
+	if err != nil {
+		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+		return "", "", err
+	}
+	address, err := s.messageRepository.GetBitcoinAddress(privateKey, arguments.AddressType, network)
+	if err != nil {
+		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+		return "", "", err
+	}
+	signature, err := s.messageRepository.SignBitcoinMessage(privateKey, arguments.AddressType, message, network)
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return "", "", err
+	}
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), path, address, signature)
+	return address, signature, nil
+}
+
+// VerifyMessage checks a signature against the address and message alone, no
+// password or salt is needed.
+func (s *service) VerifyMessage(arguments model.Arguments) error {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), arguments)
+
+	if arguments.AddressIsEmpty() || arguments.SignatureIsEmpty() {
+		err := errors.New("Both -a and -signature are required to verify a message")
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+	message, typed, err := s.ReadMessage(arguments)
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	if arguments.Currency == "ethereum" {
+		err = s.messageRepository.VerifyEthereumMessage(arguments.Address, message, typed, arguments.Signature)
+	} else {
+		network, networkErr := s.GetBitcoinNetwork(arguments.Currency)
+		if networkErr != nil {
+			s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), networkErr)
+			return networkErr
+		}
+		err = s.messageRepository.VerifyBitcoinMessage(arguments.Address, message, arguments.Signature, network)
+	}
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+	fmt.Println(VERIFY_MATCH)
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), err)
+	return err
+}
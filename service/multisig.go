@@ -0,0 +1,115 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	. "swisswallet/constants"
+	"swisswallet/model"
+)
+
+// MultisigWallet rebuilds a wsh(sortedmulti()) wallet from the memorized
+// passwords of its cosigners. Each cosigner key is the BIP48 P2WSH account of
+// the mnemonic that generate prints for that password and salt.
+func (s *service) MultisigWallet(arguments model.Arguments) error {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), arguments)
+
+	network, err := s.GetBitcoinNetwork(arguments.Currency)
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	if arguments.MultisigCosigners < 1 || arguments.MultisigCosigners > MULTISIG_MAX_COSIGNERS || arguments.MultisigThreshold < 1 || arguments.MultisigThreshold > arguments.MultisigCosigners {
+		err = errors.New(fmt.Sprintf("Multisig needs 1 to %d cosigners and a threshold between 1 and the number of cosigners", MULTISIG_MAX_COSIGNERS))
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	if arguments.AddressCount < 0 {
+		err = errors.New("Address count cannot be negative")
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	path := fmt.Sprintf(MULTISIG_DERIVATION_PATH, network.HDCoinType)
+	keyOrigin := strings.TrimPrefix(path, "m")
+	accountKeys := make([]string, arguments.MultisigCosigners)
+	keyExpressions := make([]string, arguments.MultisigCosigners)
+	for i := range accountKeys {
+		cosignerArguments := arguments
+		cosignerArguments.Output = MNEMONIC_OUTPUT
+		cosignerArguments.Password, err = s.simpleUtils.ReadSecret(fmt.Sprintf("Cosigner %d password: ", i+1))
+		if err != nil {
+			s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+			return err
+		}
+		s.logger.AddSecrets(cosignerArguments.Password)
+		cosignerArguments.Salt, err = s.simpleUtils.ReadSecret(fmt.Sprintf("Cosigner %d salt: ", i+1))
+		if err != nil {
+			s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+			return err
+		}
+		s.logger.AddSecrets(cosignerArguments.Salt)
+
+		if cosignerArguments.PasswordIsEmpry() {
+			err = errors.New(fmt.Sprintf("Cosigner %d password is empty", i+1))
+			s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+			return err
+		}
+
+		wallet, err := s.DeriveWallet(cosignerArguments)
+		if err != nil {
+			s.logger.LogOnErrorWithContext(s.logger.GetContext(), err)
+			return err
+		}
+		seed, err := s.mnemonicRepository.NewSeedFromMnemonic(wallet.Mnemonic, cosignerArguments.Language)
+		if err != nil {
+			s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+			return err
+		}
+		accountKey, fingerprint, err := s.descriptorRepository.DeriveAccountKey(seed, path, false, network)
+		if err != nil {
+			s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+			return err
+		}
+
+		for j := 0; j < i; j++ {
+			if accountKeys[j] == accountKey {
+				err = errors.New(fmt.Sprintf("Cosigners %d and %d derived the same key, every cosigner needs their own password", j+1, i+1))
+				s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+				return err
+			}
+		}
+		accountKeys[i] = accountKey
+		keyExpressions[i] = fmt.Sprintf("[%s%s]%s", fingerprint, keyOrigin, accountKey)
+	}
+
+	for i, keyExpression := range keyExpressions {
+		fmt.Printf("Cosigner %d: %s\n", i+1, keyExpression)
+	}
+	for branch, name := range []string{"Receive", "Change"} {
+		branchExpressions := make([]string, len(keyExpressions))
+		for i, keyExpression := range keyExpressions {
+			branchExpressions[i] = fmt.Sprintf("%s/%d/*", keyExpression, branch)
+		}
+		descriptor, err := s.descriptorRepository.AddChecksum(fmt.Sprintf("wsh(sortedmulti(%d,%s))", arguments.MultisigThreshold, strings.Join(branchExpressions, ",")))
+		if err != nil {
+			s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+			return err
+		}
+		fmt.Printf("%s Descriptor: %s\n", name, descriptor)
+	}
+
+	addresses, err := s.descriptorRepository.GetSortedMultisigAddresses(arguments.MultisigThreshold, accountKeys, 0, arguments.AddressCount, network)
+	if err != nil {
+		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+	for index, address := range addresses {
+		fmt.Printf("Receive Address %d: %s\n", index, address)
+	}
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), err)
+	return err
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	. "swisswallet/constants"
+)
+
+var testCosigners = [][2]string{
+	{"first cosigner password", "alice@example.com"},
+	{"second cosigner password", "bob@example.com"},
+	{"third cosigner password", "carol@example.com"},
+}
+
+func runMultisigWallet(t *testing.T, cosigners [][2]string, threshold int) (string, error) {
+	s, _, fakeUtils := newFakeService()
+	for _, cosigner := range cosigners {
+		fakeUtils.secrets = append(fakeUtils.secrets, cosigner[0], cosigner[1])
+	}
+	arguments := testArguments("bitcoin", MINIMUM_DIFFICULTY, ENGLISH_LANGUAGE, RAW_OUTPUT)
+	arguments.Password, arguments.Salt = "", ""
+	arguments.MultisigCosigners, arguments.MultisigThreshold, arguments.AddressCount = len(cosigners), threshold, 3
+
+	var err error
+	stdout := captureOutput(t, &os.Stdout, func() {
+		err = s.MultisigWallet(arguments)
+	})
+	if len(fakeUtils.secrets) != 0 && err == nil {
+		t.Errorf("%d secrets were not read", len(fakeUtils.secrets))
+	}
+	return stdout, err
+}
+
+func outputLines(stdout string, prefix string) []string {
+	var values []string
+	for _, line := range strings.Split(stdout, "\n") {
+		if strings.HasPrefix(line, prefix) {
+			values = append(values, line[strings.Index(line, ": ")+2:])
+		}
+	}
+	return values
+}
+
+// Known answers of testCosigners under the fake KDF.
+const (
+	testMultisigReceiveChecksum = "98m86m2v"
+	testMultisigChangeChecksum  = "qjsaya6y"
+	testMultisigFirstAddress    = "bc1qly79qh9zkmnlju9873lf3tn3vzqnwf7fxx07ra8tehmu8jndgvksgfu0zw"
+)
+
+// The descriptor is also rebuilt from the cosigner wallets, and the addresses
+// from the repository, whose sortedmulti addresses are pinned to the BIP383
+// vectors.
+func TestMultisigWalletDescriptorAndAddresses(t *testing.T) {
+	s, _, _ := newFakeService()
+	network, _ := s.GetBitcoinNetwork("bitcoin")
+	path := fmt.Sprintf(MULTISIG_DERIVATION_PATH, network.HDCoinType)
+
+	var accountKeys, branchExpressions []string
+	for _, cosigner := range testCosigners {
+		arguments := testArguments("bitcoin", MINIMUM_DIFFICULTY, ENGLISH_LANGUAGE, MNEMONIC_OUTPUT)
+		arguments.Password, arguments.Salt = cosigner[0], cosigner[1]
+		wallet, err := s.DeriveWallet(arguments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seed, _ := s.mnemonicRepository.NewSeedFromMnemonic(wallet.Mnemonic, ENGLISH_LANGUAGE)
+		accountKey, fingerprint, err := s.descriptorRepository.DeriveAccountKey(seed, path, false, network)
+		if err != nil {
+			t.Fatal(err)
+		}
+		accountKeys = append(accountKeys, accountKey)
+		branchExpressions = append(branchExpressions, fmt.Sprintf("[%s/48'/0'/0'/2']%s/0/*", fingerprint, accountKey))
+	}
+	descriptor, _ := s.descriptorRepository.AddChecksum("wsh(sortedmulti(2," + strings.Join(branchExpressions, ",") + "))")
+	addresses, _ := s.descriptorRepository.GetSortedMultisigAddresses(2, accountKeys, 0, 3, network)
+
+	stdout, err := runMultisigWallet(t, testCosigners, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	receiveDescriptors := outputLines(stdout, "Receive Descriptor: ")
+	if len(receiveDescriptors) != 1 || receiveDescriptors[0] != descriptor {
+		t.Errorf("receive descriptor %v, want %s", receiveDescriptors, descriptor)
+	}
+	changeDescriptors := outputLines(stdout, "Change Descriptor: ")
+	wantChange := strings.Replace(strings.Split(descriptor, "#")[0], "/0/*", "/1/*", -1)
+	if len(changeDescriptors) != 1 || strings.Split(changeDescriptors[0], "#")[0] != wantChange {
+		t.Errorf("change descriptor %v, want %s", changeDescriptors, wantChange)
+	}
+	receiveAddresses := outputLines(stdout, "Receive Address ")
+	if strings.Join(receiveAddresses, " ") != strings.Join(addresses, " ") {
+		t.Errorf("receive addresses %v, want %v", receiveAddresses, addresses)
+	}
+	if !strings.HasSuffix(descriptor, "#"+testMultisigReceiveChecksum) || len(changeDescriptors) != 1 || !strings.HasSuffix(changeDescriptors[0], "#"+testMultisigChangeChecksum) || addresses[0] != testMultisigFirstAddress {
+		t.Errorf("known answers changed: %s, %v, %s", descriptor, changeDescriptors, addresses[0])
+	}
+
+	reversed := [][2]string{testCosigners[2], testCosigners[1], testCosigners[0]}
+	stdout, err = runMultisigWallet(t, reversed, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(outputLines(stdout, "Receive Address "), " ") != strings.Join(addresses, " ") {
+		t.Errorf("cosigners in another order give the addresses %v, want %v", outputLines(stdout, "Receive Address "), addresses)
+	}
+}
+
+func TestMultisigWalletRejects(t *testing.T) {
+	tests := []struct {
+		name      string
+		cosigners [][2]string
+		threshold int
+		wantErr   string
+	}{
+		{"threshold above cosigners", testCosigners, 4, "threshold between 1 and the number of cosigners"},
+		{"zero threshold", testCosigners, 0, "threshold between 1 and the number of cosigners"},
+		{"same password twice", [][2]string{testCosigners[0], testCosigners[1], testCosigners[0]}, 2, "Cosigners 1 and 3 derived the same key"},
+		{"empty password", [][2]string{testCosigners[0], {"", "bob@example.com"}}, 1, "Cosigner 2 password is empty"},
+	}
+	for _, test := range tests {
+		stdout, err := runMultisigWallet(t, test.cosigners, test.threshold)
+		if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+			t.Errorf("%s: %v, want %q", test.name, err, test.wantErr)
+		}
+		if strings.Contains(stdout, "Descriptor") {
+			t.Errorf("%s: printed a descriptor: %s", test.name, stdout)
+		}
+	}
+}
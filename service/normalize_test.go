@@ -0,0 +1,96 @@
+package service
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	. "swisswallet/constants"
+	"swisswallet/model"
+)
+
+func TestCheckWalletArgumentsWarnsOnStderrWhenNormalizationChangesInput(t *testing.T) {
+	s, _, _ := newFakeService()
+
+	tests := []struct {
+		password, salt string
+		noNormalize    bool
+		want           []string
+	}{
+		{"correct horse battery staple", "satoshi@example.com", false, nil},
+		{"contraseňa", "satoshi@example.com", false, []string{"password"}},
+		{"correct horse battery staple", "josé@example.com", false, []string{"salt"}},
+		{"contraseňa", "josé@example.com", true, nil},
+	}
+	for _, test := range tests {
+		arguments := testArguments("ethereum", MINIMUM_DIFFICULTY, ENGLISH_LANGUAGE, RAW_OUTPUT)
+		arguments.Password, arguments.Salt, arguments.NoNormalize = test.password, test.salt, test.noNormalize
+		var err error
+		stderr := captureOutput(t, &os.Stderr, func() {
+			_, err = s.CheckWalletArguments(arguments)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, input := range []string{"password", "salt"} {
+			warning := strings.Replace(NORMALIZED_WARNING, "%s", input, 1)
+			if strings.Contains(stderr, warning) != containsString(test.want, input) {
+				t.Errorf("%q, %q, no normalize %v: stderr %q, want warnings for %v", test.password, test.salt, test.noNormalize, stderr, test.want)
+			}
+		}
+	}
+}
+
+// The warnings come once per command, not once per KDF pass of every decoy.
+func TestDeriveWalletWarnsOnceAboutNormalization(t *testing.T) {
+	s, fakeCrypto, _ := newFakeService()
+	arguments := testArguments("ethereum", MINIMUM_DIFFICULTY, ENGLISH_LANGUAGE, RAW_OUTPUT)
+	arguments.Password, arguments.Salt, arguments.Decoys = "contraseňa", "josé@example.com", 3
+
+	var err error
+	stderr := captureOutput(t, &os.Stderr, func() {
+		_, err = s.DeriveWallet(arguments)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fakeCrypto.calls) <= 2 {
+		t.Fatalf("the KDFs ran %d times, the decoys were not derived", len(fakeCrypto.calls))
+	}
+	for _, input := range []string{"password", "salt"} {
+		warning := strings.Replace(NORMALIZED_WARNING, "%s", input, 1)
+		if strings.Count(stderr, warning) != 1 {
+			t.Errorf("the %s warning appears %d times in %q, want once", input, strings.Count(stderr, warning), stderr)
+		}
+	}
+
+	stderr = captureOutput(t, &os.Stderr, func() {
+		_, err = s.GenerateAESParams(arguments)
+	})
+	if err != nil || stderr != "" {
+		t.Errorf("GenerateAESParams printed %q, %v", stderr, err)
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func TestPaperRegenerationHintHasNoNormalize(t *testing.T) {
+	arguments := testArguments("ethereum", MINIMUM_DIFFICULTY, ENGLISH_LANGUAGE, RAW_OUTPUT)
+
+	hint := paperRegenerationHint(model.PaperWallet{}, arguments)
+	if strings.Contains(hint, "-no-normalize") {
+		t.Errorf("hint %q has -no-normalize for a normalized wallet", hint)
+	}
+	arguments.NoNormalize = true
+	hint = paperRegenerationHint(model.PaperWallet{}, arguments)
+	if !strings.Contains(hint, " -no-normalize") {
+		t.Errorf("hint %q does not have -no-normalize", hint)
+	}
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	. "swisswallet/constants"
+	"swisswallet/model"
+)
+
+// CheckPaperArguments returns the paper wallet format, taken from the extension
+// of the output file.
+func (s *service) CheckPaperArguments(arguments model.Arguments) (string, error) {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), arguments)
+
+	if arguments.Output == KEYSTORE_OUTPUT {
+		err := errors.New("Paper wallets can only be printed from raw or mnemonic output")
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return "", err
+	}
+
+	err := s.simpleUtils.CheckIfSupported(arguments.QRLevel, s.simpleUtils.GetSupportedQRLevels())
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return "", err
+	}
+
+	format := strings.ToLower(strings.TrimPrefix(filepath.Ext(arguments.Paper), "."))
+	err = s.simpleUtils.CheckIfSupported(format, s.simpleUtils.GetSupportedPaperFormats())
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return "", err
+	}
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), format, err)
+	return format, err
+}
+
+func (s *service) ExportPaperWallet(paperWallet model.PaperWallet, arguments model.Arguments) error {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), paperWallet, arguments)
+
+	format, err := s.CheckPaperArguments(arguments)
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	paperWallet.Currency = arguments.Currency
+	paperWallet.Difficulty = arguments.Difficulty
+	paperWallet.Language = arguments.Language
+	paperWallet.DerivationPath = "none (raw private key)"
+	if arguments.Output == MNEMONIC_OUTPUT {
+		paperWallet.DerivationPath = ETHEREUM_DERIVATION_PATH
+	}
+	paperWallet.RegenerationHint = paperRegenerationHint(paperWallet, arguments)
+
+	paperWallet.AddressQR, err = s.qrRepository.Encode(paperWallet.Address, arguments.QRLevel)
+	if err != nil {
+		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+	paperWallet.SecretQR, err = s.qrRepository.Encode(paperWallet.Secret, arguments.QRLevel)
+	if err != nil {
+		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	document, err := s.paperRepository.Render(paperWallet, format)
+	if err != nil {
+		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	err = ioutil.WriteFile(arguments.Paper, document, 0600)
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+	fmt.Printf("Paper wallet written to: %s\n", arguments.Paper)
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), err)
+	return err
+}
+
+// paperRegenerationHint is the generate command that derives the wallet of
+// the paper again, with every flag that changes the result and placeholders
+// for the secrets.
+func paperRegenerationHint(paperWallet model.PaperWallet, arguments model.Arguments) string {
+	hint := fmt.Sprintf("swisswallet generate -c %s -o %s -d %s -l %s -p <password> -s <salt>",
+		arguments.Currency, arguments.Output, arguments.Difficulty, arguments.Language)
+	if arguments.SaltPolicy != REQUIRED_SALT_POLICY {
+		hint += fmt.Sprintf(" -salt-policy %s", arguments.SaltPolicy)
+	}
+	if arguments.NoNormalize {
+		hint += " -no-normalize"
+	}
+	if !arguments.KeyfileIsEmpty() {
+		hint += " -keyfile <keyfile>"
+	}
+	if !arguments.Bip38PassphraseIsEmpty() {
+		hint += fmt.Sprintf(" -bip38-passphrase <passphrase> -bip38-mode %s", arguments.Bip38Mode)
+	}
+	if arguments.Profile == DECOY_PROFILE {
+		hint += fmt.Sprintf(" -profile %s -decoy-index %d", DECOY_PROFILE, arguments.DecoyIndex)
+		if arguments.DuressSuffix != DEFAULT_DURESS_SUFFIX {
+			hint += " -duress-suffix <duress suffix>"
+		}
+	}
+	if paperWallet.VanityCounter > 0 {
+		hint += fmt.Sprintf(" -vanity-counter %d", paperWallet.VanityCounter)
+	}
+	return hint
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	. "swisswallet/constants"
+	"swisswallet/logger"
+	"swisswallet/model"
+)
+
+// GeneratePassphrase prints a random passphrase and its entropy and, with
+// -and-generate, goes on to generate the wallet of that passphrase and -s.
+func (s *service) GeneratePassphrase(arguments model.Arguments) error {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), arguments)
+
+	err := s.simpleUtils.CheckIfSupported(arguments.Wordlist, s.simpleUtils.GetSupportedWordlists())
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+	if arguments.Words < 1 || arguments.Words > PASSPHRASE_MAX_WORDS {
+		err = errors.New(fmt.Sprintf("Number of words must be between 1 and %d", PASSPHRASE_MAX_WORDS))
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+	if arguments.AndGenerate && !arguments.PasswordIsEmpry() {
+		err = errors.New("Use -p or -and-generate, the generated passphrase is the password")
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	passphrase, entropy, err := s.mnemonicRepository.NewPassphrase(arguments.Wordlist, arguments.Language, arguments.Words, arguments.Separator)
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+	s.logger.AddSecrets(passphrase)
+
+	fmt.Printf("Passphrase: %s\n", passphrase)
+	fmt.Printf("Entropy: %.1f bits\n", entropy)
+
+	if arguments.AndGenerate {
+		arguments.Password = passphrase
+		err = s.GenerateWallet(arguments)
+		if err != nil {
+			s.logger.LogOnErrorWithContext(s.logger.GetContext(), err)
+			return err
+		}
+	}
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), logger.Secret(passphrase), err)
+	return err
+}
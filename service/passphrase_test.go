@@ -0,0 +1,116 @@
+package service
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	. "swisswallet/constants"
+	"swisswallet/model"
+)
+
+func passphraseArguments(wordList string, words int) model.Arguments {
+	arguments := testArguments("ethereum", MINIMUM_DIFFICULTY, ENGLISH_LANGUAGE, RAW_OUTPUT)
+	arguments.Password = ""
+	arguments.Wordlist, arguments.Words, arguments.Separator = wordList, words, " "
+	return arguments
+}
+
+func TestGeneratePassphrasePrintsWordsOfTheSelectedList(t *testing.T) {
+	s, _, _ := newFakeService()
+
+	tests := []struct {
+		wordList string
+		language string
+		entropy  string
+	}{
+		{EFF_LARGE_WORDLIST, ENGLISH_LANGUAGE, "Entropy: 103.4 bits"},
+		{EFF_SHORT_WORDLIST, ENGLISH_LANGUAGE, "Entropy: 82.7 bits"},
+		{BIP39_WORDLIST, ENGLISH_LANGUAGE, "Entropy: 88.0 bits"},
+		{BIP39_WORDLIST, SPANISH_LANGUAGE, "Entropy: 88.0 bits"},
+	}
+	for _, test := range tests {
+		arguments := passphraseArguments(test.wordList, 8)
+		arguments.Language = test.language
+		var err error
+		stdout := captureOutput(t, &os.Stdout, func() {
+			err = s.GeneratePassphrase(arguments)
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", test.wordList, err)
+		}
+		if !strings.Contains(stdout, test.entropy+"\n") {
+			t.Errorf("%s: %q does not have %q", test.wordList, stdout, test.entropy)
+		}
+
+		var passphrase string
+		for _, line := range strings.Split(stdout, "\n") {
+			if strings.HasPrefix(line, "Passphrase: ") {
+				passphrase = strings.TrimPrefix(line, "Passphrase: ")
+			}
+		}
+		words := strings.Split(passphrase, " ")
+		if len(words) != 8 {
+			t.Fatalf("%s: %d words in %q, want 8", test.wordList, len(words), passphrase)
+		}
+		if test.wordList == BIP39_WORDLIST {
+			wordList, _ := s.mnemonicRepository.GetWordList(test.language)
+			for _, word := range words {
+				if !containsString(wordList, word) {
+					t.Errorf("%s %s: %q is not in the wordlist", test.wordList, test.language, word)
+				}
+			}
+		}
+	}
+}
+
+func TestGeneratePassphraseWordsBounds(t *testing.T) {
+	s, _, _ := newFakeService()
+
+	for _, words := range []int{-1, 0, 1, PASSPHRASE_MAX_WORDS, PASSPHRASE_MAX_WORDS + 1} {
+		var err error
+		stdout := captureOutput(t, &os.Stdout, func() {
+			err = s.GeneratePassphrase(passphraseArguments(EFF_SHORT_WORDLIST, words))
+		})
+		valid := words >= 1 && words <= PASSPHRASE_MAX_WORDS
+		if (err == nil) != valid {
+			t.Errorf("-words %d: %v", words, err)
+		}
+		if !valid && stdout != "" {
+			t.Errorf("-words %d printed %q", words, stdout)
+		}
+	}
+}
+
+func TestGeneratePassphraseAndGenerate(t *testing.T) {
+	s, fakeCrypto, _ := newFakeService()
+
+	arguments := passphraseArguments(EFF_LARGE_WORDLIST, 8)
+	arguments.Password, arguments.AndGenerate = "correct horse battery staple", true
+	var err error
+	stdout := captureOutput(t, &os.Stdout, func() {
+		err = s.GeneratePassphrase(arguments)
+	})
+	if err == nil || !strings.Contains(err.Error(), "Use -p or -and-generate") || stdout != "" {
+		t.Errorf("-p with -and-generate gives %v and prints %q", err, stdout)
+	}
+	if len(fakeCrypto.calls) != 0 {
+		t.Error("the KDFs ran for -p with -and-generate")
+	}
+
+	arguments.Password = ""
+	captureOutput(t, &os.Stderr, func() {
+		stdout = captureOutput(t, &os.Stdout, func() {
+			err = s.GeneratePassphrase(arguments)
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	passphrase := strings.SplitN(strings.SplitN(stdout, "Passphrase: ", 2)[1], "\n", 2)[0]
+	expected := passphraseArguments(EFF_LARGE_WORDLIST, 8)
+	expected.Password = passphrase
+	if len(fakeCrypto.calls) == 0 || fakeCrypto.calls[0].password != expected.GetCurrencyPasswordByKdf(ARGON2) {
+		t.Errorf("-and-generate did not derive the wallet of the passphrase %q", passphrase)
+	}
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	. "swisswallet/constants"
+	"swisswallet/model"
+)
+
+// CheckPasswordStrength prints the estimated guesses and crack time of the
+// password at the selected difficulty to stderr. It warns below
+// PASSWORD_WARN_SECONDS and refuses below PASSWORD_REFUSE_SECONDS unless
+// -i-know-what-im-doing.
+func (s *service) CheckPasswordStrength(arguments model.Arguments) error {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), arguments)
+
+	strength, err := s.cryptoRepository.EstimatePasswordStrength(arguments.GetNormalizedPassword(), []string{arguments.GetNormalizedSalt()}, arguments.Difficulty)
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	crackTime := formatCrackTime(strength.CrackSeconds)
+	fmt.Fprintf(os.Stderr, "Password Strength: about 2^%.0f guesses, %s to try them all at %s difficulty\n", strength.Entropy, crackTime, arguments.Difficulty)
+	if strength.CrackSeconds < PASSWORD_REFUSE_SECONDS && !arguments.IKnowWhatImDoing {
+		err = errors.New(fmt.Sprintf("Password too weak, it could be cracked in %s. Pick a longer password, e.g. six or more random words, or pass -i-know-what-im-doing", crackTime))
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+	if strength.CrackSeconds < PASSWORD_WARN_SECONDS {
+		fmt.Fprintln(os.Stderr, "Warning: this password is weak for a brain wallet, consider a longer one or a higher difficulty")
+	}
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), strength.Entropy)
+	return nil
+}
+
+func formatCrackTime(seconds float64) string {
+	units := []struct {
+		name    string
+		seconds float64
+	}{
+		{"years", 365 * 24 * 3600},
+		{"days", 24 * 3600},
+		{"hours", 3600},
+		{"minutes", 60},
+	}
+	for _, unit := range units {
+		if seconds >= unit.seconds {
+			return fmt.Sprintf("%.3g %s", seconds/unit.seconds, unit.name)
+		}
+	}
+	return "less than a minute"
+}
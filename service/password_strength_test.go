@@ -0,0 +1,62 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	. "swisswallet/constants"
+)
+
+func TestGenerateWalletChecksArgumentsBeforePasswordStrength(t *testing.T) {
+	s, _, fakeUtils := newFakeService()
+	fakeUtils.unsupported["bad-output"] = true
+	fakeUtils.unsupported["bad-language"] = true
+	fakeUtils.unsupported["bad-policy"] = true
+
+	tests := []struct {
+		output, language, saltPolicy, salt string
+		wantErr                            string
+	}{
+		{"bad-output", ENGLISH_LANGUAGE, REQUIRED_SALT_POLICY, "satoshi@example.com", "unsupported: bad-output"},
+		{RAW_OUTPUT, "bad-language", REQUIRED_SALT_POLICY, "satoshi@example.com", "unsupported: bad-language"},
+		{RAW_OUTPUT, ENGLISH_LANGUAGE, "bad-policy", "satoshi@example.com", "unsupported: bad-policy"},
+		{RAW_OUTPUT, ENGLISH_LANGUAGE, REQUIRED_SALT_POLICY, "", "Salt is required"},
+		{RAW_OUTPUT, ENGLISH_LANGUAGE, EMAIL_SALT_POLICY, "satoshi", "Salt is not an email address"},
+		{RAW_OUTPUT, ENGLISH_LANGUAGE, REQUIRED_SALT_POLICY, "satoshi@example.com", "Password too weak"},
+	}
+	for _, test := range tests {
+		arguments := testArguments("ethereum", MINIMUM_DIFFICULTY, test.language, test.output)
+		arguments.Password = "a"
+		arguments.SaltPolicy, arguments.Salt = test.saltPolicy, test.salt
+		err := s.GenerateWallet(arguments)
+		if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+			t.Errorf("GenerateWallet(%s, %s, %s, %q) = %v, want %q", test.output, test.language, test.saltPolicy, test.salt, err, test.wantErr)
+		}
+	}
+}
+
+func TestEncryptWalletChecksArgumentsBeforePasswordStrength(t *testing.T) {
+	s, _, fakeUtils := newFakeService()
+	fakeUtils.unsupported["bad-output"] = true
+	fakeUtils.unsupported["bad-language"] = true
+
+	tests := []struct {
+		output, language, key string
+		wantErr               string
+	}{
+		{"bad-output", ENGLISH_LANGUAGE, strings.Repeat("11", 32), "unsupported: bad-output"},
+		{RAW_OUTPUT, "bad-language", strings.Repeat("11", 32), "unsupported: bad-language"},
+		{KEYSTORE_OUTPUT, ENGLISH_LANGUAGE, strings.Repeat("11", 32), "Keystore output is not supported"},
+		{RAW_OUTPUT, ENGLISH_LANGUAGE, "", "Private Key or Mnemonic are required"},
+		{RAW_OUTPUT, ENGLISH_LANGUAGE, strings.Repeat("11", 32), "Password too weak"},
+	}
+	for _, test := range tests {
+		arguments := testArguments("ethereum", MINIMUM_DIFFICULTY, test.language, test.output)
+		arguments.Password = "a"
+		arguments.Key = test.key
+		err := s.EncryptWallet(arguments)
+		if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+			t.Errorf("EncryptWallet(%s, %s, %q) = %v, want %q", test.output, test.language, test.key, err, test.wantErr)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"swisswallet/model"
+
+	"github.com/btcsuite/btcutil"
+)
+
+// ReadPsbt returns the binary PSBT from -psbt or -psbt-file, either of which
+// may hold it base64 encoded.
+func (s *service) ReadPsbt(arguments model.Arguments) ([]byte, error) {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), arguments)
+
+	if arguments.PsbtIsEmpty() == arguments.PsbtFileIsEmpty() {
+		err := errors.New("Exactly one of -psbt or -psbt-file is required to sign a PSBT")
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return nil, err
+	}
+
+	content := []byte(arguments.Psbt)
+	if !arguments.PsbtFileIsEmpty() {
+		var err error
+		content, err = ioutil.ReadFile(arguments.PsbtFile)
+		if err != nil {
+			s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+			return nil, err
+		}
+	}
+
+	if bytes.HasPrefix(content, []byte("psbt\xff")) {
+		s.logger.LogOnExitWithContext(s.logger.GetContext(), len(content))
+		return content, nil
+	}
+	packet, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(content)))
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return nil, err
+	}
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), len(packet), err)
+	return packet, err
+}
+
+// SignPsbt signs the inputs of a PSBT whose BIP32 derivation fields lead back
+// to the BIP39 seed of the mnemonic for this password and salt, after showing
+// the outputs and fee.
+func (s *service) SignPsbt(arguments model.Arguments) error {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), arguments)
+
+	network, err := s.GetBitcoinNetwork(arguments.Currency)
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	packet, err := s.ReadPsbt(arguments)
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	wallet, err := s.DeriveWallet(arguments)
+	if err != nil {
+		s.logger.LogOnErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+	seed, err := s.mnemonicRepository.NewSeedFromMnemonic(wallet.Mnemonic, arguments.Language)
+	if err != nil {
+		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	summary, err := s.psbtRepository.Summarize(packet, seed, network)
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	for i, input := range summary.Inputs {
+		amount := "unknown amount"
+		if input.AmountKnown {
+			amount = btcutil.Amount(input.Amount).String()
+		}
+		signing := ""
+		if input.Signable {
+			signing = " (signing)"
+		}
+		fmt.Printf("Input %d: %s %s %s%s\n", i, input.Outpoint, input.Address, amount, signing)
+	}
+	for i, output := range summary.Outputs {
+		change := ""
+		if output.Change {
+			change = " (change)"
+		}
+		fmt.Printf("Output %d: %s %s%s\n", i, output.Address, btcutil.Amount(output.Amount), change)
+	}
+	if summary.FeeKnown {
+		fmt.Printf("Fee: %s\n", btcutil.Amount(summary.Fee))
+	} else {
+		fmt.Println("Fee: unknown, some inputs have no UTXO")
+	}
+
+	if summary.SignableInputs() == 0 {
+		err = errors.New("No input of this PSBT derives from this password and salt")
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+	if !arguments.Yes {
+		confirmed, err := s.simpleUtils.Confirm(fmt.Sprintf("Sign %d of %d inputs? [y/N] ", summary.SignableInputs(), len(summary.Inputs)))
+		if err != nil {
+			s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+			return err
+		}
+		if !confirmed {
+			err = errors.New("Signing cancelled")
+			s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+			return err
+		}
+	}
+
+	signedPacket, signed, err := s.psbtRepository.Sign(packet, seed, network)
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	fmt.Printf("Signed Inputs: %d\n", signed)
+	if arguments.PsbtOutIsEmpty() {
+		fmt.Printf("Signed PSBT: %s\n", base64.StdEncoding.EncodeToString(signedPacket))
+	} else {
+		err = ioutil.WriteFile(arguments.PsbtOut, signedPacket, 0600)
+		if err != nil {
+			s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+			return err
+		}
+		fmt.Printf("Signed PSBT written to: %s\n", arguments.PsbtOut)
+	}
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), signed, err)
+	return err
+}
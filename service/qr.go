@@ -0,0 +1,82 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	. "swisswallet/constants"
+	"swisswallet/model"
+)
+
+// SeedQR fixes the error correction at low and the version by word count, so
+// that every SeedQR of a mnemonic is the same grid a hand transcription
+// template expects. -qr-level only applies to the address code.
+var seedQRVersions = map[string]map[int]int{
+	STANDARD_SEEDQR_FORMAT: {12: 2, 24: 3},
+	COMPACT_SEEDQR_FORMAT:  {12: 1, 24: 2},
+}
+
+func (s *service) CheckQRArguments(arguments model.Arguments) error {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), arguments)
+
+	err := s.simpleUtils.CheckIfSupported(arguments.QRLevel, s.simpleUtils.GetSupportedQRLevels())
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	err = s.simpleUtils.CheckIfSupported(arguments.QRSeed, s.simpleUtils.GetSupportedSeedQRFormats())
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	if arguments.QRSeed != NO_SEEDQR_FORMAT && (arguments.Output != MNEMONIC_OUTPUT || arguments.Language != ENGLISH_LANGUAGE) {
+		err = errors.New("SeedQR requires mnemonic output in english")
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), err)
+	return err
+}
+
+func (s *service) PrintQRCodes(paperWallet model.PaperWallet, arguments model.Arguments) error {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), paperWallet, arguments)
+
+	err := s.CheckQRArguments(arguments)
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	addressQR, err := s.qrRepository.Encode(paperWallet.Address, arguments.QRLevel)
+	if err != nil {
+		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+	fmt.Printf("Address QR:\n%s", s.qrRepository.RenderTerminal(addressQR, arguments.QRInvert))
+
+	if arguments.QRSeed != NO_SEEDQR_FORMAT {
+		payload, err := s.mnemonicRepository.NewSeedQRPayload(paperWallet.Secret, arguments.Language, arguments.QRSeed)
+		if err != nil {
+			s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+			return err
+		}
+		version, ok := seedQRVersions[arguments.QRSeed][len(strings.Fields(paperWallet.Secret))]
+		if !ok {
+			err = errors.New(fmt.Sprintf("SeedQR is only defined for 12 and 24 word mnemonics, not %d words", len(strings.Fields(paperWallet.Secret))))
+			s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+			return err
+		}
+		seedQR, err := s.qrRepository.EncodeVersion(payload, QR_LOW_LEVEL, version)
+		if err != nil {
+			s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+			return err
+		}
+		fmt.Printf("SeedQR (%s):\n%s", arguments.QRSeed, s.qrRepository.RenderTerminal(seedQR, arguments.QRInvert))
+	}
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), err)
+	return err
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	. "swisswallet/constants"
+	"swisswallet/model"
+	repo "swisswallet/repository"
+)
+
+// recordingQrRepository keeps every code the service encodes.
+type recordingQrRepository struct {
+	repo.QrRepository
+	codes [][][]bool
+}
+
+func (r *recordingQrRepository) Encode(content string, level string) ([][]bool, error) {
+	code, err := r.QrRepository.Encode(content, level)
+	r.codes = append(r.codes, code)
+	return code, err
+}
+
+func (r *recordingQrRepository) EncodeVersion(content string, level string, version int) ([][]bool, error) {
+	code, err := r.QrRepository.EncodeVersion(content, level, version)
+	r.codes = append(r.codes, code)
+	return code, err
+}
+
+// qrVersionAndLevel reads the version from the size of a code with its four
+// module quiet zone, and the error correction level from the format bits
+// around the top left finder pattern.
+func qrVersionAndLevel(code [][]bool) (int, string) {
+	module := func(row, column int) uint {
+		if code[row+4][column+4] {
+			return 1
+		}
+		return 0
+	}
+	var format uint
+	for bit := uint(0); bit <= 5; bit++ {
+		format |= module(int(bit), 8) << bit
+	}
+	format |= module(7, 8)<<6 | module(8, 8)<<7 | module(8, 7)<<8
+	for bit := uint(9); bit <= 14; bit++ {
+		format |= module(8, 14-int(bit)) << bit
+	}
+	levels := map[uint]string{1: QR_LOW_LEVEL, 0: QR_MEDIUM_LEVEL, 3: QR_HIGH_LEVEL, 2: QR_HIGHEST_LEVEL}
+	return (len(code) - 8 - 17) / 4, levels[(format^0x5412)>>13]
+}
+
+func TestPrintQRCodesSeedQRVersionAndLevel(t *testing.T) {
+	mnemonics := map[int]string{
+		12: testMnemonic,
+		24: strings.Repeat("abandon ", 23) + "art",
+	}
+	tests := []struct {
+		format  string
+		words   int
+		version int
+	}{
+		{STANDARD_SEEDQR_FORMAT, 12, 2},
+		{STANDARD_SEEDQR_FORMAT, 24, 3},
+		{COMPACT_SEEDQR_FORMAT, 12, 1},
+		{COMPACT_SEEDQR_FORMAT, 24, 2},
+	}
+	for _, test := range tests {
+		s, _, _ := newFakeService()
+		qr := &recordingQrRepository{QrRepository: s.qrRepository}
+		s.qrRepository = qr
+		arguments := testArguments("ethereum", MINIMUM_DIFFICULTY, ENGLISH_LANGUAGE, MNEMONIC_OUTPUT)
+		arguments.QRLevel, arguments.QRSeed = QR_HIGH_LEVEL, test.format
+		paperWallet := model.PaperWallet{Address: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", Secret: mnemonics[test.words]}
+
+		var err error
+		stdout := captureOutput(t, &os.Stdout, func() {
+			err = s.PrintQRCodes(paperWallet, arguments)
+		})
+		if err != nil {
+			t.Fatalf("%s %d words: %v", test.format, test.words, err)
+		}
+		if len(qr.codes) != 2 || !strings.Contains(stdout, "SeedQR ("+test.format+")") {
+			t.Fatalf("%s %d words: %d codes printed as %q", test.format, test.words, len(qr.codes), stdout)
+		}
+
+		version, level := qrVersionAndLevel(qr.codes[0])
+		if level != QR_HIGH_LEVEL {
+			t.Errorf("%s %d words: address code at level %s, want -qr-level %s", test.format, test.words, level, QR_HIGH_LEVEL)
+		}
+		version, level = qrVersionAndLevel(qr.codes[1])
+		if version != test.version || level != QR_LOW_LEVEL {
+			t.Errorf("%s %d words: SeedQR version %d level %s, want version %d level %s", test.format, test.words, version, level, test.version, QR_LOW_LEVEL)
+		}
+	}
+}
+
+func TestPrintQRCodesRejectsOtherWordCounts(t *testing.T) {
+	s, _, _ := newFakeService()
+	arguments := testArguments("ethereum", MINIMUM_DIFFICULTY, ENGLISH_LANGUAGE, MNEMONIC_OUTPUT)
+	arguments.QRLevel, arguments.QRSeed = QR_MEDIUM_LEVEL, COMPACT_SEEDQR_FORMAT
+	paperWallet := model.PaperWallet{Address: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", Secret: strings.Repeat("abandon ", 17) + "agent"}
+
+	var err error
+	captureOutput(t, &os.Stdout, func() {
+		err = s.PrintQRCodes(paperWallet, arguments)
+	})
+	if err == nil || !strings.Contains(err.Error(), "12 and 24 word") {
+		t.Errorf("18 word SeedQR gives %v", err)
+	}
+}
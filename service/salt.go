@@ -0,0 +1,51 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"os"
+	"strings"
+	. "swisswallet/constants"
+	"swisswallet/model"
+)
+
+// ApplySaltPolicy checks -s against -salt-policy and returns the arguments
+// with the salt the KDF must use. The email policy trims and lowercases the
+// salt, as WarpWallet recommends the email address as salt and a stray space
+// or capital letter would otherwise derive another wallet. The other policies
+// keep the salt as is and warn on stderr about surrounding whitespace.
+func (s *service) ApplySaltPolicy(arguments model.Arguments) (model.Arguments, error) {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), arguments)
+
+	err := s.simpleUtils.CheckIfSupported(arguments.SaltPolicy, s.simpleUtils.GetSupportedSaltPolicies())
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return arguments, err
+	}
+
+	switch arguments.SaltPolicy {
+	case EMAIL_SALT_POLICY:
+		salt := strings.ToLower(strings.TrimSpace(arguments.Salt))
+		address, err := mail.ParseAddress(salt)
+		if err != nil || address.Address != salt {
+			err = errors.New(fmt.Sprintf("Salt is not an email address, required by -salt-policy %s", EMAIL_SALT_POLICY))
+			s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+			return arguments, err
+		}
+		arguments.Salt = salt
+	case REQUIRED_SALT_POLICY:
+		if arguments.SaltIsEmpry() {
+			err = errors.New(fmt.Sprintf("Salt is required, pass -s or -salt-policy %s for wallets generated without one", VERBATIM_SALT_POLICY))
+			s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+			return arguments, err
+		}
+	}
+
+	if arguments.SaltPolicy != EMAIL_SALT_POLICY && strings.TrimSpace(arguments.Salt) != arguments.Salt {
+		fmt.Fprintln(os.Stderr, SALT_WHITESPACE_WARNING)
+	}
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), arguments.SaltPolicy)
+	return arguments, nil
+}
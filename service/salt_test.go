@@ -0,0 +1,43 @@
+package service
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	. "swisswallet/constants"
+)
+
+func TestApplySaltPolicyWarnsOnStderrAboutWhitespace(t *testing.T) {
+	s, _, _ := newFakeService()
+
+	tests := []struct {
+		saltPolicy, salt, wantSalt string
+		wantWarning                bool
+	}{
+		{REQUIRED_SALT_POLICY, "satoshi@example.com", "satoshi@example.com", false},
+		{REQUIRED_SALT_POLICY, "satoshi@example.com ", "satoshi@example.com ", true},
+		{VERBATIM_SALT_POLICY, "", "", false},
+		{VERBATIM_SALT_POLICY, "satoshi", "satoshi", false},
+		{VERBATIM_SALT_POLICY, " satoshi", " satoshi", true},
+		{VERBATIM_SALT_POLICY, "satoshi\t", "satoshi\t", true},
+		{EMAIL_SALT_POLICY, " Satoshi@Example.com ", "satoshi@example.com", false},
+	}
+	for _, test := range tests {
+		arguments := testArguments("ethereum", MINIMUM_DIFFICULTY, ENGLISH_LANGUAGE, RAW_OUTPUT)
+		arguments.SaltPolicy, arguments.Salt = test.saltPolicy, test.salt
+		var err error
+		stderr := captureOutput(t, &os.Stderr, func() {
+			arguments, err = s.ApplySaltPolicy(arguments)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if arguments.Salt != test.wantSalt {
+			t.Errorf("-salt-policy %s turns %q into %q, want %q", test.saltPolicy, test.salt, arguments.Salt, test.wantSalt)
+		}
+		if strings.Contains(stderr, SALT_WHITESPACE_WARNING) != test.wantWarning {
+			t.Errorf("-salt-policy %s with %q: stderr %q, want the whitespace warning %v", test.saltPolicy, test.salt, stderr, test.wantWarning)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"swisswallet/model"
+
+	hdwallet "github.com/miguelmota/go-ethereum-hdwallet"
+)
+
+// BIP32 test vector 1, chain m/0H/1.
+const (
+	bip32SeedVector       = "000102030405060708090a0b0c0d0e0f"
+	bip32PathVector       = "m/0'/1"
+	bip32PrivateKeyVector = "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368"
+)
+
+func (s *service) SelfTest() error {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext())
+
+	err := s.cryptoRepository.SelfTest()
+	if err != nil {
+		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	err = s.mnemonicRepository.SelfTest()
+	if err != nil {
+		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	err = s.shamirRepository.SelfTest()
+	if err != nil {
+		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	err = s.descriptorRepository.SelfTest()
+	if err != nil {
+		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	err = s.psbtRepository.SelfTest()
+	if err != nil {
+		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	err = s.messageRepository.SelfTest()
+	if err != nil {
+		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	err = s.addressRepository.SelfTest()
+	if err != nil {
+		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	seed, _ := hex.DecodeString(bip32SeedVector)
+	wallet, err := hdwallet.NewFromSeed(seed)
+	if err != nil {
+		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+	account, err := wallet.Derive(hdwallet.MustParseDerivationPath(bip32PathVector), false)
+	if err != nil {
+		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+	privateKey, err := wallet.PrivateKeyHex(account)
+	if err != nil {
+		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+	if privateKey != bip32PrivateKeyVector {
+		err = errors.New("Self-test failed: BIP32 derivation does not match its known answer")
+		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), err)
+	return err
+}
+
+func (s *service) RunSelfTest(arguments model.Arguments) error {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), arguments)
+
+	err := s.SelfTest()
+	if err != nil {
+		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+	fmt.Println("Crypto primitives (argon2id, scrypt, AES-CBC, Keccak-256, BIP39, BIP32, SLIP-0039, BIP380, BIP340, EIP-712, BIP322, address checksums): OK")
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), err)
+	return err
+}
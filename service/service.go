@@ -1,9 +1,11 @@
 package service
 
 import (
+	"crypto/subtle"
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"os"
 	"strings"
 	. "swisswallet/constants"
 	"swisswallet/logger"
@@ -11,11 +13,11 @@ import (
 	repo "swisswallet/repository"
 	"swisswallet/utils"
 
+	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/core/types"
 	ethcrypto "github.com/ethereum/go-ethereum/crypto"
 	hdwallet "github.com/miguelmota/go-ethereum-hdwallet"
-	"github.com/tyler-smith/go-bip39"
-	wordlist "github.com/tyler-smith/go-bip39/wordlists"
 	"github.com/vsergeev/btckeygenie/btckey"
 )
 
@@ -23,82 +25,405 @@ type Service interface {
 	GenerateWallet(arguments model.Arguments) error
 	DecryptWallet(arguments model.Arguments) error
 	EncryptWallet(arguments model.Arguments) error
+	VerifyWallet(arguments model.Arguments) error
+	DeriveWallet(arguments model.Arguments) (*model.Wallet, error)
+	WalletFromEntropy(entropy []byte, arguments model.Arguments) (*model.Wallet, error)
+	SelfTest() error
+	RunSelfTest(arguments model.Arguments) error
 	GenerateAESParams(arguments model.Arguments) (*model.AESParams, error)
-	ChangeMnemonicLanguageIfSupported(language string) error
+	ReadKeyfile(arguments model.Arguments) ([]byte, error)
+	CheckPasswordStrength(arguments model.Arguments) error
+	ApplySaltPolicy(arguments model.Arguments) (model.Arguments, error)
+	CheckWalletArguments(arguments model.Arguments) (model.Arguments, error)
+	GeneratePassphrase(arguments model.Arguments) error
+	GetAccountFromMnemonic(mnemonic string, language string) (accounts.Account, error)
+	CheckKeystoreArguments(arguments model.Arguments) error
+	ExportKeystore(privateKey []byte, arguments model.Arguments) error
+	ImportKeystore(arguments model.Arguments) (string, error)
+	CheckBip38Arguments(arguments model.Arguments) (*chaincfg.Params, error)
+	EncryptBip38Key(privateKey []byte, arguments model.Arguments) (string, string, error)
+	DecryptBip38Wallet(arguments model.Arguments) error
+	CheckPaperArguments(arguments model.Arguments) (string, error)
+	ExportPaperWallet(paperWallet model.PaperWallet, arguments model.Arguments) error
+	CheckQRArguments(arguments model.Arguments) error
+	PrintQRCodes(paperWallet model.PaperWallet, arguments model.Arguments) error
+	ParseSlip39Groups(groups string) ([]model.Slip39Group, error)
+	SplitWallet(arguments model.Arguments) error
+	CombineWallet(arguments model.Arguments) error
+	GetBitcoinNetwork(currency string) (*chaincfg.Params, error)
+	MultisigWallet(arguments model.Arguments) error
+	CheckDescriptorArguments(arguments model.Arguments) error
+	PrintDescriptors(mnemonic string, arguments model.Arguments) error
+	GetEthereumPrivateKey(wallet *model.Wallet, arguments model.Arguments) ([]byte, error)
+	ReadUnsignedTransaction(arguments model.Arguments) (*types.Transaction, error)
+	SignTransaction(arguments model.Arguments) error
+	ReadPsbt(arguments model.Arguments) ([]byte, error)
+	SignPsbt(arguments model.Arguments) error
+	ReadMessage(arguments model.Arguments) ([]byte, bool, error)
+	SignMessage(arguments model.Arguments) error
+	SignEthereumMessage(message []byte, typed bool, arguments model.Arguments) (string, string, error)
+	SignBitcoinMessage(message []byte, arguments model.Arguments) (string, string, error)
+	VerifyMessage(arguments model.Arguments) error
+	ValidateAddress(arguments model.Arguments) error
+	ParseVanityPattern(pattern string) (string, string, error)
+	CheckVanityArguments(arguments model.Arguments) error
+	SearchVanityCounter(entropy []byte, arguments model.Arguments) (uint64, error)
+	CheckDecoyArguments(arguments model.Arguments) error
+	DecoyArguments(arguments model.Arguments, index int) model.Arguments
+	DecoyAddresses(arguments model.Arguments) ([]string, error)
+	FormatEthereumAddress(address []byte, arguments model.Arguments) string
+	CheckEthereumAddressArgument(arguments model.Arguments) error
 }
 
 type service struct {
-	cryptoRepository repo.CryptoRepository
-	simpleUtils      utils.SimpleUtils
-	logger           *logger.Logger
+	cryptoRepository     repo.CryptoRepository
+	mnemonicRepository   repo.MnemonicRepository
+	shamirRepository     repo.ShamirRepository
+	descriptorRepository repo.DescriptorRepository
+	qrRepository         repo.QrRepository
+	paperRepository      repo.PaperRepository
+	psbtRepository       repo.PsbtRepository
+	messageRepository    repo.MessageRepository
+	addressRepository    repo.AddressRepository
+	simpleUtils          utils.SimpleUtils
+	logger               *logger.Logger
 }
 
-func NewService(cryptoRepository repo.CryptoRepository, simpleUtils utils.SimpleUtils, logger *logger.Logger) Service {
+func NewService(cryptoRepository repo.CryptoRepository, mnemonicRepository repo.MnemonicRepository, shamirRepository repo.ShamirRepository, descriptorRepository repo.DescriptorRepository, qrRepository repo.QrRepository, paperRepository repo.PaperRepository, psbtRepository repo.PsbtRepository, messageRepository repo.MessageRepository, addressRepository repo.AddressRepository, simpleUtils utils.SimpleUtils, logger *logger.Logger) Service {
 	return &service{
-		cryptoRepository: cryptoRepository,
-		simpleUtils:      simpleUtils,
-		logger:           logger,
+		cryptoRepository:     cryptoRepository,
+		mnemonicRepository:   mnemonicRepository,
+		shamirRepository:     shamirRepository,
+		descriptorRepository: descriptorRepository,
+		qrRepository:         qrRepository,
+		paperRepository:      paperRepository,
+		psbtRepository:       psbtRepository,
+		messageRepository:    messageRepository,
+		addressRepository:    addressRepository,
+		simpleUtils:          simpleUtils,
+		logger:               logger,
 	}
 }
 
 func (s *service) GenerateWallet(arguments model.Arguments) error {
 	s.logger.LogOnEntryWithContext(s.logger.GetContext(), arguments)
 
-	err := s.simpleUtils.CheckIfSupported(arguments.Output, s.simpleUtils.GetSupportedOutputs())
+	if arguments.Output == KEYSTORE_OUTPUT {
+		err := s.CheckKeystoreArguments(arguments)
+		if err != nil {
+			s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+			return err
+		}
+	}
+
+	if !arguments.Bip38PassphraseIsEmpty() {
+		if arguments.Output != RAW_OUTPUT {
+			err := errors.New("BIP38 can only be used with raw output")
+			s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+			return err
+		}
+		_, err := s.CheckBip38Arguments(arguments)
+		if err != nil {
+			s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+			return err
+		}
+	}
+
+	if !arguments.PaperIsEmpty() {
+		_, err := s.CheckPaperArguments(arguments)
+		if err != nil {
+			s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+			return err
+		}
+	}
+
+	if arguments.QR {
+		err := s.CheckQRArguments(arguments)
+		if err != nil {
+			s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+			return err
+		}
+	}
+
+	if arguments.Descriptors {
+		err := s.CheckDescriptorArguments(arguments)
+		if err != nil {
+			s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+			return err
+		}
+	}
+
+	arguments, err := s.CheckWalletArguments(arguments)
 	if err != nil {
 		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
 		return err
 	}
 
-	err = s.ChangeMnemonicLanguageIfSupported(arguments.Language)
+	err = s.CheckPasswordStrength(arguments)
 	if err != nil {
 		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
 		return err
 	}
 
+	wallet, err := s.deriveCheckedWallet(arguments)
+	if err != nil {
+		s.logger.LogOnErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	paperWallet := model.PaperWallet{Address: wallet.Address, VanityCounter: wallet.VanityCounter}
+	fmt.Printf("Ethereum Address: %s\n", wallet.Address)
+	if wallet.VanityCounter > 0 {
+		fmt.Printf("Vanity Counter: %d\n", wallet.VanityCounter)
+	}
+	if !arguments.KeyfileIsEmpty() {
+		fmt.Println(KEYFILE_REQUIRED)
+	}
+	if arguments.Output == RAW_OUTPUT && !arguments.Bip38PassphraseIsEmpty() {
+		paperWallet.Address, paperWallet.Secret, err = s.EncryptBip38Key(wallet.PrivateKey, arguments)
+		if err != nil {
+			s.logger.LogOnErrorWithContext(s.logger.GetContext(), err)
+			return err
+		}
+		paperWallet.SecretName = "BIP38 Encrypted Private Key"
+		fmt.Printf("Bitcoin Address: %s\n", paperWallet.Address)
+		fmt.Printf("BIP38 Encrypted Private Key: %s\n", paperWallet.Secret)
+	} else if arguments.Output == RAW_OUTPUT {
+		paperWallet.SecretName = "Private Key"
+		paperWallet.Secret = hex.EncodeToString(wallet.PrivateKey)
+		fmt.Printf("Private Key: %x\n", wallet.PrivateKey)
+	} else if arguments.Output == KEYSTORE_OUTPUT {
+		err = s.ExportKeystore(wallet.PrivateKey, arguments)
+		if err != nil {
+			s.logger.LogOnErrorWithContext(s.logger.GetContext(), err)
+			return err
+		}
+	} else {
+		paperWallet.SecretName = "Mnemonic"
+		paperWallet.Secret = wallet.Mnemonic
+		fmt.Printf("Mnemonic: %s\n", wallet.Mnemonic)
+	}
+
+	for i, address := range wallet.DecoyAddresses {
+		fmt.Printf("Decoy %d Address: %s\n", i+1, address)
+	}
+
+	if arguments.Descriptors {
+		err = s.PrintDescriptors(wallet.Mnemonic, arguments)
+		if err != nil {
+			s.logger.LogOnErrorWithContext(s.logger.GetContext(), err)
+			return err
+		}
+	}
+
+	if arguments.QR {
+		err = s.PrintQRCodes(paperWallet, arguments)
+		if err != nil {
+			s.logger.LogOnErrorWithContext(s.logger.GetContext(), err)
+			return err
+		}
+	}
+
+	if !arguments.PaperIsEmpty() {
+		err = s.ExportPaperWallet(paperWallet, arguments)
+		if err != nil {
+			s.logger.LogOnErrorWithContext(s.logger.GetContext(), err)
+			return err
+		}
+	}
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), err)
+	return err
+}
+
+func (s *service) VerifyWallet(arguments model.Arguments) error {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), arguments)
+
+	if arguments.AddressIsEmpty() {
+		err := errors.New("Address is required in verify mode")
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+	err := s.CheckEthereumAddressArgument(arguments)
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	wallet, err := s.DeriveWallet(arguments)
+	if err != nil {
+		s.logger.LogOnErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	expectedAddress := []byte(strings.ToLower(arguments.Address))
+	derivedAddress := []byte(strings.ToLower(wallet.Address))
+	if subtle.ConstantTimeCompare(expectedAddress, derivedAddress) != 1 {
+		err = errors.New(VERIFY_NO_MATCH)
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+	fmt.Println(VERIFY_MATCH)
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), err)
+	return err
+}
+
+func (s *service) DeriveWallet(arguments model.Arguments) (*model.Wallet, error) {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), arguments)
+
+	arguments, err := s.CheckWalletArguments(arguments)
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return nil, err
+	}
+
+	wallet, err := s.deriveCheckedWallet(arguments)
+	if err != nil {
+		s.logger.LogOnErrorWithContext(s.logger.GetContext(), err)
+		return nil, err
+	}
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), wallet, err)
+	return wallet, err
+}
+
+// CheckWalletArguments validates the output, language, salt policy, decoy
+// and vanity arguments of a derivation and returns them with the salt policy
+// applied. It also warns once when normalization changes the password or salt.
+func (s *service) CheckWalletArguments(arguments model.Arguments) (model.Arguments, error) {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), arguments)
+
+	err := s.simpleUtils.CheckIfSupported(arguments.Output, s.simpleUtils.GetSupportedOutputs())
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return arguments, err
+	}
+
+	err = s.simpleUtils.CheckIfSupported(arguments.Language, s.simpleUtils.GetSupportedLanguages())
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return arguments, err
+	}
+
+	arguments, err = s.ApplySaltPolicy(arguments)
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return arguments, err
+	}
+
+	err = s.CheckDecoyArguments(arguments)
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return arguments, err
+	}
+
+	err = s.CheckVanityArguments(arguments)
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return arguments, err
+	}
+	warnIfNormalized(arguments)
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), arguments.SaltPolicy)
+	return arguments, nil
+}
+
+// deriveCheckedWallet derives the wallet of arguments that already went
+// through CheckWalletArguments, with the decoys it lists.
+func (s *service) deriveCheckedWallet(arguments model.Arguments) (*model.Wallet, error) {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), arguments)
+
+	if arguments.Profile == DECOY_PROFILE {
+		arguments = s.DecoyArguments(arguments, arguments.DecoyIndex)
+	}
+
+	wallet, err := s.walletFromPassword(arguments)
+	if err != nil {
+		s.logger.LogOnErrorWithContext(s.logger.GetContext(), err)
+		return nil, err
+	}
+	if arguments.Decoys > 0 {
+		wallet.DecoyAddresses, err = s.DecoyAddresses(arguments)
+		if err != nil {
+			s.logger.LogOnErrorWithContext(s.logger.GetContext(), err)
+			return nil, err
+		}
+	}
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), wallet, err)
+	return wallet, err
+}
+
+// walletFromPassword runs the KDFs on validated arguments and derives the
+// wallet, searching the vanity counter first when -vanity is set.
+func (s *service) walletFromPassword(arguments model.Arguments) (*model.Wallet, error) {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), arguments)
+
 	params, err := s.GenerateAESParams(arguments)
 	if err != nil {
 		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
-		return err
+		return nil, err
 	}
 
 	entropy, err := s.cryptoRepository.AesDecrypt(params.Input, params.EncryptionKey, params.GetIV())
 	if err != nil {
 		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
-		return err
+		return nil, err
 	}
 
-	var privateKey btckey.PrivateKey
-	privateKey.FromBytes(entropy)
-	publicKey := privateKey.ToBytesUncompressed()
-	address := ethcrypto.Keccak256(publicKey[1:])[12:]
-
-	if arguments.Output == RAW_OUTPUT {
-		fmt.Printf("Ethereum Address: 0x%x\n", address)
-		fmt.Printf("Private Key: %x\n", entropy)
-	} else {
-		mnemonic, err := bip39.NewMnemonic(entropy)
+	vanityCounter := arguments.VanityCounter
+	if !arguments.VanityIsEmpty() {
+		vanityCounter, err = s.SearchVanityCounter(entropy, arguments)
 		if err != nil {
 			s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
-			return err
+			return nil, err
 		}
-		wallet, err := hdwallet.NewFromMnemonic(mnemonic)
+	}
+	if vanityCounter > 0 {
+		entropy = s.cryptoRepository.VanityEntropy(entropy, vanityCounter)
+	}
+
+	wallet, err := s.WalletFromEntropy(entropy, arguments)
+	if err != nil {
+		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+		return nil, err
+	}
+	wallet.VanityCounter = vanityCounter
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), wallet)
+	return wallet, nil
+}
+
+func (s *service) WalletFromEntropy(entropy []byte, arguments model.Arguments) (*model.Wallet, error) {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), logger.SecretBytes(entropy), arguments)
+
+	var err error
+	wallet := &model.Wallet{PrivateKey: entropy}
+	wallet.Mnemonic, err = s.mnemonicRepository.NewMnemonic(entropy, arguments.Language)
+	if err != nil {
+		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+		return nil, err
+	}
+
+	if arguments.Output != MNEMONIC_OUTPUT {
+		privateKey, err := ethcrypto.ToECDSA(entropy)
 		if err != nil {
 			s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
-			return err
+			return nil, err
 		}
-		path := hdwallet.MustParseDerivationPath("m/44'/60'/0'/0/0")
-		account, err := wallet.Derive(path, false)
+		wallet.Address = s.FormatEthereumAddress(ethcrypto.PubkeyToAddress(privateKey.PublicKey).Bytes(), arguments)
+	} else {
+		account, err := s.GetAccountFromMnemonic(wallet.Mnemonic, arguments.Language)
 		if err != nil {
 			s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
-			return err
+			return nil, err
 		}
-		fmt.Printf("Ethereum Address: %s\n", account.Address.Hex())
-		fmt.Printf("Mnemonic: %s\n", mnemonic)
+		wallet.Address = s.FormatEthereumAddress(account.Address.Bytes(), arguments)
 	}
 
-	s.logger.LogOnExitWithContext(s.logger.GetContext(), err)
-	return err
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), wallet, err)
+	return wallet, err
 }
 
 func (s *service) DecryptWallet(arguments model.Arguments) error {
@@ -111,26 +436,48 @@ func (s *service) DecryptWallet(arguments model.Arguments) error {
 		return err
 	}
 
-	err = s.ChangeMnemonicLanguageIfSupported(arguments.Language)
+	err = s.simpleUtils.CheckIfSupported(arguments.Language, s.simpleUtils.GetSupportedLanguages())
 	if err != nil {
 		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
 		return err
 	}
 
+	if arguments.Output == KEYSTORE_OUTPUT {
+		err = s.CheckKeystoreArguments(arguments)
+		if err != nil {
+			s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+			return err
+		}
+	}
+
+	if isBip38Key(arguments.Key) {
+		err = s.DecryptBip38Wallet(arguments)
+		if err != nil {
+			s.logger.LogOnErrorWithContext(s.logger.GetContext(), err)
+		}
+		return err
+	}
+
 	if (arguments.MnemonicIsEmpty() && arguments.KeyIsEmpty()) || arguments.AddressIsEmpty() {
 		err := errors.New("Private Key or Mnemonic, and address are required in decryption mode")
 		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
 		return err
 	} else if arguments.KeyIsEmpty() && !arguments.MnemonicIsEmpty() {
-		entropy, err := bip39.EntropyFromMnemonic(arguments.Mnemonic)
+		entropy, err := s.mnemonicRepository.EntropyFromMnemonic(arguments.Mnemonic, arguments.Language)
 		if err != nil {
 			s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
 			return err
 		}
 		arguments.Key = hex.EncodeToString(entropy)
 	}
+	err = s.CheckEthereumAddressArgument(arguments)
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
 
 	arguments.Salt = strings.ToLower(arguments.Address)
+	warnIfNormalized(arguments)
 	params, err := s.GenerateAESParams(arguments)
 	if err != nil {
 		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
@@ -153,20 +500,14 @@ func (s *service) DecryptWallet(arguments model.Arguments) error {
 	privateKey.FromBytes(decryptedKeyAsBytes)
 	publicKey := privateKey.ToBytesUncompressed()
 	address := ethcrypto.Keccak256(publicKey[1:])[12:]
-	mnemonic, err = bip39.NewMnemonic(decryptedKeyAsBytes)
+	mnemonic, err = s.mnemonicRepository.NewMnemonic(decryptedKeyAsBytes, arguments.Language)
 	if err != nil {
 		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
 		return err
 	}
 
 	if arguments.Output == MNEMONIC_OUTPUT {
-		wallet, err := hdwallet.NewFromMnemonic(mnemonic)
-		if err != nil {
-			s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
-			return err
-		}
-		path := hdwallet.MustParseDerivationPath("m/44'/60'/0'/0/0")
-		account, err := wallet.Derive(path, false)
+		account, err := s.GetAccountFromMnemonic(mnemonic, arguments.Language)
 		if err != nil {
 			s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
 			return err
@@ -177,10 +518,18 @@ func (s *service) DecryptWallet(arguments model.Arguments) error {
 		} else {
 			fmt.Println("Private Key does not match the provided address, or wrong output mode")
 		}
-	} else if arguments.Output == RAW_OUTPUT {
+	} else if arguments.Output == RAW_OUTPUT || arguments.Output == KEYSTORE_OUTPUT {
 		if strings.ToLower(arguments.Address) == strings.ToLower("0x"+hex.EncodeToString(address)) {
 			fmt.Println("Private Key successfully decrypted")
-			fmt.Printf("Decrypted Private Key: %x\n", decryptedKeyAsBytes)
+			if arguments.Output == KEYSTORE_OUTPUT {
+				err = s.ExportKeystore(decryptedKeyAsBytes, arguments)
+				if err != nil {
+					s.logger.LogOnErrorWithContext(s.logger.GetContext(), err)
+					return err
+				}
+			} else {
+				fmt.Printf("Decrypted Private Key: %x\n", decryptedKeyAsBytes)
+			}
 		} else {
 			fmt.Println("Private Key does not match the provided address, or wrong output mode")
 		}
@@ -202,30 +551,38 @@ func (s *service) EncryptWallet(arguments model.Arguments) error {
 		return err
 	}
 
-	err = s.ChangeMnemonicLanguageIfSupported(arguments.Language)
+	err = s.simpleUtils.CheckIfSupported(arguments.Language, s.simpleUtils.GetSupportedLanguages())
 	if err != nil {
 		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
 		return err
 	}
 
+	if arguments.Output == KEYSTORE_OUTPUT {
+		err := errors.New("Keystore output is not supported in encryption mode, use raw or mnemonic")
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	if !arguments.KeystoreIsEmpty() {
+		arguments.Key, err = s.ImportKeystore(arguments)
+		if err != nil {
+			s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+			return err
+		}
+	}
+
 	if arguments.MnemonicIsEmpty() && arguments.KeyIsEmpty() {
 		err := errors.New("Private Key or Mnemonic are required in encryption mode")
 		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
 		return err
 	} else if arguments.KeyIsEmpty() && !arguments.MnemonicIsEmpty() {
-		entropy, err := bip39.EntropyFromMnemonic(arguments.Mnemonic)
+		entropy, err := s.mnemonicRepository.EntropyFromMnemonic(arguments.Mnemonic, arguments.Language)
 		if err != nil {
 			s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
 			return err
 		}
 		arguments.Key = hex.EncodeToString(entropy)
-		wallet, err := hdwallet.NewFromMnemonic(arguments.Mnemonic)
-		if err != nil {
-			s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
-			return err
-		}
-		path := hdwallet.MustParseDerivationPath("m/44'/60'/0'/0/0")
-		account, err = wallet.Derive(path, false)
+		account, err = s.GetAccountFromMnemonic(arguments.Mnemonic, arguments.Language)
 		if err != nil {
 			s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
 			return err
@@ -240,6 +597,13 @@ func (s *service) EncryptWallet(arguments model.Arguments) error {
 		arguments.Salt = strings.ToLower("0x" + hex.EncodeToString(address))
 	}
 
+	err = s.CheckPasswordStrength(arguments)
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+	warnIfNormalized(arguments)
+
 	params, err := s.GenerateAESParams(arguments)
 	if err != nil {
 		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
@@ -254,73 +618,93 @@ func (s *service) EncryptWallet(arguments model.Arguments) error {
 
 	if arguments.Output == RAW_OUTPUT {
 		fmt.Printf("Encrypted Private Key: %x\n", encryptedKeyAsBytes)
-		fmt.Printf("Ethereum Address: %s\n", "0x"+hex.EncodeToString(address))
+		fmt.Printf("Ethereum Address: %s\n", s.FormatEthereumAddress(address, arguments))
 	} else {
-		mnemonic, err := bip39.NewMnemonic(encryptedKeyAsBytes)
+		mnemonic, err := s.mnemonicRepository.NewMnemonic(encryptedKeyAsBytes, arguments.Language)
 		if err != nil {
 			s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
 			return err
 		}
 		fmt.Printf("Encrypted Mnemonic: %s\n", mnemonic)
-		fmt.Printf("Ethereum Address: %s\n", account.Address.Hex())
+		fmt.Printf("Ethereum Address: %s\n", s.FormatEthereumAddress(account.Address.Bytes(), arguments))
+	}
+	if !arguments.KeyfileIsEmpty() {
+		fmt.Println(KEYFILE_REQUIRED)
 	}
 
 	s.logger.LogOnExitWithContext(s.logger.GetContext(), err)
 	return err
 }
 
+// warnIfNormalized tells on stderr when NFKD normalization changes the
+// password or salt. It runs once per command, not once per KDF pass, as
+// decoys and vanity searches derive many times from the same input.
+func warnIfNormalized(arguments model.Arguments) {
+	if !arguments.NoNormalize && arguments.GetNormalizedPassword() != arguments.Password {
+		fmt.Fprintf(os.Stderr, NORMALIZED_WARNING+"\n", "password")
+	}
+	if !arguments.NoNormalize && arguments.GetNormalizedSalt() != arguments.Salt {
+		fmt.Fprintf(os.Stderr, NORMALIZED_WARNING+"\n", "salt")
+	}
+}
+
 func (s *service) GenerateAESParams(arguments model.Arguments) (*model.AESParams, error) {
 	s.logger.LogOnEntryWithContext(s.logger.GetContext(), arguments)
 
 	params := new(model.AESParams)
 	var err error
 
-	params.EncryptionKey, err = s.cryptoRepository.Argon2Kdf(arguments.GetCurrencyPasswordByKdf(ARGON2), arguments.GetCurrencySaltByKdf(ARGON2), arguments.GetDifficulty())
+	argon2Password := arguments.GetCurrencyPasswordByKdf(ARGON2)
+	scryptPassword := arguments.GetCurrencyPasswordByKdf(SCRYPT)
+	if !arguments.KeyfileIsEmpty() {
+		keyfile, err := s.ReadKeyfile(arguments)
+		if err != nil {
+			s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+			return nil, err
+		}
+		argon2Password = s.cryptoRepository.KeyfilePassword(argon2Password, keyfile)
+		scryptPassword = s.cryptoRepository.KeyfilePassword(scryptPassword, keyfile)
+	}
+
+	params.EncryptionKey, err = s.cryptoRepository.Argon2Kdf(argon2Password, arguments.GetCurrencySaltByKdf(ARGON2), arguments.GetDifficulty())
 	if err != nil {
 		s.logger.LogOnErrorWithContext(s.logger.GetContext(), err)
 		return nil, err
 	}
 
-	params.Input, err = s.cryptoRepository.ScryptKdf(arguments.GetCurrencyPasswordByKdf(SCRYPT), arguments.GetCurrencySaltByKdf(SCRYPT), arguments.GetDifficulty())
+	params.Input, err = s.cryptoRepository.ScryptKdf(scryptPassword, arguments.GetCurrencySaltByKdf(SCRYPT), arguments.GetDifficulty())
 	if err != nil {
 		s.logger.LogOnErrorWithContext(s.logger.GetContext(), err)
 		return nil, err
 	}
 
-	s.logger.LogOnExitWithContext(s.logger.GetContext(), fmt.Sprintf("%x", params), err)
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), params, err)
 	return params, err
 }
 
-func (s *service) ChangeMnemonicLanguageIfSupported(language string) error {
-	s.logger.LogOnEntryWithContext(s.logger.GetContext(), language)
+func (s *service) GetAccountFromMnemonic(mnemonic string, language string) (accounts.Account, error) {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), logger.Secret(mnemonic), language)
+	var account accounts.Account
 
-	err := s.simpleUtils.CheckIfSupported(language, s.simpleUtils.GetSupportedLanguages())
+	seed, err := s.mnemonicRepository.NewSeedFromMnemonic(mnemonic, language)
 	if err != nil {
 		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
-		return err
+		return account, err
 	}
 
-	if language != ENGLISH_LANGUAGE {
-		switch language {
-		case SPANISH_LANGUAGE:
-			bip39.SetWordList(wordlist.Spanish)
-		case CHINESE_TRADITIONAL_LANGUAGE:
-			bip39.SetWordList(wordlist.ChineseTraditional)
-		case CHINESE_SIMPLIFIED_LANGUAGE:
-			bip39.SetWordList(wordlist.ChineseSimplified)
-		case CZECH_LANGUAGE:
-			bip39.SetWordList(wordlist.Czech)
-		case FRENCH_LANGUAGE:
-			bip39.SetWordList(wordlist.French)
-		case ITALIAN_LANGUAGE:
-			bip39.SetWordList(wordlist.Italian)
-		case JAPANESE_LANGUAGE:
-			bip39.SetWordList(wordlist.Japanese)
-		case KOREAN_LANGUAGE:
-			bip39.SetWordList(wordlist.Korean)
-		}
+	wallet, err := hdwallet.NewFromSeed(seed)
+	if err != nil {
+		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+		return account, err
 	}
 
-	s.logger.LogOnExitWithContext(s.logger.GetContext(), err)
-	return err
+	path := hdwallet.MustParseDerivationPath(ETHEREUM_DERIVATION_PATH)
+	account, err = wallet.Derive(path, false)
+	if err != nil {
+		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+		return account, err
+	}
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), account.Address.Hex(), err)
+	return account, err
 }
@@ -0,0 +1,301 @@
+package service
+
+import (
+	"crypto/sha256"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+
+	. "swisswallet/constants"
+	"swisswallet/logger"
+	"swisswallet/model"
+	repo "swisswallet/repository"
+	"swisswallet/utils"
+)
+
+type kdfCall struct {
+	kdf        int
+	password   string
+	salt       string
+	difficulty string
+}
+
+// fakeCryptoRepository replaces argon2 and scrypt with a single SHA256 so that
+// the service can be driven through every difficulty in milliseconds. The
+// remaining primitives are the real ones.
+type fakeCryptoRepository struct {
+	repo.CryptoRepository
+	mutex sync.Mutex
+	calls []kdfCall
+	err   error
+}
+
+func (f *fakeCryptoRepository) fakeKdf(kdf int, password string, salt string, difficulty string) ([]byte, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.calls = append(f.calls, kdfCall{kdf, password, salt, difficulty})
+	if f.err != nil {
+		return nil, f.err
+	}
+	hash := sha256.Sum256([]byte(string(rune('0'+kdf)) + "\x00" + password + "\x00" + salt + "\x00" + difficulty))
+	return hash[:], nil
+}
+
+func (f *fakeCryptoRepository) Argon2Kdf(password string, salt string, difficulty string) ([]byte, error) {
+	return f.fakeKdf(ARGON2, password, salt, difficulty)
+}
+
+func (f *fakeCryptoRepository) ScryptKdf(password string, salt string, difficulty string) ([]byte, error) {
+	return f.fakeKdf(SCRYPT, password, salt, difficulty)
+}
+
+// fakeSimpleUtils rejects the values listed in unsupported, answers secret
+// prompts from secrets in order and defers to the real utils for everything
+// else.
+type fakeSimpleUtils struct {
+	utils.SimpleUtils
+	unsupported map[string]bool
+	secrets     []string
+	prompts     []string
+}
+
+func (f *fakeSimpleUtils) ReadSecret(prompt string) (string, error) {
+	f.prompts = append(f.prompts, prompt)
+	if len(f.secrets) == 0 {
+		return "", errors.New("no secret left for: " + prompt)
+	}
+	secret := f.secrets[0]
+	f.secrets = f.secrets[1:]
+	return secret, nil
+}
+
+func (f *fakeSimpleUtils) CheckIfSupported(str string, supportedStrArray []string) error {
+	if f.unsupported[str] {
+		return errors.New("unsupported: " + str)
+	}
+	return f.SimpleUtils.CheckIfSupported(str, supportedStrArray)
+}
+
+func newTestLogger() *logger.Logger {
+	l := logger.NewLogger()
+	l.SetOutput(ioutil.Discard)
+	return l
+}
+
+// captureOutput returns what run writes to output, os.Stdout or os.Stderr.
+func captureOutput(t *testing.T, output **os.File, run func()) string {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := *output
+	*output = writer
+	defer func() { *output = original }()
+
+	written := make(chan []byte)
+	go func() {
+		content, _ := ioutil.ReadAll(reader)
+		written <- content
+	}()
+	run()
+	writer.Close()
+	return string(<-written)
+}
+
+func newTestService(cryptoRepository repo.CryptoRepository, simpleUtils utils.SimpleUtils) *service {
+	l := newTestLogger()
+	if cryptoRepository == nil {
+		cryptoRepository = repo.NewCryptoRepository(l)
+	}
+	if simpleUtils == nil {
+		simpleUtils = utils.NewSimpleUtils(l)
+	}
+	return NewService(cryptoRepository, repo.NewMnemonicRepository(l), repo.NewShamirRepository(l), repo.NewDescriptorRepository(l), repo.NewQrRepository(l), repo.NewPaperRepository(l), repo.NewPsbtRepository(l), repo.NewMessageRepository(l), repo.NewAddressRepository(l), simpleUtils, l).(*service)
+}
+
+func newFakeService() (*service, *fakeCryptoRepository, *fakeSimpleUtils) {
+	l := newTestLogger()
+	fakeCrypto := &fakeCryptoRepository{CryptoRepository: repo.NewCryptoRepository(l)}
+	fakeUtils := &fakeSimpleUtils{SimpleUtils: utils.NewSimpleUtils(l), unsupported: map[string]bool{}}
+	return newTestService(fakeCrypto, fakeUtils), fakeCrypto, fakeUtils
+}
+
+func testArguments(currency string, difficulty string, language string, output string) model.Arguments {
+	return model.Arguments{
+		Password:     "correct horse battery staple",
+		Salt:         "satoshi@example.com",
+		Currency:     currency,
+		Difficulty:   difficulty,
+		Language:     language,
+		Output:       output,
+		SaltPolicy:   REQUIRED_SALT_POLICY,
+		Profile:      REAL_PROFILE,
+		DecoyIndex:   1,
+		DuressSuffix: DEFAULT_DURESS_SUFFIX,
+	}
+}
+
+func TestDeriveWalletPassesCurrencyAndDifficultyToKdfs(t *testing.T) {
+	s, fakeCrypto, _ := newFakeService()
+
+	for currency := range CurrencyCode {
+		if currency == "unknown" {
+			continue
+		}
+		for _, difficulty := range s.simpleUtils.GetSupportedDifficulties() {
+			fakeCrypto.calls = nil
+			arguments := testArguments(currency, difficulty, ENGLISH_LANGUAGE, RAW_OUTPUT)
+
+			_, err := s.DeriveWallet(arguments)
+			if err != nil {
+				t.Fatalf("DeriveWallet(%s, %s): %v", currency, difficulty, err)
+			}
+
+			expected := []kdfCall{
+				{ARGON2, arguments.GetCurrencyPasswordByKdf(ARGON2), arguments.GetCurrencySaltByKdf(ARGON2), difficulty},
+				{SCRYPT, arguments.GetCurrencyPasswordByKdf(SCRYPT), arguments.GetCurrencySaltByKdf(SCRYPT), difficulty},
+			}
+			if len(fakeCrypto.calls) != len(expected) {
+				t.Fatalf("DeriveWallet(%s, %s) called the KDFs %d times, want %d", currency, difficulty, len(fakeCrypto.calls), len(expected))
+			}
+			for i := range expected {
+				if fakeCrypto.calls[i] != expected[i] {
+					t.Errorf("DeriveWallet(%s, %s) KDF call %d = %+v, want %+v", currency, difficulty, i, fakeCrypto.calls[i], expected[i])
+				}
+			}
+		}
+	}
+}
+
+func TestDeriveWalletCurrenciesAreIndependent(t *testing.T) {
+	s, _, _ := newFakeService()
+
+	addresses := map[string]string{}
+	for currency := range CurrencyCode {
+		if currency == "unknown" {
+			continue
+		}
+		wallet, err := s.DeriveWallet(testArguments(currency, MINIMUM_DIFFICULTY, ENGLISH_LANGUAGE, RAW_OUTPUT))
+		if err != nil {
+			t.Fatalf("DeriveWallet(%s): %v", currency, err)
+		}
+		if other, ok := addresses[wallet.Address]; ok {
+			t.Errorf("%s and %s derive the same address %s", currency, other, wallet.Address)
+		}
+		addresses[wallet.Address] = currency
+	}
+}
+
+func TestDeriveWalletRejectsUnsupportedArguments(t *testing.T) {
+	for _, unsupported := range []string{RAW_OUTPUT, ENGLISH_LANGUAGE} {
+		s, fakeCrypto, fakeUtils := newFakeService()
+		fakeUtils.unsupported[unsupported] = true
+
+		_, err := s.DeriveWallet(testArguments("ethereum", MINIMUM_DIFFICULTY, ENGLISH_LANGUAGE, RAW_OUTPUT))
+		if err == nil {
+			t.Errorf("DeriveWallet accepted unsupported %s", unsupported)
+		}
+		if len(fakeCrypto.calls) != 0 {
+			t.Errorf("DeriveWallet ran the KDFs before rejecting unsupported %s", unsupported)
+		}
+	}
+}
+
+func TestDeriveWalletReturnsKdfErrors(t *testing.T) {
+	s, fakeCrypto, _ := newFakeService()
+	fakeCrypto.err = errors.New("out of memory")
+
+	wallet, err := s.DeriveWallet(testArguments("ethereum", MINIMUM_DIFFICULTY, ENGLISH_LANGUAGE, RAW_OUTPUT))
+	if err != fakeCrypto.err || wallet != nil {
+		t.Errorf("DeriveWallet = %v, %v, want nil, %v", wallet, err, fakeCrypto.err)
+	}
+}
+
+func TestDeriveWalletOutputs(t *testing.T) {
+	s, _, _ := newFakeService()
+
+	raw, err := s.DeriveWallet(testArguments("ethereum", MINIMUM_DIFFICULTY, ENGLISH_LANGUAGE, RAW_OUTPUT))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keystore, err := s.DeriveWallet(testArguments("ethereum", MINIMUM_DIFFICULTY, ENGLISH_LANGUAGE, KEYSTORE_OUTPUT))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mnemonic, err := s.DeriveWallet(testArguments("ethereum", MINIMUM_DIFFICULTY, ENGLISH_LANGUAGE, MNEMONIC_OUTPUT))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if keystore.Address != raw.Address {
+		t.Errorf("keystore output derives %s, raw output derives %s", keystore.Address, raw.Address)
+	}
+	if mnemonic.Address == raw.Address {
+		t.Errorf("mnemonic output derives the raw key address %s instead of the BIP44 one", raw.Address)
+	}
+
+	entropy, err := s.mnemonicRepository.EntropyFromMnemonic(mnemonic.Mnemonic, ENGLISH_LANGUAGE)
+	if err != nil || string(entropy) != string(raw.PrivateKey) {
+		t.Errorf("mnemonic does not encode the derived entropy: %v", err)
+	}
+}
+
+// TestDeriveWalletConcurrentLanguages derives spanish and japanese wallets
+// from many goroutines at once and compares them with the ones derived one at
+// a time, run it with -race.
+func TestDeriveWalletConcurrentLanguages(t *testing.T) {
+	s, _, _ := newFakeService()
+	languages := []string{SPANISH_LANGUAGE, JAPANESE_LANGUAGE}
+	entropy := sha256.Sum256([]byte("concurrent languages"))
+
+	expected := map[string][2]*model.Wallet{}
+	for _, language := range languages {
+		derived, err := s.DeriveWallet(testArguments("ethereum", MINIMUM_DIFFICULTY, language, MNEMONIC_OUTPUT))
+		if err != nil {
+			t.Fatal(err)
+		}
+		fromEntropy, err := s.WalletFromEntropy(entropy[:], testArguments("ethereum", MINIMUM_DIFFICULTY, language, MNEMONIC_OUTPUT))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, wallet := range []*model.Wallet{derived, fromEntropy} {
+			_, err = s.mnemonicRepository.EntropyFromMnemonic(wallet.Mnemonic, language)
+			if err != nil {
+				t.Fatalf("%s mnemonic %q: %v", language, wallet.Mnemonic, err)
+			}
+		}
+		expected[language] = [2]*model.Wallet{derived, fromEntropy}
+	}
+	if expected[SPANISH_LANGUAGE][0].Mnemonic == expected[JAPANESE_LANGUAGE][0].Mnemonic {
+		t.Fatal("spanish and japanese give the same mnemonic")
+	}
+
+	var wg sync.WaitGroup
+	for round := 0; round < 20; round++ {
+		for _, language := range languages {
+			wg.Add(1)
+			go func(language string) {
+				defer wg.Done()
+				arguments := testArguments("ethereum", MINIMUM_DIFFICULTY, language, MNEMONIC_OUTPUT)
+				derived, err := s.DeriveWallet(arguments)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				fromEntropy, err := s.WalletFromEntropy(entropy[:], arguments)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				for i, wallet := range []*model.Wallet{derived, fromEntropy} {
+					if wallet.Mnemonic != expected[language][i].Mnemonic || wallet.Address != expected[language][i].Address {
+						t.Errorf("%s wallet %d = %q %s, want %q %s", language, i, wallet.Mnemonic, wallet.Address, expected[language][i].Mnemonic, expected[language][i].Address)
+					}
+				}
+			}(language)
+		}
+	}
+	wg.Wait()
+}
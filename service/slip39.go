@@ -0,0 +1,161 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	. "swisswallet/constants"
+	"swisswallet/logger"
+	"swisswallet/model"
+
+	hdwallet "github.com/miguelmota/go-ethereum-hdwallet"
+)
+
+// ParseSlip39Groups reads groups written as "2of3,3of5", member threshold
+// first.
+func (s *service) ParseSlip39Groups(groups string) ([]model.Slip39Group, error) {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), groups)
+
+	var slip39Groups []model.Slip39Group
+	for _, group := range strings.Split(groups, ",") {
+		parts := strings.Split(strings.TrimSpace(group), "of")
+		if len(parts) != 2 {
+			err := errors.New(fmt.Sprintf("Invalid SLIP-0039 group, expected thresholdofcount like 2of3: %s", group))
+			s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+			return nil, err
+		}
+		threshold, thresholdErr := strconv.Atoi(parts[0])
+		count, countErr := strconv.Atoi(parts[1])
+		if thresholdErr != nil || countErr != nil {
+			err := errors.New(fmt.Sprintf("Invalid SLIP-0039 group, expected thresholdofcount like 2of3: %s", group))
+			s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+			return nil, err
+		}
+		slip39Groups = append(slip39Groups, model.Slip39Group{MemberThreshold: threshold, MemberCount: count})
+	}
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), slip39Groups)
+	return slip39Groups, nil
+}
+
+func (s *service) SplitWallet(arguments model.Arguments) error {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), arguments)
+
+	groups, err := s.ParseSlip39Groups(arguments.Slip39Groups)
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	wallet, err := s.DeriveWallet(arguments)
+	if err != nil {
+		s.logger.LogOnErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	mnemonics, err := s.shamirRepository.SplitMnemonics(wallet.PrivateKey, arguments.Slip39Passphrase, arguments.Slip39GroupThreshold, groups, arguments.Slip39IterationExponent)
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	fmt.Printf("Ethereum Address: %s\n", wallet.Address)
+	fmt.Printf("SLIP-0039 shares, any %d of %d groups are needed:\n", arguments.Slip39GroupThreshold, len(groups))
+	for groupIndex, groupMnemonics := range mnemonics {
+		fmt.Printf("Group %d, any %d of %d shares:\n", groupIndex+1, groups[groupIndex].MemberThreshold, groups[groupIndex].MemberCount)
+		for memberIndex, mnemonic := range groupMnemonics {
+			fmt.Printf("Share %d-%d: %s\n", groupIndex+1, memberIndex+1, mnemonic)
+		}
+	}
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), err)
+	return err
+}
+
+func (s *service) CombineWallet(arguments model.Arguments) error {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), arguments)
+
+	err := s.simpleUtils.CheckIfSupported(arguments.Output, []string{RAW_OUTPUT, MNEMONIC_OUTPUT})
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	err = s.simpleUtils.CheckIfSupported(arguments.Language, s.simpleUtils.GetSupportedLanguages())
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	err = s.simpleUtils.CheckIfSupported(arguments.Slip39Secret, s.simpleUtils.GetSupportedSlip39Secrets())
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	if arguments.Slip39Secret == SEED_SLIP39_SECRET && arguments.Output != RAW_OUTPUT {
+		err = errors.New("A BIP32 seed has no mnemonic, use raw output with -slip39-secret " + SEED_SLIP39_SECRET)
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	masterSecret, err := s.shamirRepository.CombineMnemonics(arguments.Shares, arguments.Slip39Passphrase)
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	var wallet *model.Wallet
+	if arguments.Slip39Secret == SEED_SLIP39_SECRET {
+		wallet, err = s.walletFromSeed(masterSecret, arguments)
+	} else if len(masterSecret) != SLIP39_MASTER_SECRET_LENGTH {
+		err = errors.New(fmt.Sprintf("The shares hold a %d bytes secret, SwissWallet entropy is %d bytes. Use -slip39-secret %s for shares from another wallet", len(masterSecret), SLIP39_MASTER_SECRET_LENGTH, SEED_SLIP39_SECRET))
+	} else {
+		wallet, err = s.WalletFromEntropy(masterSecret, arguments)
+	}
+	if err != nil {
+		s.logger.LogOnErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	fmt.Printf("Master Secret: %x\n", masterSecret)
+	fmt.Printf("Ethereum Address: %s\n", wallet.Address)
+	if arguments.Output == RAW_OUTPUT {
+		fmt.Printf("Private Key: %x\n", wallet.PrivateKey)
+	} else {
+		fmt.Printf("Mnemonic: %s\n", wallet.Mnemonic)
+	}
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), err)
+	return err
+}
+
+// walletFromSeed treats the master secret as a BIP32 seed, the way Trezor and
+// other SLIP-0039 wallets use it, and derives the first Ethereum account.
+func (s *service) walletFromSeed(seed []byte, arguments model.Arguments) (*model.Wallet, error) {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), logger.SecretBytes(seed), arguments)
+
+	hdWallet, err := hdwallet.NewFromSeed(seed)
+	if err != nil {
+		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+		return nil, err
+	}
+	account, err := hdWallet.Derive(hdwallet.MustParseDerivationPath(ETHEREUM_DERIVATION_PATH), false)
+	if err != nil {
+		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+		return nil, err
+	}
+	privateKey, err := hdWallet.PrivateKeyBytes(account)
+	if err != nil {
+		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+		return nil, err
+	}
+	wallet := &model.Wallet{
+		Address:    s.FormatEthereumAddress(account.Address.Bytes(), arguments),
+		PrivateKey: privateKey,
+	}
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), wallet, err)
+	return wallet, err
+}
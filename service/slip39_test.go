@@ -0,0 +1,65 @@
+package service
+
+import (
+	"testing"
+
+	. "swisswallet/constants"
+	"swisswallet/model"
+)
+
+// SLIP-0039 vector 1, a 128 bits master secret as Trezor would split it.
+var trezorShares = []string{"duckling enlarge academic academic agency result length solution fridge kidney coal piece deal husband erode duke ajar critical decision keyboard"}
+
+func TestCombineWalletSecretInterpretation(t *testing.T) {
+	s, _, _ := newFakeService()
+
+	tests := []struct {
+		secret  string
+		output  string
+		wantErr bool
+	}{
+		{ENTROPY_SLIP39_SECRET, RAW_OUTPUT, true},
+		{SEED_SLIP39_SECRET, RAW_OUTPUT, false},
+		{SEED_SLIP39_SECRET, MNEMONIC_OUTPUT, true},
+		{"guess", RAW_OUTPUT, true},
+	}
+	for _, test := range tests {
+		arguments := model.Arguments{Output: test.output, Language: ENGLISH_LANGUAGE, Shares: trezorShares, Slip39Passphrase: "TREZOR", Slip39Secret: test.secret}
+		err := s.CombineWallet(arguments)
+		if (err != nil) != test.wantErr {
+			t.Errorf("CombineWallet(%s, %s) = %v, want error %v", test.secret, test.output, err, test.wantErr)
+		}
+	}
+}
+
+func TestSplitCombineWallet(t *testing.T) {
+	s, _, _ := newFakeService()
+	arguments := testArguments("ethereum", MINIMUM_DIFFICULTY, ENGLISH_LANGUAGE, MNEMONIC_OUTPUT)
+	arguments.Slip39Passphrase = "TREZOR"
+	arguments.Slip39Secret = ENTROPY_SLIP39_SECRET
+
+	wallet, err := s.DeriveWallet(arguments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	groups := []model.Slip39Group{{MemberThreshold: 2, MemberCount: 3}}
+	mnemonics, err := s.shamirRepository.SplitMnemonics(wallet.PrivateKey, arguments.Slip39Passphrase, 1, groups, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	masterSecret, err := s.shamirRepository.CombineMnemonics(mnemonics[0][1:], arguments.Slip39Passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	combined, err := s.WalletFromEntropy(masterSecret, arguments)
+	if err != nil || combined.Address != wallet.Address || combined.Mnemonic != wallet.Mnemonic {
+		t.Errorf("combined wallet %v, %v, want %v", combined, err, wallet)
+	}
+
+	arguments.Shares = mnemonics[0][:2]
+	err = s.CombineWallet(arguments)
+	if err != nil {
+		t.Errorf("CombineWallet: %v", err)
+	}
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strings"
+	. "swisswallet/constants"
+	"swisswallet/model"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	hdwallet "github.com/miguelmota/go-ethereum-hdwallet"
+)
+
+// GetEthereumPrivateKey returns the key behind wallet.Address: the raw key for
+// raw and keystore output, or the first account of the mnemonic.
+func (s *service) GetEthereumPrivateKey(wallet *model.Wallet, arguments model.Arguments) ([]byte, error) {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), wallet, arguments)
+
+	if arguments.Output != MNEMONIC_OUTPUT {
+		s.logger.LogOnExitWithContext(s.logger.GetContext())
+		return wallet.PrivateKey, nil
+	}
+
+	seed, err := s.mnemonicRepository.NewSeedFromMnemonic(wallet.Mnemonic, arguments.Language)
+	if err != nil {
+		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+		return nil, err
+	}
+	hdWallet, err := hdwallet.NewFromSeed(seed)
+	if err != nil {
+		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+		return nil, err
+	}
+	account, err := hdWallet.Derive(hdwallet.MustParseDerivationPath(ETHEREUM_DERIVATION_PATH), false)
+	if err != nil {
+		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+		return nil, err
+	}
+	privateKey, err := hdWallet.PrivateKeyBytes(account)
+	if err != nil {
+		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+		return nil, err
+	}
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), err)
+	return privateKey, err
+}
+
+func (s *service) ReadUnsignedTransaction(arguments model.Arguments) (*types.Transaction, error) {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), arguments)
+
+	if arguments.TransactionIsEmpty() == arguments.TransactionFileIsEmpty() {
+		err := errors.New("Exactly one of -tx or -tx-file is required to sign a transaction")
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return nil, err
+	}
+
+	if arguments.ChainID <= 0 {
+		err := errors.New("Chain ID must be positive")
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return nil, err
+	}
+
+	input := arguments.Transaction
+	if !arguments.TransactionFileIsEmpty() {
+		content, err := ioutil.ReadFile(arguments.TransactionFile)
+		if err != nil {
+			s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+			return nil, err
+		}
+		input = string(content)
+	}
+
+	tx, err := s.cryptoRepository.ParseUnsignedTransaction(input, big.NewInt(arguments.ChainID))
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return nil, err
+	}
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), tx.Type(), err)
+	return tx, err
+}
+
+// SignTransaction regenerates the key from the password and salt and prints
+// the transaction fields next to the raw signed transaction, so they can be
+// checked before it is broadcast from another machine.
+func (s *service) SignTransaction(arguments model.Arguments) error {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), arguments)
+
+	tx, err := s.ReadUnsignedTransaction(arguments)
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+	err = s.CheckEthereumAddressArgument(arguments)
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	wallet, err := s.DeriveWallet(arguments)
+	if err != nil {
+		s.logger.LogOnErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+	if !arguments.AddressIsEmpty() && !strings.EqualFold(arguments.Address, wallet.Address) {
+		err = errors.New(fmt.Sprintf("Password and salt derive %s, not %s", wallet.Address, arguments.Address))
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	privateKey, err := s.GetEthereumPrivateKey(wallet, arguments)
+	if err != nil {
+		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	chainID := big.NewInt(arguments.ChainID)
+	signedTx, err := s.cryptoRepository.SignTransaction(tx, chainID, privateKey)
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+	sender, err := types.Sender(types.NewLondonSigner(chainID), signedTx)
+	if err != nil || !strings.EqualFold(sender.Hex(), wallet.Address) {
+		err = errors.New("Signed transaction does not recover to the derived address")
+		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+	rawTx, err := signedTx.MarshalBinary()
+	if err != nil {
+		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	fmt.Printf("From: %s\n", s.FormatEthereumAddress(sender.Bytes(), arguments))
+	if signedTx.To() == nil {
+		fmt.Println("To: contract creation")
+	} else {
+		fmt.Printf("To: %s\n", s.FormatEthereumAddress(signedTx.To().Bytes(), arguments))
+	}
+	fmt.Printf("Value: %s wei\n", signedTx.Value())
+	fmt.Printf("Nonce: %d\n", signedTx.Nonce())
+	fmt.Printf("Gas Limit: %d\n", signedTx.Gas())
+	if signedTx.Type() == types.DynamicFeeTxType {
+		fmt.Printf("Max Fee Per Gas: %s wei\n", signedTx.GasFeeCap())
+		fmt.Printf("Max Priority Fee Per Gas: %s wei\n", signedTx.GasTipCap())
+	} else {
+		fmt.Printf("Gas Price: %s wei\n", signedTx.GasPrice())
+	}
+	fmt.Printf("Max Fee: %s wei\n", new(big.Int).Mul(signedTx.GasFeeCap(), new(big.Int).SetUint64(signedTx.Gas())))
+	fmt.Printf("Data: %d bytes\n", len(signedTx.Data()))
+	fmt.Printf("Chain ID: %s\n", chainID)
+	fmt.Printf("Transaction Hash: %s\n", signedTx.Hash().Hex())
+	fmt.Printf("Signed Transaction: %s\n", hexutil.Encode(rawTx))
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), signedTx.Hash().Hex(), err)
+	return err
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	. "swisswallet/constants"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+var testUnsignedTransactions = []string{
+	`{"nonce":"0x9","gasPrice":"0x4a817c800","gas":"0x5208","to":"0x3535353535353535353535353535353535353535","value":"0xde0b6b3a7640000","chainId":"0x1"}`,
+	"0xe9098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a764000080",
+	`{"type":"0x1","chainId":"0x1","nonce":"0x9","gasPrice":"0x4a817c800","gas":"0x7530","to":"0x3535353535353535353535353535353535353535","value":"0xde0b6b3a7640000","data":"0x1234","accessList":[]}`,
+	"0x02f0010984773594008506fc23ac00825208943535353535353535353535353535353535353535880de0b6b3a764000080c0",
+}
+
+func TestSignTransactionRecoversToTheDerivedKey(t *testing.T) {
+	s, _, _ := newFakeService()
+
+	for _, output := range []string{RAW_OUTPUT, MNEMONIC_OUTPUT} {
+		arguments := testArguments("ethereum", MINIMUM_DIFFICULTY, ENGLISH_LANGUAGE, output)
+		arguments.ChainID = 1
+		wallet, err := s.DeriveWallet(arguments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		privateKey, err := s.GetEthereumPrivateKey(wallet, arguments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ecdsaKey, err := ethcrypto.ToECDSA(privateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyAddress := ethcrypto.PubkeyToAddress(ecdsaKey.PublicKey)
+
+		for _, unsigned := range testUnsignedTransactions {
+			arguments.Transaction = unsigned
+			arguments.Address = wallet.Address
+			var err error
+			stdout := captureOutput(t, &os.Stdout, func() {
+				err = s.SignTransaction(arguments)
+			})
+			if err != nil {
+				t.Fatalf("%s %.30s: %v", output, unsigned, err)
+			}
+
+			var raw string
+			for _, line := range strings.Split(stdout, "\n") {
+				if strings.HasPrefix(line, "Signed Transaction: ") {
+					raw = strings.TrimPrefix(line, "Signed Transaction: ")
+				}
+			}
+			encoded, err := hexutil.Decode(raw)
+			if err != nil {
+				t.Fatalf("%s %.30s: no signed transaction in %q", output, unsigned, stdout)
+			}
+			var signedTx types.Transaction
+			err = signedTx.UnmarshalBinary(encoded)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sender, err := types.Sender(types.NewLondonSigner(signedTx.ChainId()), &signedTx)
+			if err != nil || sender != keyAddress || !strings.EqualFold(sender.Hex(), wallet.Address) {
+				t.Errorf("%s %.30s: sender %s, %v, want %s", output, unsigned, sender.Hex(), err, keyAddress.Hex())
+			}
+			if signedTx.ChainId().Int64() != 1 {
+				t.Errorf("%s %.30s: chain ID %s, want 1", output, unsigned, signedTx.ChainId())
+			}
+		}
+	}
+}
+
+func TestSignTransactionRejects(t *testing.T) {
+	s, _, _ := newFakeService()
+
+	tests := []struct {
+		name        string
+		transaction string
+		chainID     int64
+		address     string
+		wantErr     string
+	}{
+		{"chain ID mismatch", testUnsignedTransactions[0], 5, "", "does not match the chain ID 5"},
+		{"typed chain ID mismatch", testUnsignedTransactions[3], 5, "", "does not match the chain ID 5"},
+		{"signed legacy", "0xf86c098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a76400008025a028ef61340bd939bc2195fe537567866003e1a15d3c71ff63e1590620aa636276a067cbe9d8997f761aecb703304b3800ccf555c9f3dc64214b297fb1966a3b6d83", 1, "", "already signed"},
+		{"signed EIP-1559", "0x02f873010984773594008506fc23ac00825208943535353535353535353535353535353535353535880de0b6b3a764000080c080a02b03b67e070f45175ce9d07c4512720168bd468a24edb6997977a53d48c87a12a0733d775fdd689d306e08ac8ab399f34b5a0253b47ed81b8bf2d2a6ea607fcac7", 1, "", "already signed"},
+		{"other address", testUnsignedTransactions[0], 1, "0x9d8A62f656a8d1615C1294fd71e9CFb3E4855A4F", "not 0x9d8A62f656a8d1615C1294fd71e9CFb3E4855A4F"},
+		{"no transaction", "", 1, "", "Exactly one of -tx or -tx-file"},
+		{"no chain ID", testUnsignedTransactions[0], 0, "", "Chain ID must be positive"},
+	}
+	for _, test := range tests {
+		arguments := testArguments("ethereum", MINIMUM_DIFFICULTY, ENGLISH_LANGUAGE, RAW_OUTPUT)
+		arguments.Transaction, arguments.ChainID, arguments.Address = test.transaction, test.chainID, test.address
+		var err error
+		stdout := captureOutput(t, &os.Stdout, func() {
+			err = s.SignTransaction(arguments)
+		})
+		if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+			t.Errorf("%s: %v, want %q", test.name, err, test.wantErr)
+		}
+		if strings.Contains(stdout, "Signed Transaction") {
+			t.Errorf("%s: printed a signed transaction: %s", test.name, stdout)
+		}
+	}
+}
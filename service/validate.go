@@ -0,0 +1,65 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"swisswallet/model"
+)
+
+// ValidateAddress checks the address given with -a against the format and
+// checksum of the currency, e.g. before sending funds to it.
+func (s *service) ValidateAddress(arguments model.Arguments) error {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), arguments)
+
+	if arguments.AddressIsEmpty() {
+		err := errors.New("An address to validate is required with -a")
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	info, err := s.addressRepository.ValidateAddress(arguments.Address, arguments.Currency, arguments.ChecksumChainID)
+	if err != nil {
+		err = errors.New(fmt.Sprintf("Invalid %s address: %s", arguments.Currency, err))
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	fmt.Printf("Address: %s\n", info.Address)
+	fmt.Printf("Network: %s\n", info.Network)
+	fmt.Printf("Address Type: %s\n", info.Type)
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), info, err)
+	return err
+}
+
+// FormatEthereumAddress returns the address with the EIP-55 checksum, or the
+// EIP-1191 one when -checksum-chain-id is set.
+func (s *service) FormatEthereumAddress(address []byte, arguments model.Arguments) string {
+	return s.addressRepository.ChecksumEthereumAddress(address, arguments.ChecksumChainID)
+}
+
+// CheckEthereumAddressArgument rejects a mixed case -a address whose checksum
+// does not match, so a typo is caught before the key derivation runs.
+func (s *service) CheckEthereumAddressArgument(arguments model.Arguments) error {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), arguments)
+
+	if arguments.ChecksumChainID < 0 {
+		err := errors.New("Checksum chain ID cannot be negative")
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+	if arguments.AddressIsEmpty() {
+		s.logger.LogOnExitWithContext(s.logger.GetContext())
+		return nil
+	}
+
+	_, err := s.addressRepository.ValidateAddress(arguments.Address, "ethereum", arguments.ChecksumChainID)
+	if err != nil {
+		err = errors.New(fmt.Sprintf("Invalid address %s: %s", arguments.Address, err))
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), err)
+	return err
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"runtime"
+	"strings"
+	. "swisswallet/constants"
+	"swisswallet/logger"
+	"swisswallet/model"
+	"sync"
+	"sync/atomic"
+
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	hdwallet "github.com/miguelmota/go-ethereum-hdwallet"
+	"github.com/tyler-smith/go-bip39"
+)
+
+const vanityWildcard = "*"
+const vanityHexCharacters = "0123456789abcdef"
+
+// ParseVanityPattern splits a vanity pattern into the lowercase hex prefix and
+// suffix the address, without 0x, must have.
+func (s *service) ParseVanityPattern(pattern string) (string, string, error) {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), pattern)
+
+	parts := strings.Split(strings.ToLower(strings.TrimPrefix(pattern, "0x")), vanityWildcard)
+	if len(parts) > 2 {
+		err := errors.New(fmt.Sprintf("Vanity pattern can have only one %s: %s", vanityWildcard, pattern))
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return "", "", err
+	}
+	prefix, suffix := parts[0], ""
+	if len(parts) == 2 {
+		suffix = parts[1]
+	}
+
+	if prefix+suffix == "" || len(prefix+suffix) > 40 || strings.Trim(prefix+suffix, vanityHexCharacters) != "" {
+		err := errors.New(fmt.Sprintf("Vanity pattern must be 1 to 40 hex characters: %s", pattern))
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return "", "", err
+	}
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), prefix, suffix)
+	return prefix, suffix, nil
+}
+
+// CheckVanityArguments validates -vanity and -vanity-counter before the KDF
+// runs. The pattern length is capped so the expected search stays within
+// minutes, mnemonic output costs a BIP39 seed stretch per try and gets a
+// shorter cap.
+func (s *service) CheckVanityArguments(arguments model.Arguments) error {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), arguments)
+
+	if arguments.VanityIsEmpty() {
+		s.logger.LogOnExitWithContext(s.logger.GetContext())
+		return nil
+	}
+	if arguments.VanityCounter > 0 {
+		err := errors.New("Use -vanity to search for a vanity counter or -vanity-counter to reuse one, not both")
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+	prefix, suffix, err := s.ParseVanityPattern(arguments.Vanity)
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+	maxLength := MAX_VANITY_LENGTH
+	if arguments.Output == MNEMONIC_OUTPUT {
+		maxLength = MAX_MNEMONIC_VANITY_LENGTH
+	}
+	if len(prefix+suffix) > maxLength {
+		err = errors.New(fmt.Sprintf("Vanity pattern can have at most %d hex characters with -o %s, %s has %d", maxLength, arguments.Output, arguments.Vanity, len(prefix+suffix)))
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return err
+	}
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), err)
+	return err
+}
+
+// vanityAddresser computes the lowercase hex address of a vanity candidate,
+// the same address WalletFromEntropy derives, without the mnemonic checks and
+// the logging of the full derivation, as it runs once per try. The mnemonic
+// comes from the mnemonic repository, so it is encoded exactly as the wallet's.
+type vanityAddresser func(entropy []byte) (string, error)
+
+func (s *service) newVanityAddresser(arguments model.Arguments) vanityAddresser {
+	if arguments.Output != MNEMONIC_OUTPUT {
+		return func(entropy []byte) (string, error) {
+			privateKey, err := ethcrypto.ToECDSA(entropy)
+			if err != nil {
+				return "", err
+			}
+			return hex.EncodeToString(ethcrypto.PubkeyToAddress(privateKey.PublicKey).Bytes()), nil
+		}
+	}
+
+	path := hdwallet.MustParseDerivationPath(ETHEREUM_DERIVATION_PATH)
+	return func(entropy []byte) (string, error) {
+		mnemonic, err := s.mnemonicRepository.NewMnemonic(entropy, arguments.Language)
+		if err != nil {
+			return "", err
+		}
+		seed := bip39.NewSeed(mnemonic, "")
+		wallet, err := hdwallet.NewFromSeed(seed)
+		if err != nil {
+			return "", err
+		}
+		account, err := wallet.Derive(path, false)
+		if err != nil {
+			return "", err
+		}
+		return hex.EncodeToString(account.Address.Bytes()), nil
+	}
+}
+
+// SearchVanityCounter tries counters from 1 up on every CPU core and returns
+// the lowest one whose wallet address matches -vanity. Each core takes every
+// n-th counter and the search only ends once all counters below the best match
+// were tried, so the result does not depend on the number of cores. The search
+// gives up after VANITY_MAX_TRIES_FACTOR times the expected number of tries.
+func (s *service) SearchVanityCounter(entropy []byte, arguments model.Arguments) (uint64, error) {
+	s.logger.LogOnEntryWithContext(s.logger.GetContext(), logger.SecretBytes(entropy), arguments)
+
+	prefix, suffix, err := s.ParseVanityPattern(arguments.Vanity)
+	if err != nil {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return 0, err
+	}
+	addressOf := s.newVanityAddresser(arguments)
+
+	workers := runtime.NumCPU()
+	expectedTries := uint64(1) << (4 * uint(len(prefix+suffix)))
+	maxTries := expectedTries * VANITY_MAX_TRIES_FACTOR
+	fmt.Fprintf(os.Stderr, "Searching for a vanity address matching %s*%s, about %d tries expected, CPU cores used: %d\n", prefix, suffix, expectedTries, workers)
+
+	best := uint64(math.MaxUint64)
+	var searchErr error
+	var errOnce sync.Once
+	var wg sync.WaitGroup
+	for worker := 0; worker < workers; worker++ {
+		wg.Add(1)
+		go func(start uint64) {
+			defer wg.Done()
+			for counter := start; counter <= maxTries && counter < atomic.LoadUint64(&best); counter += uint64(workers) {
+				address, err := addressOf(s.cryptoRepository.VanityEntropy(entropy, counter))
+				if err != nil {
+					errOnce.Do(func() { searchErr = err })
+					atomic.StoreUint64(&best, 0)
+					return
+				}
+				if !strings.HasPrefix(address, prefix) || !strings.HasSuffix(address, suffix) {
+					continue
+				}
+				for {
+					current := atomic.LoadUint64(&best)
+					if counter >= current || atomic.CompareAndSwapUint64(&best, current, counter) {
+						return
+					}
+				}
+			}
+		}(uint64(worker + 1))
+	}
+	wg.Wait()
+
+	if searchErr != nil {
+		s.logger.LogOnInternalErrorWithContext(s.logger.GetContext(), searchErr)
+		return 0, searchErr
+	}
+	if best == math.MaxUint64 {
+		err = errors.New(fmt.Sprintf("No address matching the vanity pattern %s found in %d tries", arguments.Vanity, maxTries))
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return 0, err
+	}
+
+	s.logger.LogOnExitWithContext(s.logger.GetContext(), best)
+	return best, nil
+}
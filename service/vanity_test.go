@@ -0,0 +1,112 @@
+package service
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	. "swisswallet/constants"
+	"swisswallet/model"
+)
+
+func TestVanityAddresserMatchesWalletFromEntropy(t *testing.T) {
+	s, _, _ := newFakeService()
+
+	for _, language := range s.simpleUtils.GetSupportedLanguages() {
+		for _, output := range []string{RAW_OUTPUT, MNEMONIC_OUTPUT} {
+			arguments := testArguments("ethereum", MINIMUM_DIFFICULTY, language, output)
+			addressOf := s.newVanityAddresser(arguments)
+			for counter := uint64(1); counter <= 3; counter++ {
+				entropy := s.cryptoRepository.VanityEntropy(bytes.Repeat([]byte{0x5a}, 32), counter)
+				address, err := addressOf(entropy)
+				if err != nil {
+					t.Fatal(err)
+				}
+				wallet, err := s.WalletFromEntropy(entropy, arguments)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if address != strings.ToLower(strings.TrimPrefix(wallet.Address, "0x")) {
+					t.Errorf("%s %s counter %d: vanity address %s, wallet address %s", language, output, counter, address, wallet.Address)
+				}
+			}
+		}
+	}
+}
+
+func TestSearchVanityCounterFindsTheLowestMatch(t *testing.T) {
+	s, _, _ := newFakeService()
+	entropy := bytes.Repeat([]byte{0x5a}, 32)
+
+	for _, output := range []string{RAW_OUTPUT, MNEMONIC_OUTPUT} {
+		arguments := testArguments("ethereum", MINIMUM_DIFFICULTY, ENGLISH_LANGUAGE, output)
+		arguments.Vanity = "a*b"
+
+		var counter uint64
+		var err error
+		var stderr string
+		stdout := captureOutput(t, &os.Stdout, func() {
+			stderr = captureOutput(t, &os.Stderr, func() {
+				counter, err = s.SearchVanityCounter(entropy, arguments)
+			})
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stdout != "" || !strings.HasPrefix(stderr, "Searching for a vanity address matching a*b") {
+			t.Errorf("%s: progress went to stdout %q and stderr %q, want only stderr", output, stdout, stderr)
+		}
+		for tried := uint64(1); tried <= counter; tried++ {
+			wallet, err := s.WalletFromEntropy(s.cryptoRepository.VanityEntropy(entropy, tried), arguments)
+			if err != nil {
+				t.Fatal(err)
+			}
+			address := strings.ToLower(wallet.Address)
+			matches := strings.HasPrefix(address, "0xa") && strings.HasSuffix(address, "b")
+			if matches != (tried == counter) {
+				t.Errorf("%s: counter %d gives %s, search returned %d", output, tried, wallet.Address, counter)
+			}
+		}
+	}
+}
+
+func TestCheckVanityArguments(t *testing.T) {
+	s, _, _ := newFakeService()
+
+	tests := []struct {
+		output        string
+		vanity        string
+		vanityCounter uint64
+		wantErr       bool
+	}{
+		{RAW_OUTPUT, "", 7, false},
+		{RAW_OUTPUT, "abcdef", 0, false},
+		{RAW_OUTPUT, "abc*def", 0, false},
+		{RAW_OUTPUT, "abcdef0", 0, true},
+		{MNEMONIC_OUTPUT, "ab*cd", 0, false},
+		{MNEMONIC_OUTPUT, "abcde", 0, true},
+		{RAW_OUTPUT, "abc", 7, true},
+		{RAW_OUTPUT, "xyz", 0, true},
+	}
+	for _, test := range tests {
+		arguments := model.Arguments{Output: test.output, Vanity: test.vanity, VanityCounter: test.vanityCounter}
+		err := s.CheckVanityArguments(arguments)
+		if (err != nil) != test.wantErr {
+			t.Errorf("CheckVanityArguments(%s, %q, %d) = %v, want error %v", test.output, test.vanity, test.vanityCounter, err, test.wantErr)
+		}
+	}
+}
+
+func TestPaperRegenerationHintHasVanityCounter(t *testing.T) {
+	arguments := testArguments("ethereum", MINIMUM_DIFFICULTY, ENGLISH_LANGUAGE, RAW_OUTPUT)
+
+	hint := paperRegenerationHint(model.PaperWallet{}, arguments)
+	if strings.Contains(hint, "-vanity-counter") {
+		t.Errorf("hint %q has a vanity counter for a wallet without one", hint)
+	}
+	hint = paperRegenerationHint(model.PaperWallet{VanityCounter: 42}, arguments)
+	if !strings.HasSuffix(hint, " -vanity-counter 42") {
+		t.Errorf("hint %q does not end with -vanity-counter 42", hint)
+	}
+}
@@ -1,10 +1,11 @@
 #!/bin/bash  
     
-echo "Welcome to the swisswallet. You can currently generate, encrypt or decrypt a wallet." 
+echo "Welcome to the swisswallet. You can currently generate, encrypt, decrypt or verify a wallet." 
 echo "Select one mode:"
 echo "[1] Generate"
 echo "[2] Encrypt"
 echo "[3] Decrypt"
+echo "[4] Verify"
 read -p "Mode: " mode  
 
 echo ""
@@ -54,6 +55,16 @@ elif [ $mode == "3" ]; then
     echo ""
     echo "Decrypting the mnemonic with your password and address..."
     go run main.go decrypt -p $password -m "$mnemonic" -a $address
+elif [ $mode == "4" ]; then
+    echo "Please, type the salt used when the wallet was generated:"
+    read -sp "Type your salt: " salt  
+    echo ""
+    echo ""
+    echo "Please, type the address that your password and salt should reproduce:"
+    read -p "Type your address: " address  
+    echo ""
+    echo "Verifying that your password and salt reproduce the address..."
+    go run main.go verify -p $password -s $salt -a $address
 else
   echo "Mode not supported"
 fi
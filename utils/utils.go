@@ -1,13 +1,17 @@
 package utils
 
 import (
+	"bufio"
 	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 	. "swisswallet/constants"
 	"swisswallet/logger"
 	"swisswallet/model"
+
+	"golang.org/x/term"
 )
 
 type SimpleUtils interface {
@@ -15,10 +19,26 @@ type SimpleUtils interface {
 	PrintHelpModeAndExit()
 	PrintHelpParamsAndExit(mode string)
 	ExitWithError(err error)
+	ReadSecret(prompt string) (string, error)
+	Confirm(prompt string) (bool, error)
 	GetSupportedModes() []string
+	GetPasswordlessModes() []string
 	GetSupportedOutputs() []string
 	GetSupportedLanguages() []string
+	GetSupportedKeystoreKdfs() []string
+	GetSupportedBip38Modes() []string
+	GetSupportedAddressTypes() []string
+	GetSupportedProfiles() []string
+	GetSupportedSlip39Secrets() []string
+	GetSupportedSaltPolicies() []string
+	GetSupportedWordlists() []string
+	GetSupportedPaperFormats() []string
+	GetSupportedQRLevels() []string
+	GetSupportedSeedQRFormats() []string
 	GetSupportedDifficulties() []string
+	GetSupportedLoggingLevels() []string
+	GetSupportedLoggingFormats() []string
+	GetDefaultLoggingLevel() string
 	CheckIfSupported(str string, supportedStrArray []string) error
 	IsEmptyString(str string) bool
 	IsEmptyArray(array []string) bool
@@ -35,13 +55,51 @@ func NewSimpleUtils(logger *logger.Logger) SimpleUtils {
 	}
 }
 
-var supportedModes = []string{GENERATE_MODE, DECRYPT_MODE, ENCRYPT_MODE}
-var supportedOutputs = []string{RAW_OUTPUT, MNEMONIC_OUTPUT}
+var supportedModes = []string{GENERATE_MODE, DECRYPT_MODE, ENCRYPT_MODE, VERIFY_MODE, SPLIT_MODE, COMBINE_MODE, MULTISIG_MODE, SIGN_TX_MODE, SIGN_PSBT_MODE, SIGN_MESSAGE_MODE, VERIFY_MESSAGE_MODE, VALIDATE_MODE, PASSPHRASE_MODE, SELFTEST_MODE}
+var passwordlessModes = []string{COMBINE_MODE, MULTISIG_MODE, VERIFY_MESSAGE_MODE, VALIDATE_MODE, PASSPHRASE_MODE, SELFTEST_MODE}
+var supportedOutputs = []string{RAW_OUTPUT, MNEMONIC_OUTPUT, KEYSTORE_OUTPUT}
+var supportedKeystoreKdfs = []string{SCRYPT_KEYSTORE_KDF, PBKDF2_KEYSTORE_KDF}
+var supportedPaperFormats = []string{HTML_PAPER_FORMAT, SVG_PAPER_FORMAT, PDF_PAPER_FORMAT}
+var supportedQRLevels = []string{QR_LOW_LEVEL, QR_MEDIUM_LEVEL, QR_HIGH_LEVEL, QR_HIGHEST_LEVEL}
+var supportedSeedQRFormats = []string{NO_SEEDQR_FORMAT, STANDARD_SEEDQR_FORMAT, COMPACT_SEEDQR_FORMAT}
+
+// EC-multiply keys are created by whoever holds an intermediate code, so they
+// can never be the key a password derives. Decrypt opens them anyway, it tells
+// the two kinds apart by their prefix.
+var supportedBip38Modes = []string{BIP38_NON_EC_MULTIPLY_MODE}
+var supportedAddressTypes = []string{P2PKH_ADDRESS_TYPE, P2SH_P2WPKH_ADDRESS_TYPE, P2WPKH_ADDRESS_TYPE, P2TR_ADDRESS_TYPE}
+var supportedWordlists = []string{EFF_LARGE_WORDLIST, EFF_SHORT_WORDLIST, BIP39_WORDLIST}
+var supportedSaltPolicies = []string{REQUIRED_SALT_POLICY, EMAIL_SALT_POLICY, VERBATIM_SALT_POLICY}
+var supportedProfiles = []string{REAL_PROFILE, DECOY_PROFILE}
+var supportedSlip39Secrets = []string{ENTROPY_SLIP39_SECRET, SEED_SLIP39_SECRET}
 var supportedLanguages = []string{ENGLISH_LANGUAGE, SPANISH_LANGUAGE, CHINESE_TRADITIONAL_LANGUAGE, CHINESE_SIMPLIFIED_LANGUAGE, CZECH_LANGUAGE, FRENCH_LANGUAGE, ITALIAN_LANGUAGE, JAPANESE_LANGUAGE, KOREAN_LANGUAGE}
 var supportedDifficulties = []string{MINIMUM_DIFFICULTY, LOW_DIFFICULTY, NORMAL_DIFFICULTY, STRONG_DIFFICULTY, SUPER_STRONG_DIFFICULTY, RIDICULOUSLY_STRONG_DIFFICULTY}
+var supportedLoggingLevels = []string{"panic", "fatal", "error", "warn", "info", "debug", "trace"}
+var supportedLoggingFormats = []string{JSON_LOGGING_FORMAT, TEXT_LOGGING_FORMAT}
 
 var fs = flag.NewFlagSet("options", flag.ContinueOnError)
 
+// Secrets piped through stdin are read line by line from a single buffered
+// reader, so consecutive prompts do not lose buffered input.
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// sharesFlag collects every -share occurrence, one SLIP-0039 mnemonic each.
+type sharesFlag struct {
+	shares *[]string
+}
+
+func (f *sharesFlag) String() string {
+	if f.shares == nil {
+		return ""
+	}
+	return fmt.Sprintf("%d shares", len(*f.shares))
+}
+
+func (f *sharesFlag) Set(share string) error {
+	*f.shares = append(*f.shares, share)
+	return nil
+}
+
 func (s *simpleUtils) GetArguments() (*model.Arguments, string, []string) {
 	s.logger.LogOnEntryWithContext(s.logger.GetContext(), nil)
 	arguments := new(model.Arguments)
@@ -61,13 +119,65 @@ func (s *simpleUtils) GetArguments() (*model.Arguments, string, []string) {
 
 	fs.StringVar(&arguments.Password, "p", "", "swisswallet password")
 	fs.StringVar(&arguments.Salt, "s", "", "swisswallet salt")
+	fs.StringVar(&arguments.SaltPolicy, "salt-policy", REQUIRED_SALT_POLICY, fmt.Sprintf("Salt policy %s: required rejects an empty salt, email also trims and lowercases an email address, verbatim takes -s as is", supportedSaltPolicies))
+	fs.BoolVar(&arguments.NoNormalize, "no-normalize", false, "Use the password and salt bytes as typed, without NFKD normalization, to regenerate wallets of older versions")
+	fs.BoolVar(&arguments.IKnowWhatImDoing, "i-know-what-im-doing", false, "Generate or encrypt with a password the strength estimator considers crackable")
+	fs.StringVar(&arguments.Keyfile, "keyfile", "", "File, e.g. a hex secret on a USB stick, whose SHA-256 is mixed into both KDFs as a second factor. Required again to regenerate the wallet")
 	fs.StringVar(&arguments.Mnemonic, "m", "", "24 words mnemonic")
 	fs.StringVar(&arguments.Key, "k", "", "Private key")
 	fs.StringVar(&arguments.Address, "a", "", "Currency address")
+	fs.Int64Var(&arguments.ChecksumChainID, "checksum-chain-id", 0, "Print and check Ethereum addresses with the EIP-1191 checksum of this chain ID, e.g. 30 for RSK. EIP-55 if 0")
 	fs.StringVar(&arguments.Currency, "c", "ethereum", "Currency to use. Currently supported are [testnet|bitcoin|ethereum|litecoin|monero|cosmos|polkadot")
 	fs.StringVar(&arguments.Difficulty, "d", SUPER_STRONG_DIFFICULTY, fmt.Sprintf("Difficulty of the hashing algorithms. Currently supported are %s", supportedDifficulties))
 	fs.StringVar(&arguments.Language, "l", ENGLISH_LANGUAGE, fmt.Sprintf("Mnemonic language %s", supportedLanguages))
 	fs.StringVar(&arguments.Output, "o", MNEMONIC_OUTPUT, fmt.Sprintf("Output wallet format %s", supportedOutputs))
+	fs.StringVar(&arguments.Keystore, "keystore", "", "Ethereum V3 keystore file to read the private key from in encrypt mode")
+	fs.StringVar(&arguments.KeystoreOut, "keystore-out", "", "File to write the keystore to with -o keystore. Printed to stdout if empty")
+	fs.StringVar(&arguments.KeystorePassword, "keystore-password", "", "Password protecting the keystore file")
+	fs.StringVar(&arguments.KeystoreKdf, "keystore-kdf", SCRYPT_KEYSTORE_KDF, fmt.Sprintf("Key derivation function of the keystore file %s", supportedKeystoreKdfs))
+	fs.StringVar(&arguments.Bip38Passphrase, "bip38-passphrase", "", "Passphrase to emit (generate) or read (decrypt) the Bitcoin private key as a BIP38 6P... key")
+	fs.StringVar(&arguments.Bip38Mode, "bip38-mode", BIP38_NON_EC_MULTIPLY_MODE, fmt.Sprintf("BIP38 encryption mode %s, decrypt also opens EC-multiply keys", supportedBip38Modes))
+	fs.StringVar(&arguments.Paper, "paper", "", fmt.Sprintf("Print-ready paper wallet file to write in generate mode, format taken from the extension %s", supportedPaperFormats))
+	fs.BoolVar(&arguments.QR, "qr", false, "Render the address as a QR code in the terminal in generate mode")
+	fs.StringVar(&arguments.QRLevel, "qr-level", QR_MEDIUM_LEVEL, fmt.Sprintf("QR error correction level for address and paper wallet QR codes, SeedQR always uses low %s", supportedQRLevels))
+	fs.StringVar(&arguments.QRSeed, "qr-seed", NO_SEEDQR_FORMAT, fmt.Sprintf("Also render the english mnemonic as a SeedQR with -qr %s", supportedSeedQRFormats))
+	fs.BoolVar(&arguments.QRInvert, "qr-invert", false, "Draw dark modules as blocks, for terminals with a light background")
+	fs.StringVar(&arguments.Slip39Groups, "slip39-groups", "2of3", "Comma separated SLIP-0039 groups as member threshold of member count, e.g. 2of3,3of5")
+	fs.IntVar(&arguments.Slip39GroupThreshold, "slip39-group-threshold", 1, "Number of SLIP-0039 groups needed to combine the secret")
+	fs.StringVar(&arguments.Slip39Passphrase, "slip39-passphrase", "", "SLIP-0039 passphrase protecting the shares, printable ASCII only")
+	fs.IntVar(&arguments.Slip39IterationExponent, "slip39-iteration-exponent", 1, "SLIP-0039 iteration exponent, each step doubles the PBKDF2 work")
+	fs.Var(&sharesFlag{shares: &arguments.Shares}, "share", "SLIP-0039 share to combine, repeat once per share")
+	fs.StringVar(&arguments.Slip39Secret, "slip39-secret", ENTROPY_SLIP39_SECRET, fmt.Sprintf("What combined shares hold %s: SwissWallet entropy from split, or a BIP32 seed from another wallet such as Trezor", supportedSlip39Secrets))
+	fs.IntVar(&arguments.MultisigThreshold, "multisig-threshold", 2, "Number of cosigner signatures a multisig spend needs")
+	fs.IntVar(&arguments.MultisigCosigners, "multisig-cosigners", 3, "Number of multisig cosigners, each prompted for their password and salt")
+	fs.IntVar(&arguments.AddressCount, "address-count", 5, "Number of receive addresses to print")
+	fs.StringVar(&arguments.Transaction, "tx", "", "Unsigned Ethereum transaction to sign in sign-tx mode, as JSON or RLP hex")
+	fs.StringVar(&arguments.TransactionFile, "tx-file", "", "File holding the unsigned Ethereum transaction to sign in sign-tx mode")
+	fs.Int64Var(&arguments.ChainID, "chain-id", 1, "Ethereum chain ID the transaction is signed for")
+	fs.StringVar(&arguments.Psbt, "psbt", "", "Base64 PSBT to sign in sign-psbt mode")
+	fs.StringVar(&arguments.PsbtFile, "psbt-file", "", "PSBT file to sign in sign-psbt mode, binary or base64")
+	fs.StringVar(&arguments.PsbtOut, "psbt-out", "", "File to write the signed binary PSBT to in sign-psbt mode")
+	fs.BoolVar(&arguments.Yes, "yes", false, "Sign without asking to confirm the outputs and fee")
+	fs.StringVar(&arguments.Message, "message", "", "Message to sign or verify in sign-message and verify-message modes")
+	fs.StringVar(&arguments.MessageFile, "message-file", "", "File holding the message to sign or verify")
+	fs.StringVar(&arguments.TypedData, "typed-data", "", "File holding EIP-712 typed data JSON to sign or verify instead of a message")
+	fs.StringVar(&arguments.Signature, "signature", "", "Signature to check in verify-message mode")
+	fs.StringVar(&arguments.AddressType, "address-type", P2WPKH_ADDRESS_TYPE, fmt.Sprintf("Bitcoin address type to sign messages with %s", supportedAddressTypes))
+	fs.IntVar(&arguments.AddressIndex, "address-index", 0, "Index of the Bitcoin receive address to sign messages with")
+	fs.StringVar(&arguments.Vanity, "vanity", "", "Search for an Ethereum address matching this hex pattern: prefix, *suffix or prefix*suffix")
+	fs.Uint64Var(&arguments.VanityCounter, "vanity-counter", 0, "Vanity counter printed by a -vanity search, to derive the same wallet again")
+	fs.StringVar(&arguments.Profile, "profile", REAL_PROFILE, fmt.Sprintf("Wallet the password and salt derive %s. A decoy is the wallet of the duress password, the password followed by -duress-suffix, to hand over under duress", supportedProfiles))
+	fs.IntVar(&arguments.DecoyIndex, "decoy-index", 1, "Which decoy wallet -profile decoy derives, from 1. Decoy N > 1 has N appended to its duress password")
+	fs.IntVar(&arguments.Decoys, "decoys", 0, "Also list the addresses of this many decoy wallets in generate mode, to fund them with small amounts. Each costs one more KDF run")
+	fs.StringVar(&arguments.DuressSuffix, "duress-suffix", DEFAULT_DURESS_SUFFIX, "Appended to the password to form the duress password of a decoy wallet")
+	fs.StringVar(&arguments.Wordlist, "wordlist", EFF_LARGE_WORDLIST, fmt.Sprintf("Wordlist of passphrase mode %s, bip39 uses the -l language", supportedWordlists))
+	fs.IntVar(&arguments.Words, "words", 8, "Number of words of the generated passphrase")
+	fs.StringVar(&arguments.Separator, "separator", " ", "Separator between the words of the generated passphrase")
+	fs.BoolVar(&arguments.AndGenerate, "and-generate", false, "Generate the wallet of the new passphrase and -s salt right away in passphrase mode")
+	fs.BoolVar(&arguments.Descriptors, "descriptors", false, "Print public and private BIP380 output descriptors of the Bitcoin accounts of the mnemonic in generate mode")
+	fs.StringVar(&arguments.LogLevel, "log-level", s.GetDefaultLoggingLevel(), fmt.Sprintf("Logging level %s. Defaults to $%s if set", supportedLoggingLevels, LOGGING_LEVEL_ENV))
+	fs.StringVar(&arguments.LogFormat, "log-format", JSON_LOGGING_FORMAT, fmt.Sprintf("Logging format %s", supportedLoggingFormats))
+	fs.StringVar(&arguments.LogFile, "log-file", "", "Append logs to this file instead of stderr")
 	fs.Parse(os.Args[2:])
 
 	s.logger.LogOnExitWithContext(s.logger.GetContext(), arguments, mode, fs.Args())
@@ -97,18 +207,83 @@ func (s *simpleUtils) ExitWithError(err error) {
 func PrintModes() {
 	fmt.Println("Supported modes with required arguments:")
 	fmt.Println("- \"generate mnemonic\": swisswallet generate -p password -s salt")
+	fmt.Println("- \"generate with email salt\": swisswallet generate -p password -s user@example.com -salt-policy email")
 	fmt.Println("- \"generate raw key\": swisswallet generate -o raw -p password -s salt")
 	fmt.Println("- \"encrypt mnemonic\": swisswallet encrypt -m mnemonic -p password")
+	fmt.Println("- \"generate keystore\": swisswallet generate -o keystore -p password -s salt -keystore-password keystorepassword -keystore-out keystore.json")
+	fmt.Println("- \"generate BIP38 key\": swisswallet generate -c bitcoin -o raw -p password -s salt -bip38-passphrase passphrase")
+	fmt.Println("- \"generate paper wallet\": swisswallet generate -o mnemonic -p password -s salt -paper wallet.pdf")
+	fmt.Println("- \"generate with QR codes\": swisswallet generate -o mnemonic -p password -s salt -qr -qr-seed compact")
+	fmt.Println("- \"generate Bitcoin descriptors\": swisswallet generate -c bitcoin -o mnemonic -p password -s salt -descriptors")
+	fmt.Println("- \"generate vanity wallet\": swisswallet generate -o raw -p password -s salt -vanity cafe, then -vanity-counter N to derive it again")
+	fmt.Println("- \"generate with decoy wallets\": swisswallet generate -p password -s salt -decoys 3, then -profile decoy -decoy-index N or the duress password to derive decoy N")
+	fmt.Println("- \"generate with keyfile\": swisswallet generate -p password -s salt -keyfile /media/usb/keyfile")
 	fmt.Println("- \"encrypt raw key\": swisswallet encrypt -o raw -k privatekey -p password")
+	fmt.Println("- \"encrypt keystore\": swisswallet encrypt -o raw -keystore keystore.json -keystore-password keystorepassword -p password")
 	fmt.Println("- \"decrypt mnemonic\": swisswallet decrypt -m mnemonic -p password -a address")
 	fmt.Println("- \"decrypt raw key\": swisswallet decrypt -o raw -k privatekey -p password -a address")
+	fmt.Println("- \"decrypt BIP38 key\": swisswallet decrypt -c bitcoin -o raw -k 6Pkey -p passphrase -a address")
+	fmt.Println("- \"verify password\": swisswallet verify -p password -s salt -a address")
+	fmt.Println("- \"split into SLIP-0039 shares\": swisswallet split -o raw -p password -s salt -slip39-groups 2of3,3of5 -slip39-group-threshold 1")
+	fmt.Println("- \"combine SLIP-0039 shares\": swisswallet combine -o raw -share \"first share\" -share \"second share\"")
+	fmt.Println("- \"combine SLIP-0039 shares from a Trezor\": swisswallet combine -o raw -slip39-secret seed -share \"first share\" -share \"second share\"")
+	fmt.Println("- \"multisig descriptor\": swisswallet multisig -c bitcoin -multisig-threshold 2 -multisig-cosigners 3, then enter each cosigner password and salt")
+	fmt.Println("- \"sign Ethereum transaction\": swisswallet sign-tx -p password -s salt -chain-id 1 -tx-file unsigned.json")
+	fmt.Println("- \"sign Bitcoin PSBT\": swisswallet sign-psbt -c bitcoin -p password -s salt -psbt-file unsigned.psbt -psbt-out signed.psbt")
+	fmt.Println("- \"sign message\": swisswallet sign-message -c bitcoin -p password -s salt -address-type p2wpkh -message \"I own this address\"")
+	fmt.Println("- \"sign EIP-712 typed data\": swisswallet sign-message -p password -s salt -typed-data typed.json")
+	fmt.Println("- \"verify message\": swisswallet verify-message -c bitcoin -a address -message \"I own this address\" -signature signature")
+	fmt.Println("- \"validate address\": swisswallet validate -c bitcoin -a address")
+	fmt.Println("- \"generate passphrase\": swisswallet passphrase -wordlist eff-large -words 8, add -and-generate -s salt to generate its wallet")
+	fmt.Println("- \"self-test\": swisswallet selftest")
 	fmt.Println()
 }
 
+// ReadSecret prompts on stderr and reads a line without echo when stdin is a
+// terminal, or the next line of stdin otherwise.
+func (s *simpleUtils) ReadSecret(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		secret, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+			return "", err
+		}
+		return string(secret), nil
+	}
+
+	line, err := stdinReader.ReadString('\n')
+	if err != nil && line == "" {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// Confirm prompts on stderr and reads an answer from stdin, only "y" and "yes"
+// count as agreement.
+func (s *simpleUtils) Confirm(prompt string) (bool, error) {
+	fmt.Fprint(os.Stderr, prompt)
+
+	line, err := stdinReader.ReadString('\n')
+	if err != nil && line == "" {
+		s.logger.LogOnBadRequestErrorWithContext(s.logger.GetContext(), err)
+		return false, err
+	}
+	answer := strings.ToLower(strings.TrimSpace(line))
+	return answer == "y" || answer == "yes", nil
+}
+
 func (s *simpleUtils) GetSupportedModes() []string {
 	return supportedModes
 }
 
+func (s *simpleUtils) GetPasswordlessModes() []string {
+	return passwordlessModes
+}
+
 func (s *simpleUtils) GetSupportedOutputs() []string {
 	return supportedOutputs
 }
@@ -117,10 +292,65 @@ func (s *simpleUtils) GetSupportedLanguages() []string {
 	return supportedLanguages
 }
 
+func (s *simpleUtils) GetSupportedKeystoreKdfs() []string {
+	return supportedKeystoreKdfs
+}
+
+func (s *simpleUtils) GetSupportedBip38Modes() []string {
+	return supportedBip38Modes
+}
+
+func (s *simpleUtils) GetSupportedAddressTypes() []string {
+	return supportedAddressTypes
+}
+
+func (s *simpleUtils) GetSupportedSaltPolicies() []string {
+	return supportedSaltPolicies
+}
+
+func (s *simpleUtils) GetSupportedProfiles() []string {
+	return supportedProfiles
+}
+
+func (s *simpleUtils) GetSupportedSlip39Secrets() []string {
+	return supportedSlip39Secrets
+}
+
+func (s *simpleUtils) GetSupportedWordlists() []string {
+	return supportedWordlists
+}
+
+func (s *simpleUtils) GetSupportedPaperFormats() []string {
+	return supportedPaperFormats
+}
+
+func (s *simpleUtils) GetSupportedQRLevels() []string {
+	return supportedQRLevels
+}
+
+func (s *simpleUtils) GetSupportedSeedQRFormats() []string {
+	return supportedSeedQRFormats
+}
+
 func (s *simpleUtils) GetSupportedDifficulties() []string {
 	return supportedDifficulties
 }
 
+func (s *simpleUtils) GetSupportedLoggingLevels() []string {
+	return supportedLoggingLevels
+}
+
+func (s *simpleUtils) GetSupportedLoggingFormats() []string {
+	return supportedLoggingFormats
+}
+
+func (s *simpleUtils) GetDefaultLoggingLevel() string {
+	if level := os.Getenv(LOGGING_LEVEL_ENV); level != "" {
+		return level
+	}
+	return LOGGING_LEVEL
+}
+
 func (s *simpleUtils) CheckIfSupported(str string, supportedStrArray []string) error {
 	s.logger.LogOnEntryWithContext(s.logger.GetContext(), str, supportedStrArray)
 